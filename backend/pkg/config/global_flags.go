@@ -12,6 +12,20 @@ var ImagesStoreDir string = "/var/lib/ar/images"
 var LoadTmpRoot string = "/tmp"
 var NodesDir string = "/var/lib/ar/nodes"
 
+// 流水线任务内同时运行的最大步骤数，0 表示不限制（由 pipeline run / task resume / server start 的 --max-parallel 设置）。
+var MaxParallel int = 0
+
+// 流水线步骤容器的默认资源限制，步骤模板中的 resources 未设置的字段使用这些值；为空 / 0 表示不限制。
+var StepCPU string
+var StepMemory string
+var StepPids int64
+
+// 流水线步骤 mounts 可挂载的宿主机路径白名单（目录包含其下的全部路径），为空时不允许挂载宿主机路径。
+var AllowedMountPaths []string
+
+// 密钥存储（ar secret）加密密钥文件，首次写入密钥时自动生成（仅 root 可读）。
+var SecretKeyFile string = "/var/lib/ar/secret.key"
+
 func InitGlobalFlags(command *cobra.Command) {
 	command.PersistentFlags().BoolVar(&Debug, "debug", false, "enable debug logging")
 	command.PersistentFlags().StringVar(&OciRuntimeRoot, "oci-runtime-root", "/var/lib/ar/runc", "OCI runtime state root directory")
@@ -19,4 +33,9 @@ func InitGlobalFlags(command *cobra.Command) {
 	command.PersistentFlags().StringVar(&ImagesStoreDir, "images-store-dir", "/var/lib/ar/images", "directory used to store loaded OCI images")
 	command.PersistentFlags().StringVar(&LoadTmpRoot, "load-tmp-root", "/tmp", "temporary root directory used by ar load")
 	command.PersistentFlags().StringVar(&NodesDir, "nodes-dir", "/var/lib/ar/nodes", "nodes directory")
+	command.PersistentFlags().StringVar(&StepCPU, "step-cpu", "", "default CPU limit of pipeline step containers, e.g. 0.5 or 500m (empty means unlimited)")
+	command.PersistentFlags().StringVar(&StepMemory, "step-memory", "", "default memory limit of pipeline step containers, e.g. 512Mi (empty means unlimited)")
+	command.PersistentFlags().Int64Var(&StepPids, "step-pids", 0, "default max number of processes in pipeline step containers (0 means unlimited)")
+	command.PersistentFlags().StringSliceVar(&AllowedMountPaths, "allowed-mount-paths", nil, "host paths that pipeline steps may mount, e.g. /etc/hosts,/run/ssh-agent.sock (empty disallows host path mounts)")
+	command.PersistentFlags().StringVar(&SecretKeyFile, "secret-key-file", "/var/lib/ar/secret.key", "key file used to encrypt secrets stored by ar secret (created on first use)")
 }
@@ -67,6 +67,15 @@ func StopAndRemoveOCIContainers(root, prefix string) error {
 			// 继续尝试 Destroy，以便清理残留 state
 		}
 
+		// 已冻结（任务暂停）的容器先解冻，否则信号要等到解冻后才会处理
+		if status == libcontainer.Paused {
+			if err := c.Resume(); err != nil {
+				logrus.Warnf("解冻容器 %s 失败: %v", id, err)
+			} else {
+				status = libcontainer.Running
+			}
+		}
+
 		// 对 Running/Created 状态的容器先发送 SIGTERM，再必要时 SIGKILL。
 		if status == libcontainer.Running || status == libcontainer.Created {
 			if err := c.Signal(syscall.SIGTERM); err != nil {
@@ -121,3 +130,81 @@ func IsNotExistErr(err error) bool {
 		strings.Contains(msg, "not exist") ||
 		strings.Contains(msg, string(filepath.Separator)+"state")
 }
+
+// OCIContainerStatus 返回 root 下指定 ID 容器的状态（created | running | paused | stopped 等），容器不存在时返回空字符串。
+func OCIContainerStatus(root, id string) (string, error) {
+	c, err := libcontainer.Load(root, id)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrNotExist) || IsNotExistErr(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	status, err := c.Status()
+	if err != nil {
+		return "", err
+	}
+	return status.String(), nil
+}
+
+// DestroyOCIContainer 强制停止（SIGKILL）并删除 root 下指定 ID 的容器，容器不存在时直接返回。
+func DestroyOCIContainer(root, id string) error {
+	c, err := libcontainer.Load(root, id)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrNotExist) || IsNotExistErr(err) {
+			return nil
+		}
+		return err
+	}
+	if status, err := c.Status(); err == nil && status != libcontainer.Stopped {
+		if status == libcontainer.Paused {
+			_ = c.Resume()
+		}
+		if err := c.Signal(syscall.SIGKILL); err != nil {
+			logrus.Warnf("向容器 %s 发送 SIGKILL 失败: %v", id, err)
+		}
+	}
+	if err := c.Destroy(); err != nil && !IsNotExistErr(err) {
+		return err
+	}
+	return nil
+}
+
+// PauseOCIContainer 通过 cgroup freezer 冻结 root 下指定 ID 的容器（libcontainer Pause），返回是否已冻结。
+// 容器不存在或未处于 running 状态（尚未启动、已退出或已冻结）时返回 false 且不报错。
+func PauseOCIContainer(root, id string) (bool, error) {
+	c, err := libcontainer.Load(root, id)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrNotExist) || IsNotExistErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	status, err := c.Status()
+	if err != nil {
+		return false, err
+	}
+	if status != libcontainer.Running {
+		return false, nil
+	}
+	if err := c.Pause(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResumeOCIContainer 解冻 root 下指定 ID 的已冻结容器（libcontainer Resume），容器不存在或未冻结时直接返回。
+func ResumeOCIContainer(root, id string) error {
+	c, err := libcontainer.Load(root, id)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrNotExist) || IsNotExistErr(err) {
+			return nil
+		}
+		return err
+	}
+	status, err := c.Status()
+	if err != nil || status != libcontainer.Paused {
+		return err
+	}
+	return c.Resume()
+}
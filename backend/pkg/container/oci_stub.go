@@ -8,3 +8,23 @@ import "errors"
 func StopAndRemoveOCIContainers(root, prefix string) error {
 	return errors.New("not implemented on non-linux platform")
 }
+
+// OCIContainerStatus 非 Linux 平台上未实现。
+func OCIContainerStatus(root, id string) (string, error) {
+	return "", errors.New("not implemented on non-linux platform")
+}
+
+// DestroyOCIContainer 非 Linux 平台上未实现。
+func DestroyOCIContainer(root, id string) error {
+	return errors.New("not implemented on non-linux platform")
+}
+
+// PauseOCIContainer 非 Linux 平台上未实现。
+func PauseOCIContainer(root, id string) (bool, error) {
+	return false, errors.New("not implemented on non-linux platform")
+}
+
+// ResumeOCIContainer 非 Linux 平台上未实现。
+func ResumeOCIContainer(root, id string) error {
+	return errors.New("not implemented on non-linux platform")
+}
@@ -39,6 +39,7 @@ type Config struct {
 
 type ResolverRoot interface {
 	Mutation() MutationResolver
+	Node() NodeResolver
 	Query() QueryResolver
 }
 
@@ -58,24 +59,37 @@ type ComplexityRoot struct {
 	}
 
 	Mutation struct {
-		AddNode        func(childComplexity int, input model.AddNodeInput) int
-		DeleteNode     func(childComplexity int, input model.DeleteNodeInput) int
-		ImageDelete    func(childComplexity int, name string) int
-		ImagePrune     func(childComplexity int, all *bool) int
-		ResumePipeline func(childComplexity int, taskID string) int
-		RunPipeline    func(childComplexity int, input model.RunPipelineInput) int
-		StopPipeline   func(childComplexity int, taskID string) int
-		UpdateNode     func(childComplexity int, input model.UpdateNodeInput) int
+		AddNode             func(childComplexity int, input model.AddNodeInput) int
+		ApprovePipelineStep func(childComplexity int, taskID string, step *string, approver *string, comment *string) int
+		DeleteNode          func(childComplexity int, input model.DeleteNodeInput) int
+		ImageDelete         func(childComplexity int, name string) int
+		ImagePrune          func(childComplexity int, all *bool) int
+		PausePipeline       func(childComplexity int, taskID string, by *string) int
+		RejectPipelineStep  func(childComplexity int, taskID string, step *string, approver *string, comment *string) int
+		ResumePipeline      func(childComplexity int, taskID string, from *string, only *string, waitForNodes *bool) int
+		RunPipeline         func(childComplexity int, input model.RunPipelineInput) int
+		SkipPipelineStep    func(childComplexity int, taskID string, step string) int
+		StopPipeline        func(childComplexity int, taskID string) int
+		UnpausePipeline     func(childComplexity int, taskID string) int
+		UpdateNode          func(childComplexity int, input model.UpdateNodeInput) int
 	}
 
 	Node struct {
 		IP       func(childComplexity int) int
 		Labels   func(childComplexity int) int
+		Lease    func(childComplexity int) int
 		Password func(childComplexity int) int
 		Port     func(childComplexity int) int
 		Username func(childComplexity int) int
 	}
 
+	NodeLease struct {
+		AcquiredAt   func(childComplexity int) int
+		Pid          func(childComplexity int) int
+		PipelineName func(childComplexity int) int
+		TaskID       func(childComplexity int) int
+	}
+
 	NodeList struct {
 		Nodes func(childComplexity int) int
 	}
@@ -85,18 +99,72 @@ type ComplexityRoot struct {
 		Name func(childComplexity int) int
 	}
 
+	PipelinePlan struct {
+		Always        func(childComplexity int) int
+		FailurePolicy func(childComplexity int) int
+		Levels        func(childComplexity int) int
+		MaxParallel   func(childComplexity int) int
+		MissingImages func(childComplexity int) int
+		OnFailure     func(childComplexity int) int
+		OnSuccess     func(childComplexity int) int
+		PipelineName  func(childComplexity int) int
+		Steps         func(childComplexity int) int
+		Timeout       func(childComplexity int) int
+	}
+
+	PipelinePlanStep struct {
+		Approval      func(childComplexity int) int
+		Args          func(childComplexity int) int
+		Command       func(childComplexity int) int
+		Deferred      func(childComplexity int) int
+		Entrypoint    func(childComplexity int) int
+		Env           func(childComplexity int) int
+		ForEachNode   func(childComplexity int) int
+		ForEachParent func(childComplexity int) int
+		Image         func(childComplexity int) int
+		ImagePresent  func(childComplexity int) int
+		Name          func(childComplexity int) int
+		Nodes         func(childComplexity int) int
+		Pipeline      func(childComplexity int) int
+		When          func(childComplexity int) int
+	}
+
 	PipelineRunTask struct {
-		Data   func(childComplexity int) int
-		TaskID func(childComplexity int) int
+		CreatedAt    func(childComplexity int) int
+		Data         func(childComplexity int) int
+		FinishedAt   func(childComplexity int) int
+		ParentTaskID func(childComplexity int) int
+		PipelineName func(childComplexity int) int
+		Status       func(childComplexity int) int
+		Steps        func(childComplexity int) int
+		TaskID       func(childComplexity int) int
+	}
+
+	PipelineStepRun struct {
+		Approval    func(childComplexity int) int
+		Attempt     func(childComplexity int) int
+		ChildTaskID func(childComplexity int) int
+		ContainerID func(childComplexity int) int
+		DurationMs  func(childComplexity int) int
+		Error       func(childComplexity int) int
+		ExitCode    func(childComplexity int) int
+		FinishedAt  func(childComplexity int) int
+		Image       func(childComplexity int) int
+		Name        func(childComplexity int) int
+		OomKilled   func(childComplexity int) int
+		Pipeline    func(childComplexity int) int
+		StartedAt   func(childComplexity int) int
+		Status      func(childComplexity int) int
 	}
 
 	Query struct {
-		Images     func(childComplexity int) int
-		Node       func(childComplexity int, ip string) int
-		Nodes      func(childComplexity int) int
-		Pipeline   func(childComplexity int, name string) int
-		Pipelines  func(childComplexity int) int
-		ServerInfo func(childComplexity int) int
+		Images       func(childComplexity int) int
+		Node         func(childComplexity int, ip string) int
+		Nodes        func(childComplexity int) int
+		Pipeline     func(childComplexity int, name string) int
+		Pipelines    func(childComplexity int) int
+		PlanPipeline func(childComplexity int, input model.RunPipelineInput) int
+		ServerInfo   func(childComplexity int) int
 	}
 
 	ServerInfo struct {
@@ -106,6 +174,13 @@ type ComplexityRoot struct {
 		Date            func(childComplexity int) int
 		Version         func(childComplexity int) int
 	}
+
+	StepApproval struct {
+		At       func(childComplexity int) int
+		By       func(childComplexity int) int
+		Comment  func(childComplexity int) int
+		Decision func(childComplexity int) int
+	}
 }
 
 type MutationResolver interface {
@@ -116,7 +191,15 @@ type MutationResolver interface {
 	ImagePrune(ctx context.Context, all *bool) ([]string, error)
 	RunPipeline(ctx context.Context, input model.RunPipelineInput) (*model.PipelineRunTask, error)
 	StopPipeline(ctx context.Context, taskID string) (*model.PipelineRunTask, error)
-	ResumePipeline(ctx context.Context, taskID string) (*model.PipelineRunTask, error)
+	ResumePipeline(ctx context.Context, taskID string, from *string, only *string, waitForNodes *bool) (*model.PipelineRunTask, error)
+	SkipPipelineStep(ctx context.Context, taskID string, step string) (*model.PipelineRunTask, error)
+	ApprovePipelineStep(ctx context.Context, taskID string, step *string, approver *string, comment *string) (*model.PipelineRunTask, error)
+	RejectPipelineStep(ctx context.Context, taskID string, step *string, approver *string, comment *string) (*model.PipelineRunTask, error)
+	PausePipeline(ctx context.Context, taskID string, by *string) (*model.PipelineRunTask, error)
+	UnpausePipeline(ctx context.Context, taskID string) (*model.PipelineRunTask, error)
+}
+type NodeResolver interface {
+	Lease(ctx context.Context, obj *model.Node) (*model.NodeLease, error)
 }
 type QueryResolver interface {
 	ServerInfo(ctx context.Context) (*model.ServerInfo, error)
@@ -125,6 +208,7 @@ type QueryResolver interface {
 	Node(ctx context.Context, ip string) (*model.Node, error)
 	Pipelines(ctx context.Context) ([]*model.Pipeline, error)
 	Pipeline(ctx context.Context, name string) (*model.Pipeline, error)
+	PlanPipeline(ctx context.Context, input model.RunPipelineInput) (*model.PipelinePlan, error)
 }
 
 type executableSchema struct {
@@ -189,6 +273,17 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 		}
 
 		return e.complexity.Mutation.AddNode(childComplexity, args["input"].(model.AddNodeInput)), true
+	case "Mutation.approvePipelineStep":
+		if e.complexity.Mutation.ApprovePipelineStep == nil {
+			break
+		}
+
+		args, err := ec.field_Mutation_approvePipelineStep_args(ctx, rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Mutation.ApprovePipelineStep(childComplexity, args["taskId"].(string), args["step"].(*string), args["approver"].(*string), args["comment"].(*string)), true
 	case "Mutation.deleteNode":
 		if e.complexity.Mutation.DeleteNode == nil {
 			break
@@ -222,6 +317,28 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 		}
 
 		return e.complexity.Mutation.ImagePrune(childComplexity, args["all"].(*bool)), true
+	case "Mutation.pausePipeline":
+		if e.complexity.Mutation.PausePipeline == nil {
+			break
+		}
+
+		args, err := ec.field_Mutation_pausePipeline_args(ctx, rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Mutation.PausePipeline(childComplexity, args["taskId"].(string), args["by"].(*string)), true
+	case "Mutation.rejectPipelineStep":
+		if e.complexity.Mutation.RejectPipelineStep == nil {
+			break
+		}
+
+		args, err := ec.field_Mutation_rejectPipelineStep_args(ctx, rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Mutation.RejectPipelineStep(childComplexity, args["taskId"].(string), args["step"].(*string), args["approver"].(*string), args["comment"].(*string)), true
 	case "Mutation.resumePipeline":
 		if e.complexity.Mutation.ResumePipeline == nil {
 			break
@@ -232,7 +349,7 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 			return 0, false
 		}
 
-		return e.complexity.Mutation.ResumePipeline(childComplexity, args["taskId"].(string)), true
+		return e.complexity.Mutation.ResumePipeline(childComplexity, args["taskId"].(string), args["from"].(*string), args["only"].(*string), args["waitForNodes"].(*bool)), true
 	case "Mutation.runPipeline":
 		if e.complexity.Mutation.RunPipeline == nil {
 			break
@@ -244,6 +361,17 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 		}
 
 		return e.complexity.Mutation.RunPipeline(childComplexity, args["input"].(model.RunPipelineInput)), true
+	case "Mutation.skipPipelineStep":
+		if e.complexity.Mutation.SkipPipelineStep == nil {
+			break
+		}
+
+		args, err := ec.field_Mutation_skipPipelineStep_args(ctx, rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Mutation.SkipPipelineStep(childComplexity, args["taskId"].(string), args["step"].(string)), true
 	case "Mutation.stopPipeline":
 		if e.complexity.Mutation.StopPipeline == nil {
 			break
@@ -255,6 +383,17 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 		}
 
 		return e.complexity.Mutation.StopPipeline(childComplexity, args["taskId"].(string)), true
+	case "Mutation.unpausePipeline":
+		if e.complexity.Mutation.UnpausePipeline == nil {
+			break
+		}
+
+		args, err := ec.field_Mutation_unpausePipeline_args(ctx, rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Mutation.UnpausePipeline(childComplexity, args["taskId"].(string)), true
 	case "Mutation.updateNode":
 		if e.complexity.Mutation.UpdateNode == nil {
 			break
@@ -279,6 +418,12 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 		}
 
 		return e.complexity.Node.Labels(childComplexity), true
+	case "Node.lease":
+		if e.complexity.Node.Lease == nil {
+			break
+		}
+
+		return e.complexity.Node.Lease(childComplexity), true
 	case "Node.password":
 		if e.complexity.Node.Password == nil {
 			break
@@ -298,6 +443,31 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 
 		return e.complexity.Node.Username(childComplexity), true
 
+	case "NodeLease.acquiredAt":
+		if e.complexity.NodeLease.AcquiredAt == nil {
+			break
+		}
+
+		return e.complexity.NodeLease.AcquiredAt(childComplexity), true
+	case "NodeLease.pid":
+		if e.complexity.NodeLease.Pid == nil {
+			break
+		}
+
+		return e.complexity.NodeLease.Pid(childComplexity), true
+	case "NodeLease.pipelineName":
+		if e.complexity.NodeLease.PipelineName == nil {
+			break
+		}
+
+		return e.complexity.NodeLease.PipelineName(childComplexity), true
+	case "NodeLease.taskId":
+		if e.complexity.NodeLease.TaskID == nil {
+			break
+		}
+
+		return e.complexity.NodeLease.TaskID(childComplexity), true
+
 	case "NodeList.nodes":
 		if e.complexity.NodeList.Nodes == nil {
 			break
@@ -318,12 +488,194 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 
 		return e.complexity.Pipeline.Name(childComplexity), true
 
+	case "PipelinePlan.always":
+		if e.complexity.PipelinePlan.Always == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.Always(childComplexity), true
+	case "PipelinePlan.failurePolicy":
+		if e.complexity.PipelinePlan.FailurePolicy == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.FailurePolicy(childComplexity), true
+	case "PipelinePlan.levels":
+		if e.complexity.PipelinePlan.Levels == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.Levels(childComplexity), true
+	case "PipelinePlan.maxParallel":
+		if e.complexity.PipelinePlan.MaxParallel == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.MaxParallel(childComplexity), true
+	case "PipelinePlan.missingImages":
+		if e.complexity.PipelinePlan.MissingImages == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.MissingImages(childComplexity), true
+	case "PipelinePlan.onFailure":
+		if e.complexity.PipelinePlan.OnFailure == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.OnFailure(childComplexity), true
+	case "PipelinePlan.onSuccess":
+		if e.complexity.PipelinePlan.OnSuccess == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.OnSuccess(childComplexity), true
+	case "PipelinePlan.pipelineName":
+		if e.complexity.PipelinePlan.PipelineName == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.PipelineName(childComplexity), true
+	case "PipelinePlan.steps":
+		if e.complexity.PipelinePlan.Steps == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.Steps(childComplexity), true
+	case "PipelinePlan.timeout":
+		if e.complexity.PipelinePlan.Timeout == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlan.Timeout(childComplexity), true
+
+	case "PipelinePlanStep.approval":
+		if e.complexity.PipelinePlanStep.Approval == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Approval(childComplexity), true
+	case "PipelinePlanStep.args":
+		if e.complexity.PipelinePlanStep.Args == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Args(childComplexity), true
+	case "PipelinePlanStep.command":
+		if e.complexity.PipelinePlanStep.Command == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Command(childComplexity), true
+	case "PipelinePlanStep.deferred":
+		if e.complexity.PipelinePlanStep.Deferred == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Deferred(childComplexity), true
+	case "PipelinePlanStep.entrypoint":
+		if e.complexity.PipelinePlanStep.Entrypoint == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Entrypoint(childComplexity), true
+	case "PipelinePlanStep.env":
+		if e.complexity.PipelinePlanStep.Env == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Env(childComplexity), true
+	case "PipelinePlanStep.forEachNode":
+		if e.complexity.PipelinePlanStep.ForEachNode == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.ForEachNode(childComplexity), true
+	case "PipelinePlanStep.forEachParent":
+		if e.complexity.PipelinePlanStep.ForEachParent == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.ForEachParent(childComplexity), true
+	case "PipelinePlanStep.image":
+		if e.complexity.PipelinePlanStep.Image == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Image(childComplexity), true
+	case "PipelinePlanStep.imagePresent":
+		if e.complexity.PipelinePlanStep.ImagePresent == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.ImagePresent(childComplexity), true
+	case "PipelinePlanStep.name":
+		if e.complexity.PipelinePlanStep.Name == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Name(childComplexity), true
+	case "PipelinePlanStep.nodes":
+		if e.complexity.PipelinePlanStep.Nodes == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Nodes(childComplexity), true
+	case "PipelinePlanStep.pipeline":
+		if e.complexity.PipelinePlanStep.Pipeline == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.Pipeline(childComplexity), true
+	case "PipelinePlanStep.when":
+		if e.complexity.PipelinePlanStep.When == nil {
+			break
+		}
+
+		return e.complexity.PipelinePlanStep.When(childComplexity), true
+
+	case "PipelineRunTask.createdAt":
+		if e.complexity.PipelineRunTask.CreatedAt == nil {
+			break
+		}
+
+		return e.complexity.PipelineRunTask.CreatedAt(childComplexity), true
 	case "PipelineRunTask.data":
 		if e.complexity.PipelineRunTask.Data == nil {
 			break
 		}
 
 		return e.complexity.PipelineRunTask.Data(childComplexity), true
+	case "PipelineRunTask.finishedAt":
+		if e.complexity.PipelineRunTask.FinishedAt == nil {
+			break
+		}
+
+		return e.complexity.PipelineRunTask.FinishedAt(childComplexity), true
+	case "PipelineRunTask.parentTaskId":
+		if e.complexity.PipelineRunTask.ParentTaskID == nil {
+			break
+		}
+
+		return e.complexity.PipelineRunTask.ParentTaskID(childComplexity), true
+	case "PipelineRunTask.pipelineName":
+		if e.complexity.PipelineRunTask.PipelineName == nil {
+			break
+		}
+
+		return e.complexity.PipelineRunTask.PipelineName(childComplexity), true
+	case "PipelineRunTask.status":
+		if e.complexity.PipelineRunTask.Status == nil {
+			break
+		}
+
+		return e.complexity.PipelineRunTask.Status(childComplexity), true
+	case "PipelineRunTask.steps":
+		if e.complexity.PipelineRunTask.Steps == nil {
+			break
+		}
+
+		return e.complexity.PipelineRunTask.Steps(childComplexity), true
 	case "PipelineRunTask.taskId":
 		if e.complexity.PipelineRunTask.TaskID == nil {
 			break
@@ -331,6 +683,91 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 
 		return e.complexity.PipelineRunTask.TaskID(childComplexity), true
 
+	case "PipelineStepRun.approval":
+		if e.complexity.PipelineStepRun.Approval == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Approval(childComplexity), true
+	case "PipelineStepRun.attempt":
+		if e.complexity.PipelineStepRun.Attempt == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Attempt(childComplexity), true
+	case "PipelineStepRun.childTaskId":
+		if e.complexity.PipelineStepRun.ChildTaskID == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.ChildTaskID(childComplexity), true
+	case "PipelineStepRun.containerId":
+		if e.complexity.PipelineStepRun.ContainerID == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.ContainerID(childComplexity), true
+	case "PipelineStepRun.durationMs":
+		if e.complexity.PipelineStepRun.DurationMs == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.DurationMs(childComplexity), true
+	case "PipelineStepRun.error":
+		if e.complexity.PipelineStepRun.Error == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Error(childComplexity), true
+	case "PipelineStepRun.exitCode":
+		if e.complexity.PipelineStepRun.ExitCode == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.ExitCode(childComplexity), true
+	case "PipelineStepRun.finishedAt":
+		if e.complexity.PipelineStepRun.FinishedAt == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.FinishedAt(childComplexity), true
+	case "PipelineStepRun.image":
+		if e.complexity.PipelineStepRun.Image == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Image(childComplexity), true
+	case "PipelineStepRun.name":
+		if e.complexity.PipelineStepRun.Name == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Name(childComplexity), true
+	case "PipelineStepRun.oomKilled":
+		if e.complexity.PipelineStepRun.OomKilled == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.OomKilled(childComplexity), true
+	case "PipelineStepRun.pipeline":
+		if e.complexity.PipelineStepRun.Pipeline == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Pipeline(childComplexity), true
+	case "PipelineStepRun.startedAt":
+		if e.complexity.PipelineStepRun.StartedAt == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.StartedAt(childComplexity), true
+	case "PipelineStepRun.status":
+		if e.complexity.PipelineStepRun.Status == nil {
+			break
+		}
+
+		return e.complexity.PipelineStepRun.Status(childComplexity), true
+
 	case "Query.images":
 		if e.complexity.Query.Images == nil {
 			break
@@ -371,6 +808,17 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 		}
 
 		return e.complexity.Query.Pipelines(childComplexity), true
+	case "Query.planPipeline":
+		if e.complexity.Query.PlanPipeline == nil {
+			break
+		}
+
+		args, err := ec.field_Query_planPipeline_args(ctx, rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Query.PlanPipeline(childComplexity, args["input"].(model.RunPipelineInput)), true
 	case "Query.serverInfo":
 		if e.complexity.Query.ServerInfo == nil {
 			break
@@ -409,6 +857,31 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 
 		return e.complexity.ServerInfo.Version(childComplexity), true
 
+	case "StepApproval.at":
+		if e.complexity.StepApproval.At == nil {
+			break
+		}
+
+		return e.complexity.StepApproval.At(childComplexity), true
+	case "StepApproval.by":
+		if e.complexity.StepApproval.By == nil {
+			break
+		}
+
+		return e.complexity.StepApproval.By(childComplexity), true
+	case "StepApproval.comment":
+		if e.complexity.StepApproval.Comment == nil {
+			break
+		}
+
+		return e.complexity.StepApproval.Comment(childComplexity), true
+	case "StepApproval.decision":
+		if e.complexity.StepApproval.Decision == nil {
+			break
+		}
+
+		return e.complexity.StepApproval.Decision(childComplexity), true
+
 	}
 	return 0, false
 }
@@ -554,6 +1027,18 @@ type Node {
   username: String!
   password: String!
   labels: [Label!]!
+  # 当前持有该节点租约的任务，空闲时为空
+  lease: NodeLease
+}
+
+# 节点租约：任务执行期间独占其目标节点
+type NodeLease {
+  taskId: String!
+  pipelineName: String!
+  # 持有租约的执行进程 PID
+  pid: Int!
+  # RFC3339 时间
+  acquiredAt: String!
 }
 
 type NodeList {
@@ -604,6 +1089,7 @@ extend type Query {
 # 执行流水线入参：流水线名称 + 节点列表（与 design/执行流水线流程.md 一致）
 input RunPipelineNodeInput {
   ip: String!
+  intranetIp: String
   port: String
   username: String!
   password: String!
@@ -613,23 +1099,119 @@ input RunPipelineNodeInput {
 input RunPipelineInput {
   pipelineName: String!
   nodes: [RunPipelineNodeInput!]!
+  # 运行参数：JSON 对象文本（与 pipeline run --args 文件内容相同），模板中通过 .args 读取；
+  # 敏感参数写为 {"secret": "..."}，在 pipeline.json 与接口返回中显示为 ******
+  args: String
+  # 整条流水线的执行时限（Go duration 格式，如 30m），为空表示不限制
+  timeout: String
+  # 目标节点被其他任务占用时排队等待节点租约，默认直接拒绝
+  waitForNodes: Boolean
 }
 
-# 执行流水线返回：任务 ID + 当前 DAG 状态（pipeline.json 内容）
+# 执行流水线返回：任务 ID + 当前 DAG 状态（pipeline.json 内容），以及解析后的任务与步骤执行元数据
 type PipelineRunTask {
   taskId: String!
   data: String!
+  pipelineName: String!
+  # pending | running | waiting | paused | success | failed | timeout | cancelled | interrupted
+  status: String!
+  # RFC3339 时间
+  createdAt: String
+  finishedAt: String
+  # 作为子流水线执行时的父任务 taskId
+  parentTaskId: String
+  steps: [PipelineStepRun!]!
+}
+
+# 单个步骤的执行元数据（取自 pipeline.json）
+type PipelineStepRun {
+  name: String!
+  image: String!
+  status: String!
+  startedAt: String
+  finishedAt: String
+  durationMs: Int
+  exitCode: Int
+  error: String
+  containerId: String
+  attempt: Int!
+  # 最近一次尝试的进程因超出内存限制（resources.memory）被 OOM 终止
+  oomKilled: Boolean!
+  # 子流水线步骤执行的流水线名与嵌套任务 taskId
+  pipeline: String
+  childTaskId: String
+  # 审批步骤的审批记录，审批前为空
+  approval: StepApproval
+}
+
+# 审批步骤的审批记录（取自 pipeline.json 的 approvalResult）
+type StepApproval {
+  # approved | rejected
+  decision: String!
+  by: String!
+  # RFC3339 时间
+  at: String!
+  comment: String
+}
+
+# 执行计划（dry-run）：渲染模板后的步骤与执行层级，不创建任务与容器
+type PipelinePlan {
+  pipelineName: String!
+  maxParallel: Int
+  timeout: String
+  failurePolicy: String
+  # 主流程步骤按依赖划分的层级，同一层内的步骤可并行执行
+  levels: [[String!]!]!
+  steps: [PipelinePlanStep!]!
+  onSuccess: [PipelinePlanStep!]!
+  onFailure: [PipelinePlanStep!]!
+  always: [PipelinePlanStep!]!
+  # 镜像存储中不存在的步骤镜像
+  missingImages: [String!]!
+}
+
+# 执行计划中的单个步骤；command 与 env 为容器最终的进程参数与环境变量（敏感值显示为 ******），镜像未导入时 command 为空
+type PipelinePlanStep {
+  name: String!
+  image: String
+  # 子流水线与审批步骤不启动容器，为空
+  imagePresent: Boolean
+  entrypoint: String
+  args: [String!]!
+  command: [String!]!
+  env: [String!]!
+  nodes: [String!]!
+  when: String
+  # 命令引用了其他步骤的输出，启动前才渲染
+  deferred: Boolean!
+  pipeline: String
+  approval: Boolean!
+  forEachParent: String
+  forEachNode: String
 }
 
 extend type Query {
   pipelines: [Pipeline!]!
   pipeline(name: String!): Pipeline
+  # 渲染模板并返回执行计划（与 pipeline run --dry-run 相同），input.timeout 被忽略
+  planPipeline(input: RunPipelineInput!): PipelinePlan!
 }
 
 extend type Mutation {
   runPipeline(input: RunPipelineInput!): PipelineRunTask!
   stopPipeline(taskId: String!): PipelineRunTask!
-  resumePipeline(taskId: String!): PipelineRunTask!
+  # from：从该步骤重新执行（其全部后继重置为 pending）；only：仅重新执行该步骤；二者互斥，均为空时跳过已完成的步骤继续执行
+  # waitForNodes：目标节点被其他任务占用时排队等待，默认直接拒绝
+  resumePipeline(taskId: String!, from: String, only: String, waitForNodes: Boolean): PipelineRunTask!
+  # 将步骤标记为 skipped（forEach 步骤可用模板中的步骤名），之后恢复时其后继视为依赖已满足
+  skipPipelineStep(taskId: String!, step: String!): PipelineRunTask!
+  # 批准/拒绝等待审批的步骤；step 为空时处理唯一一个 waiting 步骤，approver 为空时使用服务进程的系统用户
+  approvePipelineStep(taskId: String!, step: String, approver: String, comment: String): PipelineRunTask!
+  rejectPipelineStep(taskId: String!, step: String, approver: String, comment: String): PipelineRunTask!
+  # 暂停任务：冻结正在运行的步骤容器并不再启动新步骤，任务状态记为 paused（正在运行的子流水线一并暂停）；by 为空时使用服务进程的系统用户
+  pausePipeline(taskId: String!, by: String): PipelineRunTask!
+  # 恢复已暂停的任务：解冻步骤容器并继续调度
+  unpausePipeline(taskId: String!): PipelineRunTask!
 }`, BuiltIn: false},
 	{Name: "../schema/version.graphqls", Input: `type ServerInfo {
   version: String!
@@ -661,6 +1243,32 @@ func (ec *executionContext) field_Mutation_addNode_args(ctx context.Context, raw
 	return args, nil
 }
 
+func (ec *executionContext) field_Mutation_approvePipelineStep_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
+	if err != nil {
+		return nil, err
+	}
+	args["taskId"] = arg0
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "step", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["step"] = arg1
+	arg2, err := graphql.ProcessArgField(ctx, rawArgs, "approver", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["approver"] = arg2
+	arg3, err := graphql.ProcessArgField(ctx, rawArgs, "comment", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["comment"] = arg3
+	return args, nil
+}
+
 func (ec *executionContext) field_Mutation_deleteNode_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
 	var err error
 	args := map[string]any{}
@@ -694,7 +1302,7 @@ func (ec *executionContext) field_Mutation_imagePrune_args(ctx context.Context,
 	return args, nil
 }
 
-func (ec *executionContext) field_Mutation_resumePipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+func (ec *executionContext) field_Mutation_pausePipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
 	var err error
 	args := map[string]any{}
 	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
@@ -702,21 +1310,15 @@ func (ec *executionContext) field_Mutation_resumePipeline_args(ctx context.Conte
 		return nil, err
 	}
 	args["taskId"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_Mutation_runPipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
-	var err error
-	args := map[string]any{}
-	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "input", ec.unmarshalNRunPipelineInput2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐRunPipelineInput)
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "by", ec.unmarshalOString2ᚖstring)
 	if err != nil {
 		return nil, err
 	}
-	args["input"] = arg0
+	args["by"] = arg1
 	return args, nil
 }
 
-func (ec *executionContext) field_Mutation_stopPipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+func (ec *executionContext) field_Mutation_rejectPipelineStep_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
 	var err error
 	args := map[string]any{}
 	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
@@ -724,12 +1326,102 @@ func (ec *executionContext) field_Mutation_stopPipeline_args(ctx context.Context
 		return nil, err
 	}
 	args["taskId"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_Mutation_updateNode_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
-	var err error
-	args := map[string]any{}
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "step", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["step"] = arg1
+	arg2, err := graphql.ProcessArgField(ctx, rawArgs, "approver", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["approver"] = arg2
+	arg3, err := graphql.ProcessArgField(ctx, rawArgs, "comment", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["comment"] = arg3
+	return args, nil
+}
+
+func (ec *executionContext) field_Mutation_resumePipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
+	if err != nil {
+		return nil, err
+	}
+	args["taskId"] = arg0
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "from", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["from"] = arg1
+	arg2, err := graphql.ProcessArgField(ctx, rawArgs, "only", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["only"] = arg2
+	arg3, err := graphql.ProcessArgField(ctx, rawArgs, "waitForNodes", ec.unmarshalOBoolean2ᚖbool)
+	if err != nil {
+		return nil, err
+	}
+	args["waitForNodes"] = arg3
+	return args, nil
+}
+
+func (ec *executionContext) field_Mutation_runPipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "input", ec.unmarshalNRunPipelineInput2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐRunPipelineInput)
+	if err != nil {
+		return nil, err
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_Mutation_skipPipelineStep_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
+	if err != nil {
+		return nil, err
+	}
+	args["taskId"] = arg0
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "step", ec.unmarshalNString2string)
+	if err != nil {
+		return nil, err
+	}
+	args["step"] = arg1
+	return args, nil
+}
+
+func (ec *executionContext) field_Mutation_stopPipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
+	if err != nil {
+		return nil, err
+	}
+	args["taskId"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_Mutation_unpausePipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "taskId", ec.unmarshalNString2string)
+	if err != nil {
+		return nil, err
+	}
+	args["taskId"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_Mutation_updateNode_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
 	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "input", ec.unmarshalNUpdateNodeInput2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐUpdateNodeInput)
 	if err != nil {
 		return nil, err
@@ -771,6 +1463,17 @@ func (ec *executionContext) field_Query_pipeline_args(ctx context.Context, rawAr
 	return args, nil
 }
 
+func (ec *executionContext) field_Query_planPipeline_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
+	var err error
+	args := map[string]any{}
+	arg0, err := graphql.ProcessArgField(ctx, rawArgs, "input", ec.unmarshalNRunPipelineInput2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐRunPipelineInput)
+	if err != nil {
+		return nil, err
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
 func (ec *executionContext) field___Directive_args_args(ctx context.Context, rawArgs map[string]any) (map[string]any, error) {
 	var err error
 	args := map[string]any{}
@@ -1214,6 +1917,18 @@ func (ec *executionContext) fieldContext_Mutation_runPipeline(ctx context.Contex
 				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
 			case "data":
 				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
@@ -1261,6 +1976,18 @@ func (ec *executionContext) fieldContext_Mutation_stopPipeline(ctx context.Conte
 				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
 			case "data":
 				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
@@ -1287,7 +2014,7 @@ func (ec *executionContext) _Mutation_resumePipeline(ctx context.Context, field
 		ec.fieldContext_Mutation_resumePipeline,
 		func(ctx context.Context) (any, error) {
 			fc := graphql.GetFieldContext(ctx)
-			return ec.resolvers.Mutation().ResumePipeline(ctx, fc.Args["taskId"].(string))
+			return ec.resolvers.Mutation().ResumePipeline(ctx, fc.Args["taskId"].(string), fc.Args["from"].(*string), fc.Args["only"].(*string), fc.Args["waitForNodes"].(*bool))
 		},
 		nil,
 		ec.marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask,
@@ -1308,6 +2035,18 @@ func (ec *executionContext) fieldContext_Mutation_resumePipeline(ctx context.Con
 				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
 			case "data":
 				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
@@ -1326,217 +2065,2037 @@ func (ec *executionContext) fieldContext_Mutation_resumePipeline(ctx context.Con
 	return fc, nil
 }
 
-func (ec *executionContext) _Node_ip(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+func (ec *executionContext) _Mutation_skipPipelineStep(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Node_ip,
+		ec.fieldContext_Mutation_skipPipelineStep,
 		func(ctx context.Context) (any, error) {
-			return obj.IP, nil
+			fc := graphql.GetFieldContext(ctx)
+			return ec.resolvers.Mutation().SkipPipelineStep(ctx, fc.Args["taskId"].(string), fc.Args["step"].(string))
 		},
 		nil,
-		ec.marshalNString2string,
+		ec.marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_Node_ip(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Mutation_skipPipelineStep(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Node",
+		Object:     "Mutation",
 		Field:      field,
-		IsMethod:   false,
-		IsResolver: false,
+		IsMethod:   true,
+		IsResolver: true,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "taskId":
+				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
+			case "data":
+				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Mutation_skipPipelineStep_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Node_port(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+func (ec *executionContext) _Mutation_approvePipelineStep(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Node_port,
+		ec.fieldContext_Mutation_approvePipelineStep,
 		func(ctx context.Context) (any, error) {
-			return obj.Port, nil
+			fc := graphql.GetFieldContext(ctx)
+			return ec.resolvers.Mutation().ApprovePipelineStep(ctx, fc.Args["taskId"].(string), fc.Args["step"].(*string), fc.Args["approver"].(*string), fc.Args["comment"].(*string))
 		},
 		nil,
-		ec.marshalOString2ᚖstring,
+		ec.marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask,
+		true,
 		true,
-		false,
 	)
 }
 
-func (ec *executionContext) fieldContext_Node_port(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Mutation_approvePipelineStep(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Node",
+		Object:     "Mutation",
 		Field:      field,
-		IsMethod:   false,
-		IsResolver: false,
+		IsMethod:   true,
+		IsResolver: true,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "taskId":
+				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
+			case "data":
+				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Mutation_approvePipelineStep_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Node_username(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+func (ec *executionContext) _Mutation_rejectPipelineStep(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Node_username,
+		ec.fieldContext_Mutation_rejectPipelineStep,
 		func(ctx context.Context) (any, error) {
-			return obj.Username, nil
+			fc := graphql.GetFieldContext(ctx)
+			return ec.resolvers.Mutation().RejectPipelineStep(ctx, fc.Args["taskId"].(string), fc.Args["step"].(*string), fc.Args["approver"].(*string), fc.Args["comment"].(*string))
 		},
 		nil,
-		ec.marshalNString2string,
+		ec.marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_Node_username(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Mutation_rejectPipelineStep(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Node",
+		Object:     "Mutation",
 		Field:      field,
-		IsMethod:   false,
-		IsResolver: false,
+		IsMethod:   true,
+		IsResolver: true,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "taskId":
+				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
+			case "data":
+				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Mutation_rejectPipelineStep_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Node_password(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+func (ec *executionContext) _Mutation_pausePipeline(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Node_password,
+		ec.fieldContext_Mutation_pausePipeline,
 		func(ctx context.Context) (any, error) {
-			return obj.Password, nil
+			fc := graphql.GetFieldContext(ctx)
+			return ec.resolvers.Mutation().PausePipeline(ctx, fc.Args["taskId"].(string), fc.Args["by"].(*string))
 		},
 		nil,
-		ec.marshalNString2string,
+		ec.marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_Node_password(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Mutation_pausePipeline(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Node",
+		Object:     "Mutation",
 		Field:      field,
-		IsMethod:   false,
-		IsResolver: false,
+		IsMethod:   true,
+		IsResolver: true,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			switch field.Name {
+			case "taskId":
+				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
+			case "data":
+				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Mutation_pausePipeline_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Node_labels(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+func (ec *executionContext) _Mutation_unpausePipeline(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Node_labels,
+		ec.fieldContext_Mutation_unpausePipeline,
 		func(ctx context.Context) (any, error) {
-			return obj.Labels, nil
+			fc := graphql.GetFieldContext(ctx)
+			return ec.resolvers.Mutation().UnpausePipeline(ctx, fc.Args["taskId"].(string))
 		},
 		nil,
-		ec.marshalNLabel2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐLabelᚄ,
+		ec.marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_Node_labels(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Mutation_unpausePipeline(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Node",
+		Object:     "Mutation",
 		Field:      field,
-		IsMethod:   false,
-		IsResolver: false,
+		IsMethod:   true,
+		IsResolver: true,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
-			case "key":
-				return ec.fieldContext_Label_key(ctx, field)
-			case "value":
-				return ec.fieldContext_Label_value(ctx, field)
+			case "taskId":
+				return ec.fieldContext_PipelineRunTask_taskId(ctx, field)
+			case "data":
+				return ec.fieldContext_PipelineRunTask_data(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_PipelineRunTask_pipelineName(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineRunTask_status(ctx, field)
+			case "createdAt":
+				return ec.fieldContext_PipelineRunTask_createdAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineRunTask_finishedAt(ctx, field)
+			case "parentTaskId":
+				return ec.fieldContext_PipelineRunTask_parentTaskId(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelineRunTask_steps(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type Label", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type PipelineRunTask", field.Name)
 		},
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Mutation_unpausePipeline_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
 	return fc, nil
 }
 
-func (ec *executionContext) _NodeList_nodes(ctx context.Context, field graphql.CollectedField, obj *model.NodeList) (ret graphql.Marshaler) {
+func (ec *executionContext) _Node_ip(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_NodeList_nodes,
+		ec.fieldContext_Node_ip,
 		func(ctx context.Context) (any, error) {
-			return obj.Nodes, nil
+			return obj.IP, nil
 		},
 		nil,
-		ec.marshalNNode2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐNodeᚄ,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Node_ip(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Node",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Node_port(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Node_port,
+		func(ctx context.Context) (any, error) {
+			return obj.Port, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_Node_port(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Node",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Node_username(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Node_username,
+		func(ctx context.Context) (any, error) {
+			return obj.Username, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Node_username(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Node",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Node_password(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Node_password,
+		func(ctx context.Context) (any, error) {
+			return obj.Password, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Node_password(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Node",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Node_labels(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Node_labels,
+		func(ctx context.Context) (any, error) {
+			return obj.Labels, nil
+		},
+		nil,
+		ec.marshalNLabel2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐLabelᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Node_labels(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Node",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "key":
+				return ec.fieldContext_Label_key(ctx, field)
+			case "value":
+				return ec.fieldContext_Label_value(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Label", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Node_lease(ctx context.Context, field graphql.CollectedField, obj *model.Node) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Node_lease,
+		func(ctx context.Context) (any, error) {
+			return ec.resolvers.Node().Lease(ctx, obj)
+		},
+		nil,
+		ec.marshalONodeLease2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐNodeLease,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_Node_lease(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Node",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "taskId":
+				return ec.fieldContext_NodeLease_taskId(ctx, field)
+			case "pipelineName":
+				return ec.fieldContext_NodeLease_pipelineName(ctx, field)
+			case "pid":
+				return ec.fieldContext_NodeLease_pid(ctx, field)
+			case "acquiredAt":
+				return ec.fieldContext_NodeLease_acquiredAt(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type NodeLease", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _NodeLease_taskId(ctx context.Context, field graphql.CollectedField, obj *model.NodeLease) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_NodeLease_taskId,
+		func(ctx context.Context) (any, error) {
+			return obj.TaskID, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_NodeLease_taskId(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "NodeLease",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _NodeLease_pipelineName(ctx context.Context, field graphql.CollectedField, obj *model.NodeLease) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_NodeLease_pipelineName,
+		func(ctx context.Context) (any, error) {
+			return obj.PipelineName, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_NodeLease_pipelineName(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "NodeLease",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _NodeLease_pid(ctx context.Context, field graphql.CollectedField, obj *model.NodeLease) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_NodeLease_pid,
+		func(ctx context.Context) (any, error) {
+			return obj.Pid, nil
+		},
+		nil,
+		ec.marshalNInt2int,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_NodeLease_pid(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "NodeLease",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _NodeLease_acquiredAt(ctx context.Context, field graphql.CollectedField, obj *model.NodeLease) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_NodeLease_acquiredAt,
+		func(ctx context.Context) (any, error) {
+			return obj.AcquiredAt, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_NodeLease_acquiredAt(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "NodeLease",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _NodeList_nodes(ctx context.Context, field graphql.CollectedField, obj *model.NodeList) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_NodeList_nodes,
+		func(ctx context.Context) (any, error) {
+			return obj.Nodes, nil
+		},
+		nil,
+		ec.marshalNNode2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐNodeᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_NodeList_nodes(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "NodeList",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "ip":
+				return ec.fieldContext_Node_ip(ctx, field)
+			case "port":
+				return ec.fieldContext_Node_port(ctx, field)
+			case "username":
+				return ec.fieldContext_Node_username(ctx, field)
+			case "password":
+				return ec.fieldContext_Node_password(ctx, field)
+			case "labels":
+				return ec.fieldContext_Node_labels(ctx, field)
+			case "lease":
+				return ec.fieldContext_Node_lease(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Node", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Pipeline_name(ctx context.Context, field graphql.CollectedField, obj *model.Pipeline) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Pipeline_name,
+		func(ctx context.Context) (any, error) {
+			return obj.Name, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Pipeline_name(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Pipeline",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Pipeline_dag(ctx context.Context, field graphql.CollectedField, obj *model.Pipeline) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Pipeline_dag,
+		func(ctx context.Context) (any, error) {
+			return obj.Dag, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Pipeline_dag(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Pipeline",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_pipelineName(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_pipelineName,
+		func(ctx context.Context) (any, error) {
+			return obj.PipelineName, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_pipelineName(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_maxParallel(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_maxParallel,
+		func(ctx context.Context) (any, error) {
+			return obj.MaxParallel, nil
+		},
+		nil,
+		ec.marshalOInt2ᚖint,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_maxParallel(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_timeout(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_timeout,
+		func(ctx context.Context) (any, error) {
+			return obj.Timeout, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_timeout(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_failurePolicy(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_failurePolicy,
+		func(ctx context.Context) (any, error) {
+			return obj.FailurePolicy, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_failurePolicy(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_levels(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_levels,
+		func(ctx context.Context) (any, error) {
+			return obj.Levels, nil
+		},
+		nil,
+		ec.marshalNString2ᚕᚕstringᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_levels(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_steps(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_steps,
+		func(ctx context.Context) (any, error) {
+			return obj.Steps, nil
+		},
+		nil,
+		ec.marshalNPipelinePlanStep2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStepᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_steps(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "name":
+				return ec.fieldContext_PipelinePlanStep_name(ctx, field)
+			case "image":
+				return ec.fieldContext_PipelinePlanStep_image(ctx, field)
+			case "imagePresent":
+				return ec.fieldContext_PipelinePlanStep_imagePresent(ctx, field)
+			case "entrypoint":
+				return ec.fieldContext_PipelinePlanStep_entrypoint(ctx, field)
+			case "args":
+				return ec.fieldContext_PipelinePlanStep_args(ctx, field)
+			case "command":
+				return ec.fieldContext_PipelinePlanStep_command(ctx, field)
+			case "env":
+				return ec.fieldContext_PipelinePlanStep_env(ctx, field)
+			case "nodes":
+				return ec.fieldContext_PipelinePlanStep_nodes(ctx, field)
+			case "when":
+				return ec.fieldContext_PipelinePlanStep_when(ctx, field)
+			case "deferred":
+				return ec.fieldContext_PipelinePlanStep_deferred(ctx, field)
+			case "pipeline":
+				return ec.fieldContext_PipelinePlanStep_pipeline(ctx, field)
+			case "approval":
+				return ec.fieldContext_PipelinePlanStep_approval(ctx, field)
+			case "forEachParent":
+				return ec.fieldContext_PipelinePlanStep_forEachParent(ctx, field)
+			case "forEachNode":
+				return ec.fieldContext_PipelinePlanStep_forEachNode(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelinePlanStep", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_onSuccess(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_onSuccess,
+		func(ctx context.Context) (any, error) {
+			return obj.OnSuccess, nil
+		},
+		nil,
+		ec.marshalNPipelinePlanStep2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStepᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_onSuccess(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "name":
+				return ec.fieldContext_PipelinePlanStep_name(ctx, field)
+			case "image":
+				return ec.fieldContext_PipelinePlanStep_image(ctx, field)
+			case "imagePresent":
+				return ec.fieldContext_PipelinePlanStep_imagePresent(ctx, field)
+			case "entrypoint":
+				return ec.fieldContext_PipelinePlanStep_entrypoint(ctx, field)
+			case "args":
+				return ec.fieldContext_PipelinePlanStep_args(ctx, field)
+			case "command":
+				return ec.fieldContext_PipelinePlanStep_command(ctx, field)
+			case "env":
+				return ec.fieldContext_PipelinePlanStep_env(ctx, field)
+			case "nodes":
+				return ec.fieldContext_PipelinePlanStep_nodes(ctx, field)
+			case "when":
+				return ec.fieldContext_PipelinePlanStep_when(ctx, field)
+			case "deferred":
+				return ec.fieldContext_PipelinePlanStep_deferred(ctx, field)
+			case "pipeline":
+				return ec.fieldContext_PipelinePlanStep_pipeline(ctx, field)
+			case "approval":
+				return ec.fieldContext_PipelinePlanStep_approval(ctx, field)
+			case "forEachParent":
+				return ec.fieldContext_PipelinePlanStep_forEachParent(ctx, field)
+			case "forEachNode":
+				return ec.fieldContext_PipelinePlanStep_forEachNode(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelinePlanStep", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_onFailure(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_onFailure,
+		func(ctx context.Context) (any, error) {
+			return obj.OnFailure, nil
+		},
+		nil,
+		ec.marshalNPipelinePlanStep2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStepᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_onFailure(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "name":
+				return ec.fieldContext_PipelinePlanStep_name(ctx, field)
+			case "image":
+				return ec.fieldContext_PipelinePlanStep_image(ctx, field)
+			case "imagePresent":
+				return ec.fieldContext_PipelinePlanStep_imagePresent(ctx, field)
+			case "entrypoint":
+				return ec.fieldContext_PipelinePlanStep_entrypoint(ctx, field)
+			case "args":
+				return ec.fieldContext_PipelinePlanStep_args(ctx, field)
+			case "command":
+				return ec.fieldContext_PipelinePlanStep_command(ctx, field)
+			case "env":
+				return ec.fieldContext_PipelinePlanStep_env(ctx, field)
+			case "nodes":
+				return ec.fieldContext_PipelinePlanStep_nodes(ctx, field)
+			case "when":
+				return ec.fieldContext_PipelinePlanStep_when(ctx, field)
+			case "deferred":
+				return ec.fieldContext_PipelinePlanStep_deferred(ctx, field)
+			case "pipeline":
+				return ec.fieldContext_PipelinePlanStep_pipeline(ctx, field)
+			case "approval":
+				return ec.fieldContext_PipelinePlanStep_approval(ctx, field)
+			case "forEachParent":
+				return ec.fieldContext_PipelinePlanStep_forEachParent(ctx, field)
+			case "forEachNode":
+				return ec.fieldContext_PipelinePlanStep_forEachNode(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelinePlanStep", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_always(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_always,
+		func(ctx context.Context) (any, error) {
+			return obj.Always, nil
+		},
+		nil,
+		ec.marshalNPipelinePlanStep2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStepᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_always(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "name":
+				return ec.fieldContext_PipelinePlanStep_name(ctx, field)
+			case "image":
+				return ec.fieldContext_PipelinePlanStep_image(ctx, field)
+			case "imagePresent":
+				return ec.fieldContext_PipelinePlanStep_imagePresent(ctx, field)
+			case "entrypoint":
+				return ec.fieldContext_PipelinePlanStep_entrypoint(ctx, field)
+			case "args":
+				return ec.fieldContext_PipelinePlanStep_args(ctx, field)
+			case "command":
+				return ec.fieldContext_PipelinePlanStep_command(ctx, field)
+			case "env":
+				return ec.fieldContext_PipelinePlanStep_env(ctx, field)
+			case "nodes":
+				return ec.fieldContext_PipelinePlanStep_nodes(ctx, field)
+			case "when":
+				return ec.fieldContext_PipelinePlanStep_when(ctx, field)
+			case "deferred":
+				return ec.fieldContext_PipelinePlanStep_deferred(ctx, field)
+			case "pipeline":
+				return ec.fieldContext_PipelinePlanStep_pipeline(ctx, field)
+			case "approval":
+				return ec.fieldContext_PipelinePlanStep_approval(ctx, field)
+			case "forEachParent":
+				return ec.fieldContext_PipelinePlanStep_forEachParent(ctx, field)
+			case "forEachNode":
+				return ec.fieldContext_PipelinePlanStep_forEachNode(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelinePlanStep", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlan_missingImages(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlan) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlan_missingImages,
+		func(ctx context.Context) (any, error) {
+			return obj.MissingImages, nil
+		},
+		nil,
+		ec.marshalNString2ᚕstringᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlan_missingImages(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlan",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_name(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_name,
+		func(ctx context.Context) (any, error) {
+			return obj.Name, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_name(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_image(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_image,
+		func(ctx context.Context) (any, error) {
+			return obj.Image, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_image(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_imagePresent(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_imagePresent,
+		func(ctx context.Context) (any, error) {
+			return obj.ImagePresent, nil
+		},
+		nil,
+		ec.marshalOBoolean2ᚖbool,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_imagePresent(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Boolean does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_entrypoint(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_entrypoint,
+		func(ctx context.Context) (any, error) {
+			return obj.Entrypoint, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_entrypoint(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_args(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_args,
+		func(ctx context.Context) (any, error) {
+			return obj.Args, nil
+		},
+		nil,
+		ec.marshalNString2ᚕstringᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_args(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_command(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_command,
+		func(ctx context.Context) (any, error) {
+			return obj.Command, nil
+		},
+		nil,
+		ec.marshalNString2ᚕstringᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_command(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_env(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_env,
+		func(ctx context.Context) (any, error) {
+			return obj.Env, nil
+		},
+		nil,
+		ec.marshalNString2ᚕstringᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_env(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_nodes(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_nodes,
+		func(ctx context.Context) (any, error) {
+			return obj.Nodes, nil
+		},
+		nil,
+		ec.marshalNString2ᚕstringᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_nodes(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_when(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_when,
+		func(ctx context.Context) (any, error) {
+			return obj.When, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_when(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_deferred(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_deferred,
+		func(ctx context.Context) (any, error) {
+			return obj.Deferred, nil
+		},
+		nil,
+		ec.marshalNBoolean2bool,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_deferred(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Boolean does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_pipeline(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_pipeline,
+		func(ctx context.Context) (any, error) {
+			return obj.Pipeline, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_pipeline(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_approval(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_approval,
+		func(ctx context.Context) (any, error) {
+			return obj.Approval, nil
+		},
+		nil,
+		ec.marshalNBoolean2bool,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_approval(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Boolean does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_forEachParent(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_forEachParent,
+		func(ctx context.Context) (any, error) {
+			return obj.ForEachParent, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_forEachParent(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelinePlanStep_forEachNode(ctx context.Context, field graphql.CollectedField, obj *model.PipelinePlanStep) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelinePlanStep_forEachNode,
+		func(ctx context.Context) (any, error) {
+			return obj.ForEachNode, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelinePlanStep_forEachNode(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelinePlanStep",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_taskId(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_taskId,
+		func(ctx context.Context) (any, error) {
+			return obj.TaskID, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_taskId(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_data(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_data,
+		func(ctx context.Context) (any, error) {
+			return obj.Data, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_data(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_pipelineName(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_pipelineName,
+		func(ctx context.Context) (any, error) {
+			return obj.PipelineName, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_pipelineName(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_status(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_status,
+		func(ctx context.Context) (any, error) {
+			return obj.Status, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_status(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_createdAt(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_createdAt,
+		func(ctx context.Context) (any, error) {
+			return obj.CreatedAt, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_createdAt(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_finishedAt(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_finishedAt,
+		func(ctx context.Context) (any, error) {
+			return obj.FinishedAt, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_finishedAt(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_parentTaskId(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_parentTaskId,
+		func(ctx context.Context) (any, error) {
+			return obj.ParentTaskID, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_parentTaskId(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineRunTask_steps(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineRunTask_steps,
+		func(ctx context.Context) (any, error) {
+			return obj.Steps, nil
+		},
+		nil,
+		ec.marshalNPipelineStepRun2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineStepRunᚄ,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineRunTask_steps(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineRunTask",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "name":
+				return ec.fieldContext_PipelineStepRun_name(ctx, field)
+			case "image":
+				return ec.fieldContext_PipelineStepRun_image(ctx, field)
+			case "status":
+				return ec.fieldContext_PipelineStepRun_status(ctx, field)
+			case "startedAt":
+				return ec.fieldContext_PipelineStepRun_startedAt(ctx, field)
+			case "finishedAt":
+				return ec.fieldContext_PipelineStepRun_finishedAt(ctx, field)
+			case "durationMs":
+				return ec.fieldContext_PipelineStepRun_durationMs(ctx, field)
+			case "exitCode":
+				return ec.fieldContext_PipelineStepRun_exitCode(ctx, field)
+			case "error":
+				return ec.fieldContext_PipelineStepRun_error(ctx, field)
+			case "containerId":
+				return ec.fieldContext_PipelineStepRun_containerId(ctx, field)
+			case "attempt":
+				return ec.fieldContext_PipelineStepRun_attempt(ctx, field)
+			case "oomKilled":
+				return ec.fieldContext_PipelineStepRun_oomKilled(ctx, field)
+			case "pipeline":
+				return ec.fieldContext_PipelineStepRun_pipeline(ctx, field)
+			case "childTaskId":
+				return ec.fieldContext_PipelineStepRun_childTaskId(ctx, field)
+			case "approval":
+				return ec.fieldContext_PipelineStepRun_approval(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelineStepRun", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_name(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_name,
+		func(ctx context.Context) (any, error) {
+			return obj.Name, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_name(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_image(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_image,
+		func(ctx context.Context) (any, error) {
+			return obj.Image, nil
+		},
+		nil,
+		ec.marshalNString2string,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_NodeList_nodes(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PipelineStepRun_image(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_status(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_status,
+		func(ctx context.Context) (any, error) {
+			return obj.Status, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_status(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_startedAt(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_startedAt,
+		func(ctx context.Context) (any, error) {
+			return obj.StartedAt, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_startedAt(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_finishedAt(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_finishedAt,
+		func(ctx context.Context) (any, error) {
+			return obj.FinishedAt, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_finishedAt(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_durationMs(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_durationMs,
+		func(ctx context.Context) (any, error) {
+			return obj.DurationMs, nil
+		},
+		nil,
+		ec.marshalOInt2ᚖint,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_durationMs(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_exitCode(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_exitCode,
+		func(ctx context.Context) (any, error) {
+			return obj.ExitCode, nil
+		},
+		nil,
+		ec.marshalOInt2ᚖint,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_exitCode(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_error(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_error,
+		func(ctx context.Context) (any, error) {
+			return obj.Error, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_error(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "NodeList",
+		Object:     "PipelineStepRun",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "ip":
-				return ec.fieldContext_Node_ip(ctx, field)
-			case "port":
-				return ec.fieldContext_Node_port(ctx, field)
-			case "username":
-				return ec.fieldContext_Node_username(ctx, field)
-			case "password":
-				return ec.fieldContext_Node_password(ctx, field)
-			case "labels":
-				return ec.fieldContext_Node_labels(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type Node", field.Name)
+			return nil, errors.New("field of type String does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Pipeline_name(ctx context.Context, field graphql.CollectedField, obj *model.Pipeline) (ret graphql.Marshaler) {
+func (ec *executionContext) _PipelineStepRun_containerId(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Pipeline_name,
+		ec.fieldContext_PipelineStepRun_containerId,
 		func(ctx context.Context) (any, error) {
-			return obj.Name, nil
+			return obj.ContainerID, nil
 		},
 		nil,
-		ec.marshalNString2string,
-		true,
+		ec.marshalOString2ᚖstring,
 		true,
+		false,
 	)
 }
 
-func (ec *executionContext) fieldContext_Pipeline_name(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PipelineStepRun_containerId(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Pipeline",
+		Object:     "PipelineStepRun",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -1547,83 +4106,112 @@ func (ec *executionContext) fieldContext_Pipeline_name(_ context.Context, field
 	return fc, nil
 }
 
-func (ec *executionContext) _Pipeline_dag(ctx context.Context, field graphql.CollectedField, obj *model.Pipeline) (ret graphql.Marshaler) {
+func (ec *executionContext) _PipelineStepRun_attempt(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_Pipeline_dag,
+		ec.fieldContext_PipelineStepRun_attempt,
 		func(ctx context.Context) (any, error) {
-			return obj.Dag, nil
+			return obj.Attempt, nil
 		},
 		nil,
-		ec.marshalNString2string,
+		ec.marshalNInt2int,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_Pipeline_dag(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PipelineStepRun_attempt(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Pipeline",
+		Object:     "PipelineStepRun",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Int does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PipelineRunTask_taskId(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+func (ec *executionContext) _PipelineStepRun_oomKilled(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_PipelineRunTask_taskId,
+		ec.fieldContext_PipelineStepRun_oomKilled,
 		func(ctx context.Context) (any, error) {
-			return obj.TaskID, nil
+			return obj.OomKilled, nil
 		},
 		nil,
-		ec.marshalNString2string,
+		ec.marshalNBoolean2bool,
 		true,
 		true,
 	)
 }
 
-func (ec *executionContext) fieldContext_PipelineRunTask_taskId(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PipelineStepRun_oomKilled(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PipelineRunTask",
+		Object:     "PipelineStepRun",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			return nil, errors.New("field of type String does not have child fields")
+			return nil, errors.New("field of type Boolean does not have child fields")
 		},
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _PipelineRunTask_data(ctx context.Context, field graphql.CollectedField, obj *model.PipelineRunTask) (ret graphql.Marshaler) {
+func (ec *executionContext) _PipelineStepRun_pipeline(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
 		ec.OperationContext,
 		field,
-		ec.fieldContext_PipelineRunTask_data,
+		ec.fieldContext_PipelineStepRun_pipeline,
 		func(ctx context.Context) (any, error) {
-			return obj.Data, nil
+			return obj.Pipeline, nil
 		},
 		nil,
-		ec.marshalNString2string,
+		ec.marshalOString2ᚖstring,
 		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_pipeline(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _PipelineStepRun_childTaskId(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_childTaskId,
+		func(ctx context.Context) (any, error) {
+			return obj.ChildTaskID, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
 		true,
+		false,
 	)
 }
 
-func (ec *executionContext) fieldContext_PipelineRunTask_data(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_PipelineStepRun_childTaskId(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "PipelineRunTask",
+		Object:     "PipelineStepRun",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -1634,6 +4222,45 @@ func (ec *executionContext) fieldContext_PipelineRunTask_data(_ context.Context,
 	return fc, nil
 }
 
+func (ec *executionContext) _PipelineStepRun_approval(ctx context.Context, field graphql.CollectedField, obj *model.PipelineStepRun) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_PipelineStepRun_approval,
+		func(ctx context.Context) (any, error) {
+			return obj.Approval, nil
+		},
+		nil,
+		ec.marshalOStepApproval2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐStepApproval,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_PipelineStepRun_approval(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "PipelineStepRun",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "decision":
+				return ec.fieldContext_StepApproval_decision(ctx, field)
+			case "by":
+				return ec.fieldContext_StepApproval_by(ctx, field)
+			case "at":
+				return ec.fieldContext_StepApproval_at(ctx, field)
+			case "comment":
+				return ec.fieldContext_StepApproval_comment(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type StepApproval", field.Name)
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _Query_serverInfo(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
@@ -1746,6 +4373,8 @@ func (ec *executionContext) fieldContext_Query_nodes(_ context.Context, field gr
 				return ec.fieldContext_Node_password(ctx, field)
 			case "labels":
 				return ec.fieldContext_Node_labels(ctx, field)
+			case "lease":
+				return ec.fieldContext_Node_lease(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type Node", field.Name)
 		},
@@ -1788,6 +4417,8 @@ func (ec *executionContext) fieldContext_Query_node(ctx context.Context, field g
 				return ec.fieldContext_Node_password(ctx, field)
 			case "labels":
 				return ec.fieldContext_Node_labels(ctx, field)
+			case "lease":
+				return ec.fieldContext_Node_lease(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type Node", field.Name)
 		},
@@ -1888,6 +4519,69 @@ func (ec *executionContext) fieldContext_Query_pipeline(ctx context.Context, fie
 	return fc, nil
 }
 
+func (ec *executionContext) _Query_planPipeline(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_Query_planPipeline,
+		func(ctx context.Context) (any, error) {
+			fc := graphql.GetFieldContext(ctx)
+			return ec.resolvers.Query().PlanPipeline(ctx, fc.Args["input"].(model.RunPipelineInput))
+		},
+		nil,
+		ec.marshalNPipelinePlan2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlan,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_Query_planPipeline(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "pipelineName":
+				return ec.fieldContext_PipelinePlan_pipelineName(ctx, field)
+			case "maxParallel":
+				return ec.fieldContext_PipelinePlan_maxParallel(ctx, field)
+			case "timeout":
+				return ec.fieldContext_PipelinePlan_timeout(ctx, field)
+			case "failurePolicy":
+				return ec.fieldContext_PipelinePlan_failurePolicy(ctx, field)
+			case "levels":
+				return ec.fieldContext_PipelinePlan_levels(ctx, field)
+			case "steps":
+				return ec.fieldContext_PipelinePlan_steps(ctx, field)
+			case "onSuccess":
+				return ec.fieldContext_PipelinePlan_onSuccess(ctx, field)
+			case "onFailure":
+				return ec.fieldContext_PipelinePlan_onFailure(ctx, field)
+			case "always":
+				return ec.fieldContext_PipelinePlan_always(ctx, field)
+			case "missingImages":
+				return ec.fieldContext_PipelinePlan_missingImages(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type PipelinePlan", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_planPipeline_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _Query___type(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
@@ -2141,6 +4835,122 @@ func (ec *executionContext) fieldContext_ServerInfo_currentDateTime(_ context.Co
 	return fc, nil
 }
 
+func (ec *executionContext) _StepApproval_decision(ctx context.Context, field graphql.CollectedField, obj *model.StepApproval) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_StepApproval_decision,
+		func(ctx context.Context) (any, error) {
+			return obj.Decision, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_StepApproval_decision(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "StepApproval",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _StepApproval_by(ctx context.Context, field graphql.CollectedField, obj *model.StepApproval) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_StepApproval_by,
+		func(ctx context.Context) (any, error) {
+			return obj.By, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_StepApproval_by(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "StepApproval",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _StepApproval_at(ctx context.Context, field graphql.CollectedField, obj *model.StepApproval) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_StepApproval_at,
+		func(ctx context.Context) (any, error) {
+			return obj.At, nil
+		},
+		nil,
+		ec.marshalNString2string,
+		true,
+		true,
+	)
+}
+
+func (ec *executionContext) fieldContext_StepApproval_at(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "StepApproval",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _StepApproval_comment(ctx context.Context, field graphql.CollectedField, obj *model.StepApproval) (ret graphql.Marshaler) {
+	return graphql.ResolveField(
+		ctx,
+		ec.OperationContext,
+		field,
+		ec.fieldContext_StepApproval_comment,
+		func(ctx context.Context) (any, error) {
+			return obj.Comment, nil
+		},
+		nil,
+		ec.marshalOString2ᚖstring,
+		true,
+		false,
+	)
+}
+
+func (ec *executionContext) fieldContext_StepApproval_comment(_ context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "StepApproval",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) ___Directive_name(ctx context.Context, field graphql.CollectedField, obj *introspection.Directive) (ret graphql.Marshaler) {
 	return graphql.ResolveField(
 		ctx,
@@ -3710,7 +6520,7 @@ func (ec *executionContext) unmarshalInputRunPipelineInput(ctx context.Context,
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"pipelineName", "nodes"}
+	fieldsInOrder := [...]string{"pipelineName", "nodes", "args", "timeout", "waitForNodes"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -3723,14 +6533,35 @@ func (ec *executionContext) unmarshalInputRunPipelineInput(ctx context.Context,
 			if err != nil {
 				return it, err
 			}
-			it.PipelineName = data
-		case "nodes":
-			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("nodes"))
-			data, err := ec.unmarshalNRunPipelineNodeInput2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐRunPipelineNodeInputᚄ(ctx, v)
+			it.PipelineName = data
+		case "nodes":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("nodes"))
+			data, err := ec.unmarshalNRunPipelineNodeInput2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐRunPipelineNodeInputᚄ(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Nodes = data
+		case "args":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("args"))
+			data, err := ec.unmarshalOString2ᚖstring(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Args = data
+		case "timeout":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("timeout"))
+			data, err := ec.unmarshalOString2ᚖstring(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Timeout = data
+		case "waitForNodes":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("waitForNodes"))
+			data, err := ec.unmarshalOBoolean2ᚖbool(ctx, v)
 			if err != nil {
 				return it, err
 			}
-			it.Nodes = data
+			it.WaitForNodes = data
 		}
 	}
 
@@ -3744,7 +6575,7 @@ func (ec *executionContext) unmarshalInputRunPipelineNodeInput(ctx context.Conte
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"ip", "port", "username", "password", "labels"}
+	fieldsInOrder := [...]string{"ip", "intranetIp", "port", "username", "password", "labels"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -3758,6 +6589,13 @@ func (ec *executionContext) unmarshalInputRunPipelineNodeInput(ctx context.Conte
 				return it, err
 			}
 			it.IP = data
+		case "intranetIp":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("intranetIp"))
+			data, err := ec.unmarshalOString2ᚖstring(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.IntranetIP = data
 		case "port":
 			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("port"))
 			data, err := ec.unmarshalOString2ᚖstring(ctx, v)
@@ -4023,6 +6861,41 @@ func (ec *executionContext) _Mutation(ctx context.Context, sel ast.SelectionSet)
 			if out.Values[i] == graphql.Null {
 				out.Invalids++
 			}
+		case "skipPipelineStep":
+			out.Values[i] = ec.OperationContext.RootResolverMiddleware(innerCtx, func(ctx context.Context) (res graphql.Marshaler) {
+				return ec._Mutation_skipPipelineStep(ctx, field)
+			})
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "approvePipelineStep":
+			out.Values[i] = ec.OperationContext.RootResolverMiddleware(innerCtx, func(ctx context.Context) (res graphql.Marshaler) {
+				return ec._Mutation_approvePipelineStep(ctx, field)
+			})
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "rejectPipelineStep":
+			out.Values[i] = ec.OperationContext.RootResolverMiddleware(innerCtx, func(ctx context.Context) (res graphql.Marshaler) {
+				return ec._Mutation_rejectPipelineStep(ctx, field)
+			})
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "pausePipeline":
+			out.Values[i] = ec.OperationContext.RootResolverMiddleware(innerCtx, func(ctx context.Context) (res graphql.Marshaler) {
+				return ec._Mutation_pausePipeline(ctx, field)
+			})
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "unpausePipeline":
+			out.Values[i] = ec.OperationContext.RootResolverMiddleware(innerCtx, func(ctx context.Context) (res graphql.Marshaler) {
+				return ec._Mutation_unpausePipeline(ctx, field)
+			})
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -4060,22 +6933,109 @@ func (ec *executionContext) _Node(ctx context.Context, sel ast.SelectionSet, obj
 		case "ip":
 			out.Values[i] = ec._Node_ip(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
-				out.Invalids++
+				atomic.AddUint32(&out.Invalids, 1)
 			}
 		case "port":
 			out.Values[i] = ec._Node_port(ctx, field, obj)
 		case "username":
 			out.Values[i] = ec._Node_username(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
-				out.Invalids++
+				atomic.AddUint32(&out.Invalids, 1)
 			}
 		case "password":
 			out.Values[i] = ec._Node_password(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
-				out.Invalids++
+				atomic.AddUint32(&out.Invalids, 1)
 			}
 		case "labels":
 			out.Values[i] = ec._Node_labels(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				atomic.AddUint32(&out.Invalids, 1)
+			}
+		case "lease":
+			field := field
+
+			innerFunc := func(ctx context.Context, _ *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._Node_lease(ctx, field, obj)
+				return res
+			}
+
+			if field.Deferrable != nil {
+				dfs, ok := deferred[field.Deferrable.Label]
+				di := 0
+				if ok {
+					dfs.AddField(field)
+					di = len(dfs.Values) - 1
+				} else {
+					dfs = graphql.NewFieldSet([]graphql.CollectedField{field})
+					deferred[field.Deferrable.Label] = dfs
+				}
+				dfs.Concurrently(di, func(ctx context.Context) graphql.Marshaler {
+					return innerFunc(ctx, dfs)
+				})
+
+				// don't run the out.Concurrently() call below
+				out.Values[i] = graphql.Null
+				continue
+			}
+
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
+var nodeLeaseImplementors = []string{"NodeLease"}
+
+func (ec *executionContext) _NodeLease(ctx context.Context, sel ast.SelectionSet, obj *model.NodeLease) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, nodeLeaseImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("NodeLease")
+		case "taskId":
+			out.Values[i] = ec._NodeLease_taskId(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "pipelineName":
+			out.Values[i] = ec._NodeLease_pipelineName(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "pid":
+			out.Values[i] = ec._NodeLease_pid(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "acquiredAt":
+			out.Values[i] = ec._NodeLease_acquiredAt(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
 				out.Invalids++
 			}
@@ -4157,8 +7117,231 @@ func (ec *executionContext) _Pipeline(ctx context.Context, sel ast.SelectionSet,
 			if out.Values[i] == graphql.Null {
 				out.Invalids++
 			}
-		case "dag":
-			out.Values[i] = ec._Pipeline_dag(ctx, field, obj)
+		case "dag":
+			out.Values[i] = ec._Pipeline_dag(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
+var pipelinePlanImplementors = []string{"PipelinePlan"}
+
+func (ec *executionContext) _PipelinePlan(ctx context.Context, sel ast.SelectionSet, obj *model.PipelinePlan) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, pipelinePlanImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("PipelinePlan")
+		case "pipelineName":
+			out.Values[i] = ec._PipelinePlan_pipelineName(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "maxParallel":
+			out.Values[i] = ec._PipelinePlan_maxParallel(ctx, field, obj)
+		case "timeout":
+			out.Values[i] = ec._PipelinePlan_timeout(ctx, field, obj)
+		case "failurePolicy":
+			out.Values[i] = ec._PipelinePlan_failurePolicy(ctx, field, obj)
+		case "levels":
+			out.Values[i] = ec._PipelinePlan_levels(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "steps":
+			out.Values[i] = ec._PipelinePlan_steps(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "onSuccess":
+			out.Values[i] = ec._PipelinePlan_onSuccess(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "onFailure":
+			out.Values[i] = ec._PipelinePlan_onFailure(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "always":
+			out.Values[i] = ec._PipelinePlan_always(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "missingImages":
+			out.Values[i] = ec._PipelinePlan_missingImages(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
+var pipelinePlanStepImplementors = []string{"PipelinePlanStep"}
+
+func (ec *executionContext) _PipelinePlanStep(ctx context.Context, sel ast.SelectionSet, obj *model.PipelinePlanStep) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, pipelinePlanStepImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("PipelinePlanStep")
+		case "name":
+			out.Values[i] = ec._PipelinePlanStep_name(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "image":
+			out.Values[i] = ec._PipelinePlanStep_image(ctx, field, obj)
+		case "imagePresent":
+			out.Values[i] = ec._PipelinePlanStep_imagePresent(ctx, field, obj)
+		case "entrypoint":
+			out.Values[i] = ec._PipelinePlanStep_entrypoint(ctx, field, obj)
+		case "args":
+			out.Values[i] = ec._PipelinePlanStep_args(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "command":
+			out.Values[i] = ec._PipelinePlanStep_command(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "env":
+			out.Values[i] = ec._PipelinePlanStep_env(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "nodes":
+			out.Values[i] = ec._PipelinePlanStep_nodes(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "when":
+			out.Values[i] = ec._PipelinePlanStep_when(ctx, field, obj)
+		case "deferred":
+			out.Values[i] = ec._PipelinePlanStep_deferred(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "pipeline":
+			out.Values[i] = ec._PipelinePlanStep_pipeline(ctx, field, obj)
+		case "approval":
+			out.Values[i] = ec._PipelinePlanStep_approval(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "forEachParent":
+			out.Values[i] = ec._PipelinePlanStep_forEachParent(ctx, field, obj)
+		case "forEachNode":
+			out.Values[i] = ec._PipelinePlanStep_forEachNode(ctx, field, obj)
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
+var pipelineRunTaskImplementors = []string{"PipelineRunTask"}
+
+func (ec *executionContext) _PipelineRunTask(ctx context.Context, sel ast.SelectionSet, obj *model.PipelineRunTask) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, pipelineRunTaskImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("PipelineRunTask")
+		case "taskId":
+			out.Values[i] = ec._PipelineRunTask_taskId(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "data":
+			out.Values[i] = ec._PipelineRunTask_data(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "pipelineName":
+			out.Values[i] = ec._PipelineRunTask_pipelineName(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "status":
+			out.Values[i] = ec._PipelineRunTask_status(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "createdAt":
+			out.Values[i] = ec._PipelineRunTask_createdAt(ctx, field, obj)
+		case "finishedAt":
+			out.Values[i] = ec._PipelineRunTask_finishedAt(ctx, field, obj)
+		case "parentTaskId":
+			out.Values[i] = ec._PipelineRunTask_parentTaskId(ctx, field, obj)
+		case "steps":
+			out.Values[i] = ec._PipelineRunTask_steps(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
 				out.Invalids++
 			}
@@ -4185,27 +7368,60 @@ func (ec *executionContext) _Pipeline(ctx context.Context, sel ast.SelectionSet,
 	return out
 }
 
-var pipelineRunTaskImplementors = []string{"PipelineRunTask"}
+var pipelineStepRunImplementors = []string{"PipelineStepRun"}
 
-func (ec *executionContext) _PipelineRunTask(ctx context.Context, sel ast.SelectionSet, obj *model.PipelineRunTask) graphql.Marshaler {
-	fields := graphql.CollectFields(ec.OperationContext, sel, pipelineRunTaskImplementors)
+func (ec *executionContext) _PipelineStepRun(ctx context.Context, sel ast.SelectionSet, obj *model.PipelineStepRun) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, pipelineStepRunImplementors)
 
 	out := graphql.NewFieldSet(fields)
 	deferred := make(map[string]*graphql.FieldSet)
 	for i, field := range fields {
 		switch field.Name {
 		case "__typename":
-			out.Values[i] = graphql.MarshalString("PipelineRunTask")
-		case "taskId":
-			out.Values[i] = ec._PipelineRunTask_taskId(ctx, field, obj)
+			out.Values[i] = graphql.MarshalString("PipelineStepRun")
+		case "name":
+			out.Values[i] = ec._PipelineStepRun_name(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
 				out.Invalids++
 			}
-		case "data":
-			out.Values[i] = ec._PipelineRunTask_data(ctx, field, obj)
+		case "image":
+			out.Values[i] = ec._PipelineStepRun_image(ctx, field, obj)
 			if out.Values[i] == graphql.Null {
 				out.Invalids++
 			}
+		case "status":
+			out.Values[i] = ec._PipelineStepRun_status(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "startedAt":
+			out.Values[i] = ec._PipelineStepRun_startedAt(ctx, field, obj)
+		case "finishedAt":
+			out.Values[i] = ec._PipelineStepRun_finishedAt(ctx, field, obj)
+		case "durationMs":
+			out.Values[i] = ec._PipelineStepRun_durationMs(ctx, field, obj)
+		case "exitCode":
+			out.Values[i] = ec._PipelineStepRun_exitCode(ctx, field, obj)
+		case "error":
+			out.Values[i] = ec._PipelineStepRun_error(ctx, field, obj)
+		case "containerId":
+			out.Values[i] = ec._PipelineStepRun_containerId(ctx, field, obj)
+		case "attempt":
+			out.Values[i] = ec._PipelineStepRun_attempt(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "oomKilled":
+			out.Values[i] = ec._PipelineStepRun_oomKilled(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "pipeline":
+			out.Values[i] = ec._PipelineStepRun_pipeline(ctx, field, obj)
+		case "childTaskId":
+			out.Values[i] = ec._PipelineStepRun_childTaskId(ctx, field, obj)
+		case "approval":
+			out.Values[i] = ec._PipelineStepRun_approval(ctx, field, obj)
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -4373,6 +7589,28 @@ func (ec *executionContext) _Query(ctx context.Context, sel ast.SelectionSet) gr
 					func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
 			}
 
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return rrm(innerCtx) })
+		case "planPipeline":
+			field := field
+
+			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._Query_planPipeline(ctx, field)
+				if res == graphql.Null {
+					atomic.AddUint32(&fs.Invalids, 1)
+				}
+				return res
+			}
+
+			rrm := func(ctx context.Context) graphql.Marshaler {
+				return ec.OperationContext.RootResolverMiddleware(ctx,
+					func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+			}
+
 			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return rrm(innerCtx) })
 		case "__type":
 			out.Values[i] = ec.OperationContext.RootResolverMiddleware(innerCtx, func(ctx context.Context) (res graphql.Marshaler) {
@@ -4464,6 +7702,57 @@ func (ec *executionContext) _ServerInfo(ctx context.Context, sel ast.SelectionSe
 	return out
 }
 
+var stepApprovalImplementors = []string{"StepApproval"}
+
+func (ec *executionContext) _StepApproval(ctx context.Context, sel ast.SelectionSet, obj *model.StepApproval) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, stepApprovalImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("StepApproval")
+		case "decision":
+			out.Values[i] = ec._StepApproval_decision(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "by":
+			out.Values[i] = ec._StepApproval_by(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "at":
+			out.Values[i] = ec._StepApproval_at(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "comment":
+			out.Values[i] = ec._StepApproval_comment(ctx, field, obj)
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
 var __DirectiveImplementors = []string{"__Directive"}
 
 func (ec *executionContext) ___Directive(ctx context.Context, sel ast.SelectionSet, obj *introspection.Directive) graphql.Marshaler {
@@ -4879,6 +8168,22 @@ func (ec *executionContext) marshalNImageEntry2ᚖgithubᚗcomᚋtangxuscᚋar
 	return ec._ImageEntry(ctx, sel, v)
 }
 
+func (ec *executionContext) unmarshalNInt2int(ctx context.Context, v any) (int, error) {
+	res, err := graphql.UnmarshalInt(v)
+	return res, graphql.ErrorOnPath(ctx, err)
+}
+
+func (ec *executionContext) marshalNInt2int(ctx context.Context, sel ast.SelectionSet, v int) graphql.Marshaler {
+	_ = sel
+	res := graphql.MarshalInt(v)
+	if res == graphql.Null {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			graphql.AddErrorf(ctx, "the requested element is null which the schema does not allow")
+		}
+	}
+	return res
+}
+
 func (ec *executionContext) marshalNLabel2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐLabelᚄ(ctx context.Context, sel ast.SelectionSet, v []*model.Label) graphql.Marshaler {
 	ret := make(graphql.Array, len(v))
 	var wg sync.WaitGroup
@@ -5075,6 +8380,74 @@ func (ec *executionContext) marshalNPipeline2ᚖgithubᚗcomᚋtangxuscᚋarᚋb
 	return ec._Pipeline(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalNPipelinePlan2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlan(ctx context.Context, sel ast.SelectionSet, v model.PipelinePlan) graphql.Marshaler {
+	return ec._PipelinePlan(ctx, sel, &v)
+}
+
+func (ec *executionContext) marshalNPipelinePlan2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlan(ctx context.Context, sel ast.SelectionSet, v *model.PipelinePlan) graphql.Marshaler {
+	if v == nil {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			graphql.AddErrorf(ctx, "the requested element is null which the schema does not allow")
+		}
+		return graphql.Null
+	}
+	return ec._PipelinePlan(ctx, sel, v)
+}
+
+func (ec *executionContext) marshalNPipelinePlanStep2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStepᚄ(ctx context.Context, sel ast.SelectionSet, v []*model.PipelinePlanStep) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalNPipelinePlanStep2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStep(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	for _, e := range ret {
+		if e == graphql.Null {
+			return graphql.Null
+		}
+	}
+
+	return ret
+}
+
+func (ec *executionContext) marshalNPipelinePlanStep2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelinePlanStep(ctx context.Context, sel ast.SelectionSet, v *model.PipelinePlanStep) graphql.Marshaler {
+	if v == nil {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			graphql.AddErrorf(ctx, "the requested element is null which the schema does not allow")
+		}
+		return graphql.Null
+	}
+	return ec._PipelinePlanStep(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalNPipelineRunTask2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineRunTask(ctx context.Context, sel ast.SelectionSet, v model.PipelineRunTask) graphql.Marshaler {
 	return ec._PipelineRunTask(ctx, sel, &v)
 }
@@ -5089,6 +8462,60 @@ func (ec *executionContext) marshalNPipelineRunTask2ᚖgithubᚗcomᚋtangxusc
 	return ec._PipelineRunTask(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalNPipelineStepRun2ᚕᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineStepRunᚄ(ctx context.Context, sel ast.SelectionSet, v []*model.PipelineStepRun) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalNPipelineStepRun2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineStepRun(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	for _, e := range ret {
+		if e == graphql.Null {
+			return graphql.Null
+		}
+	}
+
+	return ret
+}
+
+func (ec *executionContext) marshalNPipelineStepRun2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipelineStepRun(ctx context.Context, sel ast.SelectionSet, v *model.PipelineStepRun) graphql.Marshaler {
+	if v == nil {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			graphql.AddErrorf(ctx, "the requested element is null which the schema does not allow")
+		}
+		return graphql.Null
+	}
+	return ec._PipelineStepRun(ctx, sel, v)
+}
+
 func (ec *executionContext) unmarshalNRunPipelineInput2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐRunPipelineInput(ctx context.Context, v any) (model.RunPipelineInput, error) {
 	res, err := ec.unmarshalInputRunPipelineInput(ctx, v)
 	return res, graphql.ErrorOnPath(ctx, err)
@@ -5174,6 +8601,36 @@ func (ec *executionContext) marshalNString2ᚕstringᚄ(ctx context.Context, sel
 	return ret
 }
 
+func (ec *executionContext) unmarshalNString2ᚕᚕstringᚄ(ctx context.Context, v any) ([][]string, error) {
+	var vSlice []any
+	vSlice = graphql.CoerceList(v)
+	var err error
+	res := make([][]string, len(vSlice))
+	for i := range vSlice {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithIndex(i))
+		res[i], err = ec.unmarshalNString2ᚕstringᚄ(ctx, vSlice[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (ec *executionContext) marshalNString2ᚕᚕstringᚄ(ctx context.Context, sel ast.SelectionSet, v [][]string) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	for i := range v {
+		ret[i] = ec.marshalNString2ᚕstringᚄ(ctx, sel, v[i])
+	}
+
+	for _, e := range ret {
+		if e == graphql.Null {
+			return graphql.Null
+		}
+	}
+
+	return ret
+}
+
 func (ec *executionContext) unmarshalNUpdateNodeInput2githubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐUpdateNodeInput(ctx context.Context, v any) (model.UpdateNodeInput, error) {
 	res, err := ec.unmarshalInputUpdateNodeInput(ctx, v)
 	return res, graphql.ErrorOnPath(ctx, err)
@@ -5462,6 +8919,24 @@ func (ec *executionContext) marshalOBoolean2ᚖbool(ctx context.Context, sel ast
 	return res
 }
 
+func (ec *executionContext) unmarshalOInt2ᚖint(ctx context.Context, v any) (*int, error) {
+	if v == nil {
+		return nil, nil
+	}
+	res, err := graphql.UnmarshalInt(v)
+	return &res, graphql.ErrorOnPath(ctx, err)
+}
+
+func (ec *executionContext) marshalOInt2ᚖint(ctx context.Context, sel ast.SelectionSet, v *int) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	_ = sel
+	_ = ctx
+	res := graphql.MarshalInt(*v)
+	return res
+}
+
 func (ec *executionContext) marshalONode2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐNode(ctx context.Context, sel ast.SelectionSet, v *model.Node) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -5469,6 +8944,13 @@ func (ec *executionContext) marshalONode2ᚖgithubᚗcomᚋtangxuscᚋarᚋbacke
 	return ec._Node(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalONodeLease2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐNodeLease(ctx context.Context, sel ast.SelectionSet, v *model.NodeLease) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._NodeLease(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalOPipeline2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐPipeline(ctx context.Context, sel ast.SelectionSet, v *model.Pipeline) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -5476,6 +8958,13 @@ func (ec *executionContext) marshalOPipeline2ᚖgithubᚗcomᚋtangxuscᚋarᚋb
 	return ec._Pipeline(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalOStepApproval2ᚖgithubᚗcomᚋtangxuscᚋarᚋbackendᚋpkgᚋgraphᚋmodelᚐStepApproval(ctx context.Context, sel ast.SelectionSet, v *model.StepApproval) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._StepApproval(ctx, sel, v)
+}
+
 func (ec *executionContext) unmarshalOString2ᚖstring(ctx context.Context, v any) (*string, error) {
 	if v == nil {
 		return nil, nil
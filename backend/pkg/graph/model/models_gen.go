@@ -37,11 +37,19 @@ type Mutation struct {
 }
 
 type Node struct {
-	IP       string   `json:"ip"`
-	Port     *string  `json:"port,omitempty"`
-	Username string   `json:"username"`
-	Password string   `json:"password"`
-	Labels   []*Label `json:"labels"`
+	IP       string     `json:"ip"`
+	Port     *string    `json:"port,omitempty"`
+	Username string     `json:"username"`
+	Password string     `json:"password"`
+	Labels   []*Label   `json:"labels"`
+	Lease    *NodeLease `json:"lease,omitempty"`
+}
+
+type NodeLease struct {
+	TaskID       string `json:"taskId"`
+	PipelineName string `json:"pipelineName"`
+	Pid          int    `json:"pid"`
+	AcquiredAt   string `json:"acquiredAt"`
 }
 
 type NodeList struct {
@@ -53,9 +61,62 @@ type Pipeline struct {
 	Dag  string `json:"dag"`
 }
 
+type PipelinePlan struct {
+	PipelineName  string              `json:"pipelineName"`
+	MaxParallel   *int                `json:"maxParallel,omitempty"`
+	Timeout       *string             `json:"timeout,omitempty"`
+	FailurePolicy *string             `json:"failurePolicy,omitempty"`
+	Levels        [][]string          `json:"levels"`
+	Steps         []*PipelinePlanStep `json:"steps"`
+	OnSuccess     []*PipelinePlanStep `json:"onSuccess"`
+	OnFailure     []*PipelinePlanStep `json:"onFailure"`
+	Always        []*PipelinePlanStep `json:"always"`
+	MissingImages []string            `json:"missingImages"`
+}
+
+type PipelinePlanStep struct {
+	Name          string   `json:"name"`
+	Image         *string  `json:"image,omitempty"`
+	ImagePresent  *bool    `json:"imagePresent,omitempty"`
+	Entrypoint    *string  `json:"entrypoint,omitempty"`
+	Args          []string `json:"args"`
+	Command       []string `json:"command"`
+	Env           []string `json:"env"`
+	Nodes         []string `json:"nodes"`
+	When          *string  `json:"when,omitempty"`
+	Deferred      bool     `json:"deferred"`
+	Pipeline      *string  `json:"pipeline,omitempty"`
+	Approval      bool     `json:"approval"`
+	ForEachParent *string  `json:"forEachParent,omitempty"`
+	ForEachNode   *string  `json:"forEachNode,omitempty"`
+}
+
 type PipelineRunTask struct {
-	TaskID string `json:"taskId"`
-	Data   string `json:"data"`
+	TaskID       string             `json:"taskId"`
+	Data         string             `json:"data"`
+	PipelineName string             `json:"pipelineName"`
+	Status       string             `json:"status"`
+	CreatedAt    *string            `json:"createdAt,omitempty"`
+	FinishedAt   *string            `json:"finishedAt,omitempty"`
+	ParentTaskID *string            `json:"parentTaskId,omitempty"`
+	Steps        []*PipelineStepRun `json:"steps"`
+}
+
+type PipelineStepRun struct {
+	Name        string        `json:"name"`
+	Image       string        `json:"image"`
+	Status      string        `json:"status"`
+	StartedAt   *string       `json:"startedAt,omitempty"`
+	FinishedAt  *string       `json:"finishedAt,omitempty"`
+	DurationMs  *int          `json:"durationMs,omitempty"`
+	ExitCode    *int          `json:"exitCode,omitempty"`
+	Error       *string       `json:"error,omitempty"`
+	ContainerID *string       `json:"containerId,omitempty"`
+	Attempt     int           `json:"attempt"`
+	OomKilled   bool          `json:"oomKilled"`
+	Pipeline    *string       `json:"pipeline,omitempty"`
+	ChildTaskID *string       `json:"childTaskId,omitempty"`
+	Approval    *StepApproval `json:"approval,omitempty"`
 }
 
 type Query struct {
@@ -64,14 +125,18 @@ type Query struct {
 type RunPipelineInput struct {
 	PipelineName string                  `json:"pipelineName"`
 	Nodes        []*RunPipelineNodeInput `json:"nodes"`
+	Args         *string                 `json:"args,omitempty"`
+	Timeout      *string                 `json:"timeout,omitempty"`
+	WaitForNodes *bool                   `json:"waitForNodes,omitempty"`
 }
 
 type RunPipelineNodeInput struct {
-	IP       string        `json:"ip"`
-	Port     *string       `json:"port,omitempty"`
-	Username string        `json:"username"`
-	Password string        `json:"password"`
-	Labels   []*LabelInput `json:"labels"`
+	IP         string        `json:"ip"`
+	IntranetIP *string       `json:"intranetIp,omitempty"`
+	Port       *string       `json:"port,omitempty"`
+	Username   string        `json:"username"`
+	Password   string        `json:"password"`
+	Labels     []*LabelInput `json:"labels"`
 }
 
 type ServerInfo struct {
@@ -82,6 +147,13 @@ type ServerInfo struct {
 	CurrentDateTime string `json:"currentDateTime"`
 }
 
+type StepApproval struct {
+	Decision string  `json:"decision"`
+	By       string  `json:"by"`
+	At       string  `json:"at"`
+	Comment  *string `json:"comment,omitempty"`
+}
+
 type UpdateNodeInput struct {
 	IP       string        `json:"ip"`
 	Port     *string       `json:"port,omitempty"`
@@ -7,6 +7,7 @@ package resolver
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/tangxusc/ar/backend/pkg/config"
 	"github.com/tangxusc/ar/backend/pkg/graph/model"
@@ -15,7 +16,7 @@ import (
 
 // ImageDelete is the resolver for the imageDelete field.
 func (r *mutationResolver) ImageDelete(ctx context.Context, name string) (bool, error) {
-	if err := pipeline.DeleteImage(config.ImagesStoreDir, name); err != nil {
+	if err := pipeline.DeleteImage(config.ImagesStoreDir, filepath.Dir(config.PipelinesDir), name); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -24,9 +25,9 @@ func (r *mutationResolver) ImageDelete(ctx context.Context, name string) (bool,
 // ImagePrune is the resolver for the imagePrune field.
 func (r *mutationResolver) ImagePrune(ctx context.Context, all *bool) ([]string, error) {
 	if all != nil && *all {
-		return pipeline.PruneAllImages(config.ImagesStoreDir)
+		return pipeline.PruneAllImages(config.ImagesStoreDir, filepath.Dir(config.PipelinesDir))
 	}
-	return pipeline.PruneImages(config.ImagesStoreDir, config.PipelinesDir)
+	return pipeline.PruneImages(config.ImagesStoreDir, config.PipelinesDir, filepath.Dir(config.PipelinesDir))
 }
 
 // Images is the resolver for the images field.
@@ -6,9 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/tangxusc/ar/backend/pkg/config"
 	"github.com/tangxusc/ar/backend/pkg/graph/model"
+	"github.com/tangxusc/ar/backend/pkg/pipeline"
 )
 
 func nodeFilePath(ip string) string {
@@ -48,13 +50,55 @@ func loadAllNodes() (*model.NodeList, error) {
 	}
 	return &model.NodeList{Nodes: nodes}, nil
 }
+
+// saveNode 写入节点文件，登录密码加密保存到密钥存储（node-<ip>），节点文件中只记录其引用。
 func saveNode(n *model.Node) error {
 	if err := os.MkdirAll(config.NodesDir, 0o755); err != nil {
 		return err
 	}
-	data, err := json.MarshalIndent(n, "", "  ")
+	password, err := pipeline.StoreSecretValue(pipeline.ConfiguredSecretStore(), pipeline.NodePasswordSecretName(n.IP), n.Password)
+	if err != nil {
+		return fmt.Errorf("保存节点密码失败: %w", err)
+	}
+	stored := *n
+	stored.Password = password
+	data, err := json.MarshalIndent(&stored, "", "  ")
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(nodeFilePath(n.IP), data, 0o600)
 }
+
+// nodeLease 读取节点当前有效的租约，空闲时返回 nil。
+func nodeLease(ip string) (*model.NodeLease, error) {
+	lease, err := pipeline.ReadNodeLease(filepath.Dir(config.PipelinesDir), ip)
+	if err != nil || lease == nil {
+		return nil, err
+	}
+	return &model.NodeLease{
+		TaskID:       lease.TaskID,
+		PipelineName: lease.PipelineName,
+		Pid:          lease.PID,
+		AcquiredAt:   lease.AcquiredAt.Format(time.RFC3339),
+	}, nil
+}
+
+// deleteNodeFile 删除节点文件及其在密钥存储中的登录密码，节点不存在时忽略。
+func deleteNodeFile(ip string) error {
+	path := nodeFilePath(ip)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var n model.Node
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil
+	}
+	return pipeline.RemoveSecretRef(pipeline.ConfiguredSecretStore(), pipeline.NodePasswordSecretName(ip), n.Password)
+}
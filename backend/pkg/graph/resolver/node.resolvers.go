@@ -81,13 +81,17 @@ func (r *mutationResolver) UpdateNode(ctx context.Context, input model.UpdateNod
 
 // DeleteNode is the resolver for the deleteNode field.
 func (r *mutationResolver) DeleteNode(ctx context.Context, input model.DeleteNodeInput) (*model.NodeList, error) {
-	path := nodeFilePath(input.IP)
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+	if err := deleteNodeFile(input.IP); err != nil {
 		return nil, err
 	}
 	return loadAllNodes()
 }
 
+// Lease is the resolver for the lease field.
+func (r *nodeResolver) Lease(ctx context.Context, obj *model.Node) (*model.NodeLease, error) {
+	return nodeLease(obj.IP)
+}
+
 // Nodes is the resolver for the nodes field.
 func (r *queryResolver) Nodes(ctx context.Context) ([]*model.Node, error) {
 	nodes, err := loadAllNodes()
@@ -120,4 +124,8 @@ func (r *queryResolver) Node(ctx context.Context, ip string) (*model.Node, error
 // Mutation returns graph.MutationResolver implementation.
 func (r *Resolver) Mutation() graph.MutationResolver { return &mutationResolver{r} }
 
+// Node returns graph.NodeResolver implementation.
+func (r *Resolver) Node() graph.NodeResolver { return &nodeResolver{r} }
+
 type mutationResolver struct{ *Resolver }
+type nodeResolver struct{ *Resolver }
@@ -7,7 +7,7 @@ package resolver
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"path/filepath"
 
 	"github.com/tangxusc/ar/backend/pkg/config"
@@ -18,74 +18,80 @@ import (
 // RunPipeline is the resolver for the runPipeline field.
 func (r *mutationResolver) RunPipeline(ctx context.Context, input model.RunPipelineInput) (*model.PipelineRunTask, error) {
 	nodes := runPipelineNodesFromInput(input.Nodes)
-	arRoot := filepath.Dir(config.PipelinesDir)
-	runner := pipeline.NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot)
-	taskID := pipeline.GenerateTaskID()
-	runCtx, cancel := context.WithCancel(ctx)
-	runCancelRegistry.Store(taskID, cancel)
-	defer runCancelRegistry.Delete(taskID)
-
-	taskID, err := runner.Run(runCtx, input.PipelineName, nodes, nil, taskID)
+	args, timeout, err := runPipelineOptionsFromInput(input)
 	if err != nil {
 		return nil, err
 	}
-	runDir := pipeline.RunDirFor(arRoot, input.PipelineName, taskID)
-	runData, err := pipeline.ReadPipelineJSON(runDir)
+	// 任务由 TaskManager 在后台执行，生成 pipeline.json 后立即返回，浏览器断开不会取消任务
+	taskID, err := r.Tasks.Submit(input.PipelineName, nodes, args, timeout, boolValue(input.WaitForNodes))
 	if err != nil {
-		return &model.PipelineRunTask{TaskID: taskID, Data: "{}"}, nil
+		return nil, err
 	}
-	dataBytes, _ := json.Marshal(runData)
-	return &model.PipelineRunTask{TaskID: taskID, Data: string(dataBytes)}, nil
+	return pipelineRunTaskByID(taskID), nil
 }
 
 // StopPipeline is the resolver for the stopPipeline field.
 func (r *mutationResolver) StopPipeline(ctx context.Context, taskID string) (*model.PipelineRunTask, error) {
 	arRoot := filepath.Dir(config.PipelinesDir)
-	if v, ok := runCancelRegistry.Load(taskID); ok {
-		if cancel, ok := v.(context.CancelFunc); ok {
-			cancel()
-		}
-	}
-	runDir, err := pipeline.FindRunDirByTaskID(arRoot, taskID)
-	if err != nil {
-		return &model.PipelineRunTask{TaskID: taskID, Data: "{}"}, nil
+	// 停止容器并将未结束的步骤标记为 cancelled，运行中的子流水线任务一并停止；
+	// 先于取消托管任务写入停止标记，使主流程被停止后的钩子按宽限期执行，而不是立即被取消
+	runner := pipeline.NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot, config.MaxParallel, pipeline.ConfiguredStepContainerConfig())
+	stopErr := runner.Stop(taskID)
+	r.Tasks.Cancel(taskID)
+	if stopErr != nil {
+		return nil, stopErr
 	}
-	runData, err := pipeline.ReadPipelineJSON(runDir)
-	if err != nil {
-		return &model.PipelineRunTask{TaskID: taskID, Data: "{}"}, nil
-	}
-	for i := range runData.Steps {
-		s := &runData.Steps[i]
-		if s.Status == pipeline.StatusRunning || s.Status == pipeline.StatusPending {
-			s.Status = pipeline.StatusCancelled
-		}
-	}
-	_ = pipeline.WritePipelineJSON(runDir, runData)
-	dataBytes, _ := json.Marshal(runData)
-	return &model.PipelineRunTask{TaskID: taskID, Data: string(dataBytes)}, nil
+	return pipelineRunTaskByID(taskID), nil
 }
 
 // ResumePipeline is the resolver for the resumePipeline field.
-func (r *mutationResolver) ResumePipeline(ctx context.Context, taskID string) (*model.PipelineRunTask, error) {
-	arRoot := filepath.Dir(config.PipelinesDir)
-	runner := pipeline.NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot)
-	runCtx, cancel := context.WithCancel(ctx)
-	runCancelRegistry.Store(taskID, cancel)
-	defer runCancelRegistry.Delete(taskID)
+func (r *mutationResolver) ResumePipeline(ctx context.Context, taskID string, from *string, only *string, waitForNodes *bool) (*model.PipelineRunTask, error) {
+	opts := pipeline.ResumeOptions{From: stringValue(from), Only: stringValue(only)}
+	if err := r.Tasks.Resume(taskID, opts, boolValue(waitForNodes)); err != nil {
+		return nil, err
+	}
+	return pipelineRunTaskByID(taskID), nil
+}
 
-	if err := runner.Resume(runCtx, taskID); err != nil {
+// SkipPipelineStep is the resolver for the skipPipelineStep field.
+func (r *mutationResolver) SkipPipelineStep(ctx context.Context, taskID string, step string) (*model.PipelineRunTask, error) {
+	arRoot := filepath.Dir(config.PipelinesDir)
+	if r.Tasks.IsRunning(taskID) {
+		return nil, fmt.Errorf("任务 %s 正在执行，请先停止后再跳过步骤", taskID)
+	}
+	runData, err := pipeline.SkipStep(arRoot, taskID, step)
+	if err != nil {
 		return nil, err
 	}
-	runDir, err := pipeline.FindRunDirByTaskID(arRoot, taskID)
+	return pipelineRunTaskFromRunData(taskID, runData), nil
+}
+
+// ApprovePipelineStep is the resolver for the approvePipelineStep field.
+func (r *mutationResolver) ApprovePipelineStep(ctx context.Context, taskID string, step *string, approver *string, comment *string) (*model.PipelineRunTask, error) {
+	return decidePipelineStep(taskID, step, approver, comment, true)
+}
+
+// RejectPipelineStep is the resolver for the rejectPipelineStep field.
+func (r *mutationResolver) RejectPipelineStep(ctx context.Context, taskID string, step *string, approver *string, comment *string) (*model.PipelineRunTask, error) {
+	return decidePipelineStep(taskID, step, approver, comment, false)
+}
+
+// PausePipeline is the resolver for the pausePipeline field.
+func (r *mutationResolver) PausePipeline(ctx context.Context, taskID string, by *string) (*model.PipelineRunTask, error) {
+	runData, err := pipeline.PauseTask(filepath.Dir(config.PipelinesDir), taskID, stringValue(by))
 	if err != nil {
-		return &model.PipelineRunTask{TaskID: taskID, Data: "{}"}, nil
+		return nil, err
 	}
-	runData, err := pipeline.ReadPipelineJSON(runDir)
+	return pipelineRunTaskFromRunData(taskID, runData), nil
+}
+
+// UnpausePipeline is the resolver for the unpausePipeline field.
+func (r *mutationResolver) UnpausePipeline(ctx context.Context, taskID string) (*model.PipelineRunTask, error) {
+	runData, err := pipeline.UnpauseTask(filepath.Dir(config.PipelinesDir), taskID)
 	if err != nil {
-		return &model.PipelineRunTask{TaskID: taskID, Data: "{}"}, nil
+		return nil, err
 	}
-	dataBytes, _ := json.Marshal(runData)
-	return &model.PipelineRunTask{TaskID: taskID, Data: string(dataBytes)}, nil
+	return pipelineRunTaskFromRunData(taskID, runData), nil
 }
 
 // Pipelines is the resolver for the pipelines field.
@@ -97,3 +103,18 @@ func (r *queryResolver) Pipelines(ctx context.Context) ([]*model.Pipeline, error
 func (r *queryResolver) Pipeline(ctx context.Context, name string) (*model.Pipeline, error) {
 	return loadPipelineByName(name)
 }
+
+// PlanPipeline is the resolver for the planPipeline field.
+func (r *queryResolver) PlanPipeline(ctx context.Context, input model.RunPipelineInput) (*model.PipelinePlan, error) {
+	nodes := runPipelineNodesFromInput(input.Nodes)
+	args, _, err := runPipelineOptionsFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	runner := pipeline.NewRunner(filepath.Dir(config.PipelinesDir), config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot, config.MaxParallel, pipeline.ConfiguredStepContainerConfig())
+	plan, err := runner.Plan(input.PipelineName, nodes, args)
+	if err != nil {
+		return nil, err
+	}
+	return pipelinePlanToModel(plan), nil
+}
@@ -1,16 +1,17 @@
 package resolver
 
 import (
-	"sync"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/tangxusc/ar/backend/pkg/config"
 	"github.com/tangxusc/ar/backend/pkg/graph/model"
 	"github.com/tangxusc/ar/backend/pkg/pipeline"
 )
 
-// runCancelRegistry 登记正在运行的流水线 taskID -> context.CancelFunc，供 StopPipeline 取消执行。
-// 放在单独文件中，避免 gqlgen generate 覆盖 pipeline.resolvers.go 时被移除。
-var runCancelRegistry sync.Map
-
 // runPipelineNodesFromInput 将 GraphQL 入参转为 pipeline.RunNode 列表。
 // 放在单独文件中，避免 gqlgen generate 覆盖 pipeline.resolvers.go 时被移除。
 func runPipelineNodesFromInput(nodes []*model.RunPipelineNodeInput) []pipeline.RunNode {
@@ -33,12 +34,197 @@ func runPipelineNodesFromInput(nodes []*model.RunPipelineNodeInput) []pipeline.R
 			}
 		}
 		out = append(out, pipeline.RunNode{
-			IP:       n.IP,
-			Port:     port,
-			Username: n.Username,
-			Password: n.Password,
-			Labels:   labels,
+			IP:         n.IP,
+			IntranetIP: stringValue(n.IntranetIP),
+			Port:       port,
+			Username:   n.Username,
+			Password:   n.Password,
+			Labels:     labels,
 		})
 	}
 	return out
 }
+
+// runPipelineOptionsFromInput 解析 RunPipelineInput 中的运行参数（JSON 对象文本）与执行时限。
+func runPipelineOptionsFromInput(input model.RunPipelineInput) (map[string]interface{}, time.Duration, error) {
+	var args map[string]interface{}
+	if s := strings.TrimSpace(stringValue(input.Args)); s != "" {
+		if err := json.Unmarshal([]byte(s), &args); err != nil {
+			return nil, 0, fmt.Errorf("解析参数 JSON 失败: %w", err)
+		}
+	}
+	var timeout time.Duration
+	if s := strings.TrimSpace(stringValue(input.Timeout)); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, 0, fmt.Errorf("timeout 无效: %s", s)
+		}
+		timeout = d
+	}
+	return args, timeout, nil
+}
+
+// pipelineRunTaskByID 读取任务当前的 pipeline.json 并转为 GraphQL PipelineRunTask，读取失败时仅返回 taskId。
+func pipelineRunTaskByID(taskID string) *model.PipelineRunTask {
+	runDir, err := pipeline.FindRunDirByTaskID(filepath.Dir(config.PipelinesDir), taskID)
+	if err != nil {
+		return pipelineRunTaskFromRunData(taskID, nil)
+	}
+	runData, err := pipeline.ReadPipelineJSON(runDir)
+	if err != nil {
+		return pipelineRunTaskFromRunData(taskID, nil)
+	}
+	return pipelineRunTaskFromRunData(taskID, runData)
+}
+
+// pipelineRunTaskFromRunData 将 pipeline.json 内容转为 GraphQL PipelineRunTask：data 为原始 JSON，其余字段为解析后的任务与步骤元数据。
+// runData 为 nil（如 pipeline.json 读取失败）时仅返回 taskId 与空 data。
+func pipelineRunTaskFromRunData(taskID string, runData *pipeline.PipelineRunData) *model.PipelineRunTask {
+	if runData == nil {
+		return &model.PipelineRunTask{TaskID: taskID, Data: "{}", Steps: []*model.PipelineStepRun{}}
+	}
+	dataBytes, _ := json.Marshal(runData)
+	task := &model.PipelineRunTask{
+		TaskID:       taskID,
+		Data:         string(dataBytes),
+		PipelineName: runData.PipelineName,
+		Status:       runData.Status,
+		FinishedAt:   formatTimePtr(runData.FinishedAt),
+		Steps:        make([]*model.PipelineStepRun, 0, len(runData.Steps)),
+	}
+	if runData.ParentTaskID != "" {
+		task.ParentTaskID = &runData.ParentTaskID
+	}
+	if !runData.CreatedAt.IsZero() {
+		task.CreatedAt = formatTimePtr(&runData.CreatedAt)
+	}
+	for _, s := range runData.Steps {
+		step := &model.PipelineStepRun{
+			Name:       s.Name,
+			Image:      s.Image,
+			Status:     s.Status,
+			StartedAt:  formatTimePtr(s.StartedAt),
+			FinishedAt: formatTimePtr(s.FinishedAt),
+			ExitCode:   s.ExitCode,
+			Attempt:    s.Attempt,
+			OomKilled:  s.OOMKilled,
+		}
+		if s.FinishedAt != nil {
+			durationMs := int(s.DurationMs)
+			step.DurationMs = &durationMs
+		}
+		if s.Error != "" {
+			step.Error = &s.Error
+		}
+		if s.ContainerID != "" {
+			step.ContainerID = &s.ContainerID
+		}
+		if s.Pipeline != "" {
+			step.Pipeline = &s.Pipeline
+		}
+		if s.ChildTaskID != "" {
+			step.ChildTaskID = &s.ChildTaskID
+		}
+		if a := s.ApprovalResult; a != nil {
+			step.Approval = &model.StepApproval{Decision: a.Decision, By: a.By, At: a.At.Format(time.RFC3339)}
+			if a.Comment != "" {
+				step.Approval.Comment = &a.Comment
+			}
+		}
+		task.Steps = append(task.Steps, step)
+	}
+	return task
+}
+
+// decidePipelineStep 提交审批步骤的审批结果并返回任务当前状态。审批结果由执行任务的进程异步记录，
+// 返回的 pipeline.json 中步骤可能仍为 waiting。
+func decidePipelineStep(taskID string, step, approver, comment *string, approved bool) (*model.PipelineRunTask, error) {
+	arRoot := filepath.Dir(config.PipelinesDir)
+	if err := pipeline.DecideApproval(arRoot, taskID, stringValue(step), stringValue(approver), stringValue(comment), approved); err != nil {
+		return nil, err
+	}
+	return pipelineRunTaskByID(taskID), nil
+}
+
+// pipelinePlanToModel 将 dry-run 执行计划转为 GraphQL PipelinePlan。
+func pipelinePlanToModel(plan *pipeline.PipelinePlan) *model.PipelinePlan {
+	out := &model.PipelinePlan{
+		PipelineName:  plan.PipelineName,
+		Timeout:       optionalString(plan.Timeout),
+		FailurePolicy: optionalString(plan.FailurePolicy),
+		Levels:        plan.Levels,
+		Steps:         pipelinePlanStepsToModel(plan.Steps),
+		OnSuccess:     pipelinePlanStepsToModel(plan.OnSuccess),
+		OnFailure:     pipelinePlanStepsToModel(plan.OnFailure),
+		Always:        pipelinePlanStepsToModel(plan.Always),
+		MissingImages: nonNilStrings(plan.MissingImages),
+	}
+	if plan.MaxParallel > 0 {
+		out.MaxParallel = &plan.MaxParallel
+	}
+	if out.Levels == nil {
+		out.Levels = [][]string{}
+	}
+	return out
+}
+
+func pipelinePlanStepsToModel(steps []pipeline.PlannedStep) []*model.PipelinePlanStep {
+	out := make([]*model.PipelinePlanStep, 0, len(steps))
+	for _, s := range steps {
+		out = append(out, &model.PipelinePlanStep{
+			Name:          s.Name,
+			Image:         optionalString(s.Image),
+			ImagePresent:  s.ImagePresent,
+			Entrypoint:    optionalString(s.Entrypoint),
+			Args:          nonNilStrings(s.Args),
+			Command:       nonNilStrings(s.Command),
+			Env:           nonNilStrings(s.Env),
+			Nodes:         nonNilStrings(s.Nodes),
+			When:          optionalString(s.When),
+			Deferred:      s.Deferred,
+			Pipeline:      optionalString(s.Pipeline),
+			Approval:      s.Approval,
+			ForEachParent: optionalString(s.ForEachParent),
+			ForEachNode:   optionalString(s.ForEachNode),
+		})
+	}
+	return out
+}
+
+// optionalString 将空字符串转为 nil，用于可空的 GraphQL 字段。
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// nonNilStrings 将 nil 切片转为空切片，用于非空的 GraphQL 列表字段。
+func nonNilStrings(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// stringValue 返回可选字符串参数的值，nil 返回空字符串。
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// boolValue 返回可选布尔参数的值，nil 返回 false。
+func boolValue(b *bool) bool {
+	return b != nil && *b
+}
+
+// formatTimePtr 将时间格式化为 RFC3339 字符串，nil 返回 nil。
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
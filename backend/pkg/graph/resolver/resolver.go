@@ -4,4 +4,9 @@ package resolver
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-type Resolver struct{}
+import "github.com/tangxusc/ar/backend/pkg/pipeline"
+
+type Resolver struct {
+	// Tasks 托管流水线任务的执行，runPipeline / resumePipeline 提交后立即返回，任务不随 HTTP 请求取消
+	Tasks *pipeline.TaskManager
+}
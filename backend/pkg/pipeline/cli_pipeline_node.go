@@ -140,9 +140,8 @@ func listPipelineEntries(pipelinesDir string) ([]pipelineListEntry, error) {
 		steps := 0
 		data, err := os.ReadFile(path)
 		if err == nil {
-			var templateSteps []TemplateStep
-			if json.Unmarshal(data, &templateSteps) == nil {
-				steps = len(templateSteps)
+			if tpl, err := parsePipelineTemplate(data); err == nil {
+				steps = len(tpl.Steps)
 			}
 		}
 		entries = append(entries, pipelineListEntry{
@@ -221,13 +220,21 @@ func addNodeCommand(rootCommand *cobra.Command) {
 				return nil
 			}
 			logrus.Debugf("node list: 共 %d 个节点", len(nodes))
+			arRoot := filepath.Dir(config.PipelinesDir)
 			for _, n := range nodes {
 				labelParts := make([]string, 0, len(n.Labels))
 				for _, l := range n.Labels {
 					labelParts = append(labelParts, fmt.Sprintf("%s=%s", l.Key, l.Value))
 				}
 				labels := strings.Join(labelParts, ",")
-				fmt.Printf("%s\t%s\t%s\t%s\n", n.IP, n.Port, n.Username, labels)
+				// 最后一列为当前持有节点租约的任务，空闲时为 -
+				lease := "-"
+				if l, err := ReadNodeLease(arRoot, n.IP); err != nil {
+					logrus.WithError(err).Warnf("node list: 读取节点 %s 的租约失败", n.IP)
+				} else if l != nil {
+					lease = fmt.Sprintf("%s(%s)", l.TaskID, l.PipelineName)
+				}
+				fmt.Printf("%s\t%s\t%s\t%s\t%s\n", n.IP, n.Port, n.Username, labels, lease)
 			}
 			logrus.Info("node list: 完成")
 			return nil
@@ -303,7 +310,8 @@ func deleteNodeByIP(ip string) error {
 	}
 	filename := fmt.Sprintf("node_%s.json", trimmed)
 	path := filepath.Join(config.NodesDir, filename)
-	if _, err := os.Stat(path); err != nil {
+	data, err := os.ReadFile(path)
+	if err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("节点 %s 不存在", ip)
 		}
@@ -312,6 +320,13 @@ func deleteNodeByIP(ip string) error {
 	if err := os.Remove(path); err != nil {
 		return fmt.Errorf("删除节点文件失败 %s: %w", path, err)
 	}
+	// 节点密码保存在密钥存储中时一并删除
+	var n cliNode
+	if err := json.Unmarshal(data, &n); err == nil {
+		if err := RemoveSecretRef(ConfiguredSecretStore(), NodePasswordSecretName(trimmed), n.Password); err != nil {
+			return fmt.Errorf("删除节点密码失败: %w", err)
+		}
+	}
 	return nil
 }
 
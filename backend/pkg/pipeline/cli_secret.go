@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tangxusc/ar/backend/pkg/config"
+)
+
+// addSecretCommand 注册 `ar secret` 相关子命令：set / get / list / rm。
+func addSecretCommand(rootCommand *cobra.Command) {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "管理加密保存的密钥（模板中通过 {{secret \"name\"}} 引用）",
+	}
+	rootCommand.AddCommand(secretCmd)
+
+	var setFromFile string
+	setCmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "保存密钥（值从标准输入或 --from-file 读取），已存在时覆盖",
+		Long:  "加密保存密钥，值默认从标准输入读取（去掉末尾换行），避免出现在命令行历史中。例如: echo -n 'Harbor12345' | ar secret set registry-password",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logrus.Info("secret set: 开始执行")
+			name := strings.TrimSpace(args[0])
+			var value string
+			if setFromFile != "" {
+				data, err := os.ReadFile(setFromFile)
+				if err != nil {
+					logrus.Errorf("secret set: 读取文件失败 %s: %v", setFromFile, err)
+					return fmt.Errorf("读取密钥文件失败 %s: %w", setFromFile, err)
+				}
+				value = string(data)
+			} else {
+				data, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					logrus.Errorf("secret set: 从标准输入读取失败: %v", err)
+					return fmt.Errorf("从标准输入读取密钥失败: %w", err)
+				}
+				value = strings.TrimRight(string(data), "\r\n")
+			}
+			if value == "" {
+				return fmt.Errorf("密钥值不能为空")
+			}
+			logrus.Debugf("secret set: name=%s keyFile=%s", name, config.SecretKeyFile)
+			if err := ConfiguredSecretStore().Set(name, value); err != nil {
+				logrus.Errorf("secret set 失败: %v", err)
+				return err
+			}
+			logrus.Infof("secret set: 已保存密钥 %s", name)
+			return nil
+		},
+	}
+	setCmd.Flags().StringVar(&setFromFile, "from-file", "", "从文件读取密钥值（原样保存，适用于证书、私钥等）")
+	secretCmd.AddCommand(setCmd)
+
+	getCmd := &cobra.Command{
+		Use:   "get NAME",
+		Short: "输出密钥的明文值",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := ConfiguredSecretStore().Get(strings.TrimSpace(args[0]))
+			if err != nil {
+				logrus.Errorf("secret get 失败: %v", err)
+				return err
+			}
+			fmt.Print(value)
+			return nil
+		},
+	}
+	secretCmd.AddCommand(getCmd)
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "列出已保存的密钥（不显示值）",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infos, err := ConfiguredSecretStore().List()
+			if err != nil {
+				logrus.Errorf("secret list 失败: %v", err)
+				return err
+			}
+			if len(infos) == 0 {
+				logrus.Info("secret list: 当前无密钥")
+				return nil
+			}
+			for _, info := range infos {
+				fmt.Printf("%s\t%s\n", info.Name, info.UpdatedAt.Format(time.RFC3339))
+			}
+			return nil
+		},
+	}
+	secretCmd.AddCommand(listCmd)
+
+	rmCmd := &cobra.Command{
+		Use:     "rm NAME...",
+		Aliases: []string{"delete", "del"},
+		Short:   "删除一个或多个密钥",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logrus.Info("secret rm: 开始执行")
+			if len(args) == 0 {
+				return fmt.Errorf("请指定要删除的密钥名称，例如: ar secret rm <name>")
+			}
+			store := ConfiguredSecretStore()
+			for _, name := range args {
+				if err := store.Remove(strings.TrimSpace(name)); err != nil {
+					logrus.Errorf("secret rm 删除 %s 失败: %v", name, err)
+					return err
+				}
+				logrus.Infof("secret rm: 已删除密钥 %s", name)
+			}
+			return nil
+		},
+	}
+	secretCmd.AddCommand(rmCmd)
+}
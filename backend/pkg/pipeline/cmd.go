@@ -73,6 +73,11 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 
 	// ar run：按 design/执行流水线流程.md 使用 OCI 规范执行流水线（不依赖 podman）
 	var runPipelineName, runNodesPath, runArgsPath string
+	var runTimeout time.Duration
+	var runDetach bool
+	var runServerAddr string
+	var runDryRun bool
+	var runWaitNodes bool
 	runCmd := &cobra.Command{
 		Use:   "run",
 		Short: "执行流水线（按 DAG 顺序运行 OCI 容器）",
@@ -115,8 +120,41 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 			}
 
 			arRoot := filepath.Dir(config.PipelinesDir)
-			runner := NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot)
-			taskID, err := runner.Run(ctx, runPipelineName, nodes, runArgs, "")
+			runner := NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot, config.MaxParallel, ConfiguredStepContainerConfig())
+			if runDryRun {
+				// 仅渲染模板并输出执行计划，不创建任务目录与容器
+				plan, err := runner.Plan(runPipelineName, nodes, runArgs)
+				if err != nil {
+					logrus.Errorf("pipeline run --dry-run 失败: %v", err)
+					return err
+				}
+				printPipelinePlan(plan)
+				if len(plan.MissingImages) > 0 {
+					return fmt.Errorf("以下步骤镜像未导入镜像存储: %v", plan.MissingImages)
+				}
+				return nil
+			}
+
+			if runDetach {
+				// 交给本地 ar server 托管执行，提交后立即返回，终端断开不影响任务
+				taskID, err := submitToServer(runServerAddr, runPipelineName, nodes, runArgs, runTimeout, runWaitNodes)
+				if err != nil {
+					logrus.Errorf("pipeline run --detach 失败: %v", err)
+					return err
+				}
+				logrus.Infof("pipeline run: 已提交到 ar server taskId=%s", taskID)
+				fmt.Println("taskId:", taskID)
+				return nil
+			}
+
+			runCtx := ctx
+			if runWaitNodes {
+				runCtx = WithNodeLeaseWait(runCtx)
+			}
+			if runTimeout > 0 {
+				runCtx = WithPipelineTimeout(runCtx, runTimeout)
+			}
+			taskID, err := runner.Run(runCtx, runPipelineName, nodes, runArgs, "")
 			if err != nil {
 				logrus.Errorf("pipeline run 失败: %v", err)
 				return err
@@ -129,14 +167,24 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 	runCmd.Flags().StringVarP(&runPipelineName, "pipeline", "p", "", "流水线名称（对应 pipelines-dir 下的 <name>.template.json）")
 	runCmd.Flags().StringVarP(&runNodesPath, "nodes", "n", "", "节点列表 JSON 文件路径（格式见 design/节点管理.md）")
 	runCmd.Flags().StringVarP(&runArgsPath, "args", "a", "", "参数文件路径（JSON 键值对，模板中通过 {{index .args \"key\"}} 或 {{arg .args \"key\"}} 读取，可选）")
+	runCmd.Flags().IntVar(&config.MaxParallel, "max-parallel", 0, "同时运行的最大步骤数（0 表示不限制；模板中的 maxParallel 可进一步收紧）")
+	runCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "整条流水线的执行时限（如 30m，0 表示不限制；到期后运行中的步骤标记为 timeout）")
+	runCmd.Flags().BoolVarP(&runDetach, "detach", "d", false, "提交到本地 ar server 后台执行并立即返回 taskId（需先执行 ar server start）")
+	runCmd.Flags().StringVar(&runServerAddr, "server", "http://127.0.0.1:8080", "--detach 时提交任务的 ar server 地址")
+	runCmd.Flags().BoolVar(&runWaitNodes, "wait-nodes", false, "目标节点被其他任务占用时排队等待（默认直接拒绝执行）")
+	runCmd.Flags().BoolVar(&runDryRun, "dry-run", false, "仅渲染模板并输出执行计划（执行层级、各步骤最终命令与环境变量、镜像是否已导入），不创建容器")
 	_ = runCmd.MarkFlagRequired("pipeline")
 	_ = runCmd.MarkFlagRequired("nodes")
 	pipelineCmd.AddCommand(runCmd)
 
-	// ar pipeline task：任务相关操作（list / stop / resume / log 等）
+	// ar pipeline task：任务相关操作（list / stop / resume / pause / log 等）
 	var listPipelineName string
 	var stopTaskID string
 	var resumeTaskID string
+	var resumeFrom string
+	var resumeOnly string
+	var resumeWaitNodes bool
+	var skipTaskID string
 	var logTaskID string
 	var logContainerID string
 	var logFollow bool
@@ -171,7 +219,7 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 	taskStopCmd := &cobra.Command{
 		Use:   "stop",
 		Short: "停止指定流水线任务（按 taskId）",
-		Long:  "根据 taskId 查找对应流水线运行目录，停止正在运行的容器并将 pending/running 步骤状态标记为 cancelled，写回 pipeline.json（参照 design/停止流水线流程.md）。",
+		Long:  "根据 taskId 查找对应流水线运行目录，停止正在运行的容器并将 pending/running 步骤状态标记为 cancelled，写回 pipeline.json；同时写入停止标记，执行任务的进程（含 ar server 托管执行的任务）据此取消任务（参照 design/停止流水线流程.md）。",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			logrus.Info("pipeline task stop: 开始执行")
 			if stopTaskID == "" {
@@ -180,7 +228,8 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 			}
 			logrus.Debugf("pipeline task stop: taskId=%s", stopTaskID)
 			arRoot := filepath.Dir(config.PipelinesDir)
-			if err := stopPipelineTask(arRoot, config.OciRuntimeRoot, stopTaskID); err != nil {
+			runner := NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot, config.MaxParallel, ConfiguredStepContainerConfig())
+			if err := runner.Stop(stopTaskID); err != nil {
 				logrus.Errorf("pipeline task stop 失败: %v", err)
 				return err
 			}
@@ -196,7 +245,7 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 	taskResumeCmd := &cobra.Command{
 		Use:   "resume",
 		Short: "恢复被取消的流水线任务（按 taskId）",
-		Long:  "根据 taskId 查找对应流水线运行目录，读取 pipeline.json，确定上次执行到的步骤并从该步骤开始继续执行（参照 design/恢复流水线执行.md）。",
+		Long:  "根据 taskId 查找对应流水线运行目录，读取 pipeline.json，确定上次执行到的步骤并从该步骤开始继续执行（参照 design/恢复流水线执行.md）。可用 --from 从指定步骤重新执行（其后继一并重置），或用 --only 仅重新执行单个步骤。",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			logrus.Info("pipeline task resume: 开始执行")
 			if resumeTaskID == "" {
@@ -205,8 +254,13 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 			}
 			logrus.Debugf("pipeline task resume: taskId=%s", resumeTaskID)
 			arRoot := filepath.Dir(config.PipelinesDir)
-			runner := NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot)
-			if err := runner.Resume(ctx, resumeTaskID); err != nil {
+			runner := NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot, config.MaxParallel, ConfiguredStepContainerConfig())
+			opts := ResumeOptions{From: resumeFrom, Only: resumeOnly}
+			resumeCtx := ctx
+			if resumeWaitNodes {
+				resumeCtx = WithNodeLeaseWait(resumeCtx)
+			}
+			if err := runner.ResumeWith(resumeCtx, resumeTaskID, opts); err != nil {
 				logrus.Errorf("pipeline task resume 失败: %v", err)
 				return err
 			}
@@ -215,9 +269,118 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 		},
 	}
 	taskResumeCmd.Flags().StringVarP(&resumeTaskID, "task", "t", "", "要恢复的流水线任务 ID（必填）")
+	taskResumeCmd.Flags().StringVar(&resumeFrom, "from", "", "从指定步骤重新执行：该步骤及其全部后继重置为 pending（forEach 步骤可用模板中的步骤名）")
+	taskResumeCmd.Flags().StringVar(&resumeOnly, "only", "", "仅重新执行指定步骤，其余步骤不执行")
+	taskResumeCmd.MarkFlagsMutuallyExclusive("from", "only")
+	taskResumeCmd.Flags().BoolVar(&resumeWaitNodes, "wait-nodes", false, "目标节点被其他任务占用时排队等待（默认直接拒绝执行）")
+	taskResumeCmd.Flags().IntVar(&config.MaxParallel, "max-parallel", 0, "同时运行的最大步骤数（0 表示不限制；模板中的 maxParallel 可进一步收紧）")
 	_ = taskResumeCmd.MarkFlagRequired("task")
 	taskCmd.AddCommand(taskResumeCmd)
 
+	// ar pipeline task skip -t <taskId> <step>
+	taskSkipCmd := &cobra.Command{
+		Use:   "skip <step>",
+		Short: "将流水线任务中的步骤标记为 skipped（按 taskId）",
+		Long:  "将 pipeline.json 中指定步骤标记为 skipped（forEach 步骤可用模板中的步骤名，作用于全部节点实例），之后 resume 时其后继视为依赖已满足。正在运行或等待审批的步骤需先停止任务。",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logrus.Info("pipeline task skip: 开始执行")
+			if skipTaskID == "" {
+				logrus.Error("pipeline task skip: 未指定 -t taskId")
+				return fmt.Errorf("请通过 -t 指定流水线任务 ID（taskId）")
+			}
+			logrus.Debugf("pipeline task skip: taskId=%s step=%s", skipTaskID, args[0])
+			arRoot := filepath.Dir(config.PipelinesDir)
+			if _, err := SkipStep(arRoot, skipTaskID, args[0]); err != nil {
+				logrus.Errorf("pipeline task skip 失败: %v", err)
+				return err
+			}
+			logrus.Infof("pipeline task skip: 完成 taskId=%s step=%s", skipTaskID, args[0])
+			return nil
+		},
+	}
+	taskSkipCmd.Flags().StringVarP(&skipTaskID, "task", "t", "", "流水线任务 ID（必填）")
+	_ = taskSkipCmd.MarkFlagRequired("task")
+	taskCmd.AddCommand(taskSkipCmd)
+
+	// ar pipeline task approve/reject -t <taskId> [-s <step>]：处理等待审批的步骤
+	for _, approved := range []bool{true, false} {
+		var taskID, stepName, approver, comment string
+		use, short, action := "approve", "批准流水线任务中等待审批的步骤", "approve"
+		if !approved {
+			use, short, action = "reject", "拒绝流水线任务中等待审批的步骤（步骤记为 failed）", "reject"
+		}
+		decideCmd := &cobra.Command{
+			Use:   use,
+			Short: short,
+			Long:  "根据 taskId 查找处于 waiting 状态的审批步骤并提交审批结果，执行任务的进程据此继续调度，审批人、时间与结果记录在 pipeline.json 的 approvalResult 字段。任务只有一个等待审批的步骤时可省略 -s。",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				logrus.Infof("pipeline task %s: 开始执行", action)
+				if taskID == "" {
+					logrus.Errorf("pipeline task %s: 未指定 -t taskId", action)
+					return fmt.Errorf("请通过 -t 指定流水线任务 ID（taskId）")
+				}
+				logrus.Debugf("pipeline task %s: taskId=%s step=%s by=%s", action, taskID, stepName, approver)
+				arRoot := filepath.Dir(config.PipelinesDir)
+				if err := DecideApproval(arRoot, taskID, stepName, approver, comment, approved); err != nil {
+					logrus.Errorf("pipeline task %s 失败: %v", action, err)
+					return err
+				}
+				logrus.Infof("pipeline task %s: 完成 taskId=%s", action, taskID)
+				return nil
+			},
+		}
+		decideCmd.Flags().StringVarP(&taskID, "task", "t", "", "流水线任务 ID（必填）")
+		decideCmd.Flags().StringVarP(&stepName, "step", "s", "", "审批步骤名（任务只有一个等待审批的步骤时可省略）")
+		decideCmd.Flags().StringVar(&approver, "by", "", "审批人（默认当前系统用户，sudo 执行时取 SUDO_USER）")
+		decideCmd.Flags().StringVarP(&comment, "comment", "m", "", "审批意见（可选）")
+		_ = decideCmd.MarkFlagRequired("task")
+		taskCmd.AddCommand(decideCmd)
+	}
+
+	// ar pipeline task pause/unpause -t <taskId>：冻结/解冻任务
+	for _, pause := range []bool{true, false} {
+		var taskID, by string
+		use, short, long := "pause", "暂停流水线任务（冻结正在运行的步骤容器，不再启动新步骤）",
+			"根据 taskId 暂停任务：执行任务的进程通过 cgroup freezer 冻结正在运行的步骤容器（进程与 SSH 会话保持不变），不再启动新步骤与重试，任务状态记为 paused；正在运行的子流水线一并暂停。暂停期间步骤与流水线的 timeout 不计时。"
+		if !pause {
+			use, short, long = "unpause", "恢复已暂停的流水线任务",
+				"根据 taskId 解冻被暂停任务的步骤容器并继续调度后续步骤，任务状态恢复为 running（有等待审批的步骤时为 waiting）。"
+		}
+		pauseCmd := &cobra.Command{
+			Use:   use,
+			Short: short,
+			Long:  long,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				logrus.Infof("pipeline task %s: 开始执行", use)
+				if taskID == "" {
+					logrus.Errorf("pipeline task %s: 未指定 -t taskId", use)
+					return fmt.Errorf("请通过 -t 指定流水线任务 ID（taskId）")
+				}
+				logrus.Debugf("pipeline task %s: taskId=%s", use, taskID)
+				arRoot := filepath.Dir(config.PipelinesDir)
+				var err error
+				if pause {
+					_, err = PauseTask(arRoot, taskID, by)
+				} else {
+					_, err = UnpauseTask(arRoot, taskID)
+				}
+				if err != nil {
+					logrus.Errorf("pipeline task %s 失败: %v", use, err)
+					return err
+				}
+				logrus.Infof("pipeline task %s: 完成 taskId=%s", use, taskID)
+				return nil
+			},
+		}
+		pauseCmd.Flags().StringVarP(&taskID, "task", "t", "", "流水线任务 ID（必填）")
+		if pause {
+			pauseCmd.Flags().StringVar(&by, "by", "", "操作人（默认当前系统用户，sudo 执行时取 SUDO_USER）")
+		}
+		_ = pauseCmd.MarkFlagRequired("task")
+		taskCmd.AddCommand(pauseCmd)
+	}
+
 	// ar pipeline task log -t <taskId> -c <containerId>
 	taskLogCmd := &cobra.Command{
 		Use:   "log",
@@ -257,6 +420,44 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 	_ = taskLogCmd.MarkFlagRequired("task")
 	taskCmd.AddCommand(taskLogCmd)
 
+	// ar pipeline task exec -t <taskId> -c <containerId> -- <command>
+	var execOpts TaskExecOptions
+	var execNoTTY bool
+	taskExecCmd := &cobra.Command{
+		Use:   "exec -t TASK_ID [-c CONTAINER_ID] -- COMMAND [ARG...]",
+		Short: "在流水线任务正在运行的步骤容器中执行命令（如 -- sh 进入容器排查）",
+		Long:  "根据 taskId 找到正在运行的步骤容器（容器 ID 与 task list 展示的一致，任务只有一个运行中的步骤容器时可省略 -c），在该容器的命名空间中启动附加进程，用户、工作目录与环境变量沿用步骤的配置（敏感值已掩码，可从 /run/secrets 读取）。标准输入为终端时默认分配 TTY。退出码与命令一致。",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logrus.Info("pipeline task exec: 开始执行")
+			if execOpts.TaskID == "" {
+				logrus.Error("pipeline task exec: 未指定 -t taskId")
+				return fmt.Errorf("请通过 -t 指定流水线任务 ID（taskId）")
+			}
+			execOpts.Command = args
+			execOpts.TTY = !execNoTTY && isTerminal(os.Stdin)
+			logrus.Debugf("pipeline task exec: taskId=%s container=%s tty=%v command=%v", execOpts.TaskID, execOpts.ContainerID, execOpts.TTY, args)
+			arRoot := filepath.Dir(config.PipelinesDir)
+			exitCode, err := ExecInTask(arRoot, config.OciRuntimeRoot, execOpts)
+			if err != nil {
+				logrus.Errorf("pipeline task exec 失败: %v", err)
+				return err
+			}
+			logrus.Infof("pipeline task exec: 完成 exitCode=%d", exitCode)
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+	taskExecCmd.Flags().StringVarP(&execOpts.TaskID, "task", "t", "", "流水线任务 ID（必填）")
+	taskExecCmd.Flags().StringVarP(&execOpts.ContainerID, "container", "c", "", "步骤容器 ID（见 ar pipeline task list；任务只有一个正在运行的步骤容器时可省略）")
+	taskExecCmd.Flags().StringArrayVarP(&execOpts.Env, "env", "e", nil, "追加环境变量 KEY=VALUE（可重复）")
+	taskExecCmd.Flags().StringVarP(&execOpts.Cwd, "workdir", "w", "", "工作目录（默认沿用步骤的工作目录）")
+	taskExecCmd.Flags().BoolVar(&execNoTTY, "no-tty", false, "不分配 TTY（标准输入不是终端时自动不分配）")
+	_ = taskExecCmd.MarkFlagRequired("task")
+	taskCmd.AddCommand(taskExecCmd)
+
 	// pipeline build：根据 design/构建流水线镜像流程.md 构建流水线镜像，FROM 行为参照 docker build
 	var buildTemplatePath string
 	var buildImageTag string
@@ -315,6 +516,7 @@ func AddCommand(ctx context.Context, rootCommand *cobra.Command) {
 	addImageCommand(rootCommand)
 	addPipelineCommand(pipelineCmd)
 	addNodeCommand(rootCommand)
+	addSecretCommand(rootCommand)
 }
 
 // listRunningTasks 扫描 /var/lib/ar/tasks 目录，列出所有包含 running 步骤的流水线任务。
@@ -373,17 +575,30 @@ func listRunningTasks(arRoot, filterPipelineName string) error {
 				continue
 			}
 			pipelineName := runData.PipelineName
-			for i, step := range runData.Steps {
-				if step.Status != StatusRunning {
-					continue
+			// 包含主流程与钩子步骤
+			for _, group := range runData.stepGroups() {
+				for i, step := range group.steps {
+					if step.Status != StatusRunning && step.Status != StatusWaiting {
+						continue
+					}
+					containerID := step.ContainerID
+					if containerID == "" {
+						containerID = latestContainerID(pipelineDirName, group.indexBase+i, step)
+					}
+					switch {
+					case step.Pipeline != "":
+						// 子流水线步骤没有容器，展示其子任务 ID（子任务的容器在子任务行中列出）
+						containerID = "task:" + step.ChildTaskID
+					case step.Approval != nil:
+						containerID = "(等待审批)"
+					}
+					rows = append(rows, runningRow{
+						pipelineName: pipelineName,
+						taskID:       taskID,
+						stepName:     step.Name,
+						containerID:  containerID,
+					})
 				}
-				containerID := fmt.Sprintf("ar_%s_%s_%d", pipelineDirName, sanitizeStepNameForContainerID(step.Name, i+1), i+1)
-				rows = append(rows, runningRow{
-					pipelineName: pipelineName,
-					taskID:       taskID,
-					stepName:     step.Name,
-					containerID:  containerID,
-				})
 			}
 		}
 	}
@@ -404,10 +619,87 @@ func listRunningTasks(arRoot, filterPipelineName string) error {
 	return nil
 }
 
+// printPipelinePlan 输出 dry-run 执行计划：执行层级，以及各步骤的镜像、最终命令与环境变量（敏感值已掩码）。
+func printPipelinePlan(plan *PipelinePlan) {
+	fmt.Printf("流水线: %s\n", plan.PipelineName)
+	if plan.MaxParallel > 0 {
+		fmt.Printf("并发上限: %d\n", plan.MaxParallel)
+	}
+	if plan.Timeout != "" {
+		fmt.Printf("执行时限: %s\n", plan.Timeout)
+	}
+	if plan.FailurePolicy != "" {
+		fmt.Printf("失败策略: %s\n", plan.FailurePolicy)
+	}
+	fmt.Println("执行层级:")
+	for i, level := range plan.Levels {
+		fmt.Printf("  %d: %s\n", i+1, strings.Join(level, ", "))
+	}
+	for _, group := range []struct {
+		title string
+		steps []PlannedStep
+	}{{"步骤", plan.Steps}, {"onSuccess 钩子", plan.OnSuccess}, {"onFailure 钩子", plan.OnFailure}, {"always 钩子", plan.Always}} {
+		if len(group.steps) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", group.title)
+		for _, step := range group.steps {
+			printPlannedStep(step)
+		}
+	}
+}
+
+func printPlannedStep(step PlannedStep) {
+	fmt.Printf("- %s\n", step.Name)
+	switch {
+	case step.Pipeline != "":
+		fmt.Printf("    子流水线: %s\n", step.Pipeline)
+	case step.Approval:
+		fmt.Println("    审批步骤（等待人工批准）")
+	default:
+		present := "已导入"
+		if step.ImagePresent == nil || !*step.ImagePresent {
+			present = "未导入"
+		}
+		fmt.Printf("    镜像: %s（%s）\n", step.Image, present)
+		if len(step.Command) > 0 {
+			fmt.Printf("    命令: %s\n", strings.Join(step.Command, " "))
+		} else {
+			fmt.Printf("    entrypoint: %s\n", step.Entrypoint)
+			fmt.Printf("    args: %s\n", strings.Join(step.Args, " "))
+		}
+		for _, kv := range step.Env {
+			fmt.Printf("    env: %s\n", kv)
+		}
+		if res := step.Resources; res != nil {
+			var limits []string
+			if res.CPU != "" {
+				limits = append(limits, "cpu="+res.CPU)
+			}
+			if res.Memory != "" {
+				limits = append(limits, "memory="+res.Memory)
+			}
+			if res.Pids > 0 {
+				limits = append(limits, fmt.Sprintf("pids=%d", res.Pids))
+			}
+			fmt.Printf("    资源限制: %s\n", strings.Join(limits, " "))
+		}
+	}
+	if step.When != "" {
+		fmt.Printf("    when: %s\n", step.When)
+	}
+	if step.Deferred {
+		fmt.Println("    （命令引用了其他步骤的输出，启动前渲染）")
+	}
+	if len(step.Nodes) > 0 {
+		fmt.Printf("    后继: %s\n", strings.Join(step.Nodes, ", "))
+	}
+}
+
 // stopPipelineTask 参照 design/停止流水线流程.md，实现按 taskId 停止流水线任务：
 // 1. 根据 taskId 找到运行目录（包含 pipeline.json）。
-// 2. 标记 pending/running 步骤为 cancelled。
-// 3. 对于 running 步骤，调用 OCI runtime 停止并删除对应容器。
+// 2. 标记 pending/queued/running 步骤为 cancelled。
+// 3. 对于 running 步骤，调用 OCI runtime 停止并删除对应容器；running 的子流水线步骤递归停止其子任务。
 // 4. 写回 pipeline.json。
 func stopPipelineTask(arRoot, runtimeRoot, taskID string) error {
 	if taskID == "" {
@@ -423,30 +715,51 @@ func stopPipelineTask(arRoot, runtimeRoot, taskID string) error {
 		return fmt.Errorf("读取 pipeline.json 失败: %w", err)
 	}
 
+	// 先写停止标记再停止容器：执行进程据此取消任务，不再启动新步骤与重试（见 run_stop.go）
+	if err := requestTaskStop(runDir); err != nil {
+		logrus.Warn(err)
+	}
+
 	// 根据 runDir 反推出流水线目录名（已是 sanitize 之后的名字）。
 	pipelineDirName := filepath.Base(filepath.Dir(runDir))
 
-	// 按设计：正在运行的节点需要停止容器，未运行的节点标记为取消。
-	for i := range runData.Steps {
-		step := &runData.Steps[i]
-		switch step.Status {
-		case StatusRunning:
-			// 计算容器 ID，与 Run()/Resume() 时保持一致。
-			containerID := fmt.Sprintf("ar_%s_%s_%d", pipelineDirName, sanitizeStepNameForContainerID(step.Name, i+1), i+1)
+	// 按设计：正在运行的节点（含钩子步骤）需要停止容器，未运行的节点标记为取消。
+	for _, group := range runData.stepGroups() {
+		for i, step := range group.steps {
+			if step.Status != StatusRunning {
+				continue
+			}
+			// 子流水线步骤没有自己的容器，级联停止其子任务
+			if step.Pipeline != "" {
+				if step.ChildTaskID == "" {
+					continue
+				}
+				if err := stopPipelineTask(arRoot, runtimeRoot, step.ChildTaskID); err != nil {
+					logrus.WithError(err).Warnf("停止子流水线任务失败: %s", step.ChildTaskID)
+				}
+				continue
+			}
+			// 计算容器 ID，与 Run()/Resume() 时保持一致；重试产生的容器 ID 以其为前缀，会一并停止。
+			containerID := stepContainerID(pipelineDirName, step.Name, group.indexBase+i, 1)
 			if err := container.StopAndRemoveOCIContainers(runtimeRoot, containerID); err != nil {
 				logrus.WithError(err).Warnf("停止流水线任务容器失败: %s", containerID)
 			}
-			step.Status = StatusCancelled
-		case StatusPending:
-			step.Status = StatusCancelled
-		default:
-			// success / failed / cancelled 等状态保持不变
 		}
 	}
+	// success / failed 等已结束的步骤保持不变
+	CancelTask(runData)
+	runData.Pause = nil
 
 	if err := WritePipelineJSON(runDir, runData); err != nil {
 		return fmt.Errorf("写回 pipeline.json 失败: %w", err)
 	}
+	// 暂停中的任务被停止后不再保留暂停标记，避免恢复执行时再次暂停
+	if err := clearTaskPause(runDir); err != nil {
+		logrus.Warn(err)
+	}
+
+	// 执行进程可能已不存在（如被 kill），由停止方释放节点租约
+	ReleaseNodeLeases(arRoot, taskID)
 
 	logrus.Infof("流水线任务已停止: taskId=%s runDir=%s", taskID, runDir)
 	return nil
@@ -455,7 +768,7 @@ func stopPipelineTask(arRoot, runtimeRoot, taskID string) error {
 // showTaskContainerLogs 根据 taskId 和容器 ID 输出对应容器的 stdout/stderr 日志。
 // 日志文件位于任务运行目录下的 logs 子目录中，命名为 <containerID>.stdout 和 <containerID>.stderr。
 // 支持类似 docker logs 的 --follow 与 --tail。
-// 若 containerID 为空，则按照 pipeline.json 中的步骤依次计算容器 ID，并输出该任务下所有步骤容器的日志。
+// 若 containerID 为空，则按照 pipeline.json 中的步骤（及其各次尝试）依次计算容器 ID，并输出该任务下所有步骤容器的日志。
 func showTaskContainerLogs(arRoot, taskID, containerID string, follow bool, tailLines int) error {
 	if strings.TrimSpace(taskID) == "" {
 		return fmt.Errorf("taskId 不能为空")
@@ -474,10 +787,25 @@ func showTaskContainerLogs(arRoot, taskID, containerID string, follow bool, tail
 		}
 		pipelineDirName := filepath.Base(filepath.Dir(runDir))
 
-		for i, step := range runData.Steps {
-			cid := fmt.Sprintf("ar_%s_%s_%d", pipelineDirName, sanitizeStepNameForContainerID(step.Name, i+1), i+1)
-			if err := showOneContainerLogs(runDir, cid, follow, tailLines); err != nil {
-				return err
+		for _, group := range runData.stepGroups() {
+			for i, step := range group.steps {
+				// 子流水线步骤与审批步骤没有容器日志（子流水线请按其 childTaskId 查看子任务日志）
+				if step.Pipeline != "" || step.Approval != nil {
+					continue
+				}
+				// 步骤有多次尝试（重试）时，依次输出每次尝试的容器日志
+				cids := []string{stepContainerID(pipelineDirName, step.Name, group.indexBase+i, 1)}
+				if len(step.Attempts) > 0 {
+					cids = cids[:0]
+					for _, a := range step.Attempts {
+						cids = append(cids, a.ContainerID)
+					}
+				}
+				for _, cid := range cids {
+					if err := showOneContainerLogs(runDir, cid, follow, tailLines); err != nil {
+						return err
+					}
+				}
 			}
 		}
 		return nil
@@ -784,7 +1112,7 @@ func addImageCommand(rootCommand *cobra.Command) {
 			}
 			logrus.Debugf("image rm: 待删除 %d 个镜像: %v", len(args), args)
 			for _, name := range args {
-				if err := DeleteImage(config.ImagesStoreDir, name); err != nil {
+				if err := DeleteImage(config.ImagesStoreDir, filepath.Dir(config.PipelinesDir), name); err != nil {
 					logrus.Errorf("image rm 删除 %s 失败: %v", name, err)
 					return err
 				}
@@ -806,7 +1134,7 @@ func addImageCommand(rootCommand *cobra.Command) {
 			logrus.Info("image prune: 开始执行")
 			logrus.Debugf("image prune: all=%v imagesStoreDir=%s pipelinesDir=%s", pruneAll, config.ImagesStoreDir, config.PipelinesDir)
 			if pruneAll {
-				pruned, err := PruneAllImages(config.ImagesStoreDir)
+				pruned, err := PruneAllImages(config.ImagesStoreDir, filepath.Dir(config.PipelinesDir))
 				if err != nil {
 					logrus.Errorf("image prune --all 失败: %v", err)
 					return err
@@ -817,7 +1145,7 @@ func addImageCommand(rootCommand *cobra.Command) {
 				logrus.Infof("image prune: 完成，共删除 %d 个镜像", len(pruned))
 				return nil
 			}
-			pruned, err := PruneImages(config.ImagesStoreDir, config.PipelinesDir)
+			pruned, err := PruneImages(config.ImagesStoreDir, config.PipelinesDir, filepath.Dir(config.PipelinesDir))
 			if err != nil {
 				logrus.Errorf("image prune 失败: %v", err)
 				return err
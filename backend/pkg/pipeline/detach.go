@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// runPipelineMutation 通过本地 ar server 提交流水线任务的 GraphQL 请求。
+const runPipelineMutation = `mutation RunPipeline($input: RunPipelineInput!) {
+  runPipeline(input: $input) { taskId status }
+}`
+
+// submitToServer 将流水线任务提交给 serverAddr（如 http://127.0.0.1:8080）上的 ar server 执行，返回 taskId。
+// 任务由 server 进程托管，提交后命令即可退出。waitNodes 为 true 时目标节点被占用的任务在 server 中排队等待。
+func submitToServer(serverAddr, pipelineName string, nodes []RunNode, args map[string]interface{}, timeout time.Duration, waitNodes bool) (string, error) {
+	type labelInput struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	type nodeInput struct {
+		IP         string       `json:"ip"`
+		IntranetIP string       `json:"intranetIp,omitempty"`
+		Port       string       `json:"port,omitempty"`
+		Username   string       `json:"username"`
+		Password   string       `json:"password"`
+		Labels     []labelInput `json:"labels"`
+	}
+	input := map[string]interface{}{"pipelineName": pipelineName}
+	nodeInputs := make([]nodeInput, 0, len(nodes))
+	for _, n := range nodes {
+		labels := make([]labelInput, 0, len(n.Labels))
+		for _, l := range n.Labels {
+			labels = append(labels, labelInput{Key: l.Key, Value: l.Value})
+		}
+		nodeInputs = append(nodeInputs, nodeInput{IP: n.IP, IntranetIP: n.IntranetIP, Port: n.Port, Username: n.Username, Password: n.Password, Labels: labels})
+	}
+	input["nodes"] = nodeInputs
+	if args != nil {
+		argsJSON, err := json.Marshal(args)
+		if err != nil {
+			return "", fmt.Errorf("序列化参数失败: %w", err)
+		}
+		input["args"] = string(argsJSON)
+	}
+	if timeout > 0 {
+		input["timeout"] = timeout.String()
+	}
+	if waitNodes {
+		input["waitForNodes"] = true
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     runPipelineMutation,
+		"variables": map[string]interface{}{"input": input},
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化请求失败: %w", err)
+	}
+	url := strings.TrimRight(serverAddr, "/") + "/graphql"
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("提交任务到 ar server 失败（请确认已执行 ar server start）%s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取 ar server 响应失败: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			RunPipeline *struct {
+				TaskID string `json:"taskId"`
+			} `json:"runPipeline"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析 ar server 响应失败（HTTP %d）: %w", resp.StatusCode, err)
+	}
+	if len(result.Errors) > 0 {
+		msgs := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return "", fmt.Errorf("ar server 拒绝执行流水线: %s", strings.Join(msgs, "; "))
+	}
+	if result.Data.RunPipeline == nil || result.Data.RunPipeline.TaskID == "" {
+		return "", fmt.Errorf("ar server 未返回 taskId（HTTP %d）", resp.StatusCode)
+	}
+	return result.Data.RunPipeline.TaskID, nil
+}
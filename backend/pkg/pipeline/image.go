@@ -14,6 +14,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/layout"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
 )
 
 // ImageEntry 表示镜像仓库中的一条镜像记录。
@@ -39,6 +40,7 @@ type authFile struct {
 const authFilePath = "/var/lib/ar/auth.json"
 
 // SaveRegistryAuth 保存指定 registry 的用户名和密码，供后续镜像拉取使用。
+// 密码加密保存到密钥存储（registry-<server>），登录配置中只记录其引用。
 func SaveRegistryAuth(server, username, password string) error {
 	server = strings.TrimSpace(server)
 	if server == "" {
@@ -56,9 +58,13 @@ func SaveRegistryAuth(server, username, password string) error {
 	if data.Registries == nil {
 		data.Registries = make(map[string]registryAuthEntry)
 	}
+	passwordRef, err := StoreSecretValue(ConfiguredSecretStore(), RegistrySecretName(server), password)
+	if err != nil {
+		return fmt.Errorf("保存 registry 密码失败: %w", err)
+	}
 	data.Registries[server] = registryAuthEntry{
 		Username: username,
-		Password: password,
+		Password: passwordRef,
 	}
 
 	if err := saveAuthFile(data); err != nil {
@@ -67,7 +73,7 @@ func SaveRegistryAuth(server, username, password string) error {
 	return nil
 }
 
-// getAuthForRegistry 读取指定 registry 的认证信息。
+// getAuthForRegistry 读取指定 registry 的认证信息，密码为密钥引用时从密钥存储解析（旧版本保存的明文密码原样使用）。
 func getAuthForRegistry(server string) (registryAuthEntry, bool, error) {
 	server = strings.TrimSpace(server)
 	if server == "" {
@@ -81,7 +87,15 @@ func getAuthForRegistry(server string) (registryAuthEntry, bool, error) {
 		return registryAuthEntry{}, false, nil
 	}
 	entry, ok := data.Registries[server]
-	return entry, ok, nil
+	if !ok {
+		return entry, false, nil
+	}
+	password, err := ResolveSecretRefs(entry.Password, ConfiguredSecretStore(), nil)
+	if err != nil {
+		return registryAuthEntry{}, false, fmt.Errorf("读取 registry %s 的密码失败: %w", server, err)
+	}
+	entry.Password = password
+	return entry, true, nil
 }
 
 func loadAuthFile() (*authFile, error) {
@@ -160,23 +174,32 @@ func ListImages(storeDir string) ([]ImageEntry, error) {
 	return list, nil
 }
 
+// findImageEntry 按存储目录名、镜像引用或规范化后的目录名在 list 中查找镜像。
+func findImageEntry(list []ImageEntry, imageNameOrRef string) (ImageEntry, bool) {
+	safe := sanitizeImageName(imageNameOrRef)
+	for _, e := range list {
+		if e.Name == imageNameOrRef || e.Ref == imageNameOrRef || (safe != "" && e.Name == safe) {
+			return e, true
+		}
+	}
+	return ImageEntry{}, false
+}
+
 // OpenImageFromStore 根据镜像名或引用从 storeDir 中打开 v1.Image，供 run 使用。
 func OpenImageFromStore(storeDir, imageNameOrRef string) (v1.Image, error) {
 	list, err := ListImages(storeDir)
 	if err != nil {
 		return nil, err
 	}
-	safe := sanitizeImageName(imageNameOrRef)
-	var layoutPath string
-	for _, e := range list {
-		if e.Name == imageNameOrRef || e.Ref == imageNameOrRef || (safe != "" && e.Name == safe) {
-			layoutPath = e.Path
-			break
-		}
-	}
-	if layoutPath == "" {
+	entry, ok := findImageEntry(list, imageNameOrRef)
+	if !ok {
 		return nil, fmt.Errorf("镜像未找到: %s（请先 ar load 导入）", imageNameOrRef)
 	}
+	return openImageLayout(entry.Path)
+}
+
+// openImageLayout 打开 OCI layout 目录中的第一个可用镜像。
+func openImageLayout(layoutPath string) (v1.Image, error) {
 	ociPath, err := layout.FromPath(layoutPath)
 	if err != nil {
 		return nil, fmt.Errorf("打开 OCI layout 失败 %s: %w", layoutPath, err)
@@ -221,7 +244,9 @@ func pullRemoteImage(imageRef string, tlsVerify bool) (v1.Image, error) {
 			},
 		}))
 	}
-	if entry, ok, _ := getAuthForRegistry(ref.Context().RegistryStr()); ok {
+	if entry, ok, err := getAuthForRegistry(ref.Context().RegistryStr()); err != nil {
+		logrus.Warnf("读取登录信息失败，以匿名方式访问 registry: %v", err)
+	} else if ok {
 		remoteOptions = append(remoteOptions, remote.WithAuth(&authn.Basic{
 			Username: entry.Username,
 			Password: entry.Password,
@@ -315,7 +340,9 @@ func PushImageFromStore(imageNameOrRef, storeDir, targetRef string, tlsVerify bo
 	}
 
 	// 若存在针对该 registry 的登录信息，则使用 basic auth（与 PullImageToStore 行为保持一致）。
-	if entry, ok, _ := getAuthForRegistry(ref.Context().RegistryStr()); ok {
+	if entry, ok, err := getAuthForRegistry(ref.Context().RegistryStr()); err != nil {
+		logrus.Warnf("读取登录信息失败，以匿名方式访问 registry: %v", err)
+	} else if ok {
 		remoteOptions = append(remoteOptions, remote.WithAuth(&authn.Basic{
 			Username: entry.Username,
 			Password: entry.Password,
@@ -391,8 +418,9 @@ func dirSize(root string) int64 {
 	return total
 }
 
-// DeleteImage 从 storeDir 中删除指定名称的镜像目录。
-func DeleteImage(storeDir, name string) error {
+// DeleteImage 从 storeDir 中删除指定名称的镜像目录，并删除其在 arRoot 下的 rootfs 缓存；
+// 缓存正被运行中的步骤使用时拒绝删除。
+func DeleteImage(storeDir, arRoot, name string) error {
 	if storeDir == "" || name == "" {
 		return fmt.Errorf("镜像存储目录和镜像名不能为空")
 	}
@@ -411,13 +439,35 @@ func DeleteImage(storeDir, name string) error {
 		}
 		return fmt.Errorf("访问镜像目录失败: %w", err)
 	}
+	if err := releaseImageRootfsCache(storeDir, arRoot, path); err != nil {
+		return fmt.Errorf("镜像 %s 正在使用中，无法删除: %w", name, err)
+	}
 	if err := os.RemoveAll(path); err != nil {
 		return fmt.Errorf("删除镜像失败 %s: %w", path, err)
 	}
 	return nil
 }
 
-// ReferencedImageNames 从 pipelinesDir 下所有 *.template.json 中收集引用的镜像名（存储目录名形式）。
+// releaseImageRootfsCache 在删除镜像目录 path 之前删除其 rootfs 缓存：存储中仍有其他镜像目录具有相同 digest 时保留缓存；
+// 缓存正被步骤容器引用时返回错误，此时不应删除镜像。
+func releaseImageRootfsCache(storeDir, arRoot, path string) error {
+	_, digest, err := readImageRefAndDigestFromLayout(path)
+	if err != nil || digest == "" {
+		return nil
+	}
+	list, err := ListImages(storeDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range list {
+		if e.Digest == digest && filepath.Clean(e.Path) != filepath.Clean(path) {
+			return nil
+		}
+	}
+	return removeRootfsCache(RootfsCacheDir(arRoot), digest)
+}
+
+// ReferencedImageNames 从 pipelinesDir 下所有 *.template.json 的主流程与钩子步骤中收集引用的镜像名（存储目录名形式）。
 func ReferencedImageNames(pipelinesDir string) (map[string]struct{}, error) {
 	refs := make(map[string]struct{})
 	if pipelinesDir == "" {
@@ -440,30 +490,32 @@ func ReferencedImageNames(pipelinesDir string) (map[string]struct{}, error) {
 		if err != nil {
 			continue
 		}
-		var steps []struct {
-			Image string `json:"image"`
-		}
-		if err := json.Unmarshal(data, &steps); err != nil {
+		tpl, err := parsePipelineTemplate(data)
+		if err != nil {
 			continue
 		}
-		for _, s := range steps {
-			img := strings.TrimSpace(s.Image)
-			if img == "" {
-				continue
-			}
-			safe := sanitizeImageName(img)
-			if safe != "" {
-				refs[safe] = struct{}{}
+		// 钩子步骤的镜像只在主流程结束后使用，同样视为被引用
+		for _, steps := range [][]TemplateStep{tpl.Steps, tpl.OnSuccess, tpl.OnFailure, tpl.Always} {
+			for _, s := range steps {
+				img := strings.TrimSpace(s.Image)
+				if img == "" {
+					continue
+				}
+				safe := sanitizeImageName(img)
+				if safe != "" {
+					refs[safe] = struct{}{}
+				}
+				// 同时保留未 sanitize 的 key，便于按“目录名”匹配
+				refs[img] = struct{}{}
 			}
-			// 同时保留未 sanitize 的 key，便于按“目录名”匹配
-			refs[img] = struct{}{}
 		}
 	}
 	return refs, nil
 }
 
-// PruneImages 删除 storeDir 中未被流水线引用的镜像；返回被删除的镜像名列表。
-func PruneImages(storeDir, pipelinesDir string) ([]string, error) {
+// PruneImages 删除 storeDir 中未被流水线引用的镜像及其 rootfs 缓存；返回被删除的镜像名列表。
+// rootfs 缓存正被步骤容器使用的镜像跳过；不属于任何剩余镜像的缓存一并清理。
+func PruneImages(storeDir, pipelinesDir, arRoot string) ([]string, error) {
 	referenced, err := ReferencedImageNames(pipelinesDir)
 	if err != nil {
 		return nil, err
@@ -480,26 +532,56 @@ func PruneImages(storeDir, pipelinesDir string) ([]string, error) {
 		if _, ok := referenced[entry.Ref]; ok {
 			continue
 		}
+		if err := releaseImageRootfsCache(storeDir, arRoot, entry.Path); err != nil {
+			logrus.Warnf("镜像 %s 正在使用中，跳过: %v", entry.Name, err)
+			continue
+		}
 		if err := os.RemoveAll(entry.Path); err != nil {
 			return pruned, fmt.Errorf("删除未引用镜像 %s 失败: %w", entry.Name, err)
 		}
 		pruned = append(pruned, entry.Name)
 	}
+	if err := pruneOrphanRootfsCache(storeDir, arRoot); err != nil {
+		return pruned, err
+	}
 	return pruned, nil
 }
 
-// PruneAllImages 删除 storeDir 下所有镜像目录（用于 image prune --all）。
-func PruneAllImages(storeDir string) ([]string, error) {
+// pruneOrphanRootfsCache 清理不属于 storeDir 中任何镜像的 rootfs 缓存（如同名镜像重新导入后遗留的旧 digest 缓存）。
+func pruneOrphanRootfsCache(storeDir, arRoot string) error {
+	list, err := ListImages(storeDir)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(list))
+	for _, e := range list {
+		if e.Digest != "" {
+			keep[e.Digest] = true
+		}
+	}
+	pruneRootfsCache(RootfsCacheDir(arRoot), keep)
+	return nil
+}
+
+// PruneAllImages 删除 storeDir 下所有镜像目录及其 rootfs 缓存（用于 image prune --all）；rootfs 缓存正被步骤容器使用的镜像跳过。
+func PruneAllImages(storeDir, arRoot string) ([]string, error) {
 	list, err := ListImages(storeDir)
 	if err != nil {
 		return nil, err
 	}
 	var pruned []string
 	for _, entry := range list {
+		if err := releaseImageRootfsCache(storeDir, arRoot, entry.Path); err != nil {
+			logrus.Warnf("镜像 %s 正在使用中，跳过: %v", entry.Name, err)
+			continue
+		}
 		if err := os.RemoveAll(entry.Path); err != nil {
 			return pruned, fmt.Errorf("删除镜像 %s 失败: %w", entry.Name, err)
 		}
 		pruned = append(pruned, entry.Name)
 	}
+	if err := pruneOrphanRootfsCache(storeDir, arRoot); err != nil {
+		return pruned, err
+	}
 	return pruned, nil
 }
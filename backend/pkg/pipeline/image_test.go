@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestPruneImages_KeepsHookOnlyImages(t *testing.T) {
+	arRoot := t.TempDir()
+	storeDir := t.TempDir()
+	pipelinesDir := t.TempDir()
+	for _, ref := range []string{"installer:v1", "cleanup:v1", "unused:v1"} {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writeImageToStore(img, ref, storeDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tpl := `{"steps": [{"name": "install", "image": "installer:v1"}], "always": [{"name": "cleanup", "image": "cleanup:v1"}]}`
+	if err := os.WriteFile(filepath.Join(pipelinesDir, "k8s.template.json"), []byte(tpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := PruneImages(storeDir, pipelinesDir, arRoot)
+	if err != nil {
+		t.Fatalf("PruneImages returned error: %v", err)
+	}
+	if len(pruned) != 1 {
+		t.Fatalf("expected only the unused image to be pruned, got %v", pruned)
+	}
+	list, err := ListImages(storeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := map[string]bool{}
+	for _, entry := range list {
+		kept[entry.Ref] = true
+	}
+	if !kept["installer:v1"] || !kept["cleanup:v1"] || kept["unused:v1"] {
+		t.Fatalf("unexpected images after prune: %v", kept)
+	}
+}
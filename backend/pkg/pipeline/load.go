@@ -21,6 +21,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/layout"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/opencontainers/cgroups/fscommon"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/specconv"
@@ -97,7 +98,7 @@ func (l *Loader) Load(ctx context.Context, archivePath string, cleanTmp bool) er
 	logrus.Infof("开始运行一次性流水线容器: %s", containerID)
 	// Loader 的一次性容器仍然将 stdout/stderr 聚合到内存，用于错误信息。
 	var out bytes.Buffer
-	if err := runOneShotContainer(ctx, l.runtimeRoot, bundleDir, containerID, &out, &out); err != nil {
+	if err := requireZeroExit(runOneShotContainer(ctx, l.runtimeRoot, bundleDir, containerID, nil, &out, &out)); err != nil {
 		// 将容器输出附加到错误日志，便于排查加载失败原因。
 		logrus.Errorf("Loader.Load: 一次性容器执行失败 containerID=%s: %v, output: %s", containerID, err, strings.TrimSpace(out.String()))
 		return fmt.Errorf("%w, output: %s", err, strings.TrimSpace(out.String()))
@@ -164,7 +165,7 @@ func (l *Loader) LoadFromStore(ctx context.Context, imageNameOrRef string, clean
 	containerID := fmt.Sprintf("ar_load_%d", time.Now().UnixNano())
 	logrus.Infof("开始运行一次性流水线容器: %s", containerID)
 	var out bytes.Buffer
-	if err := runOneShotContainer(ctx, l.runtimeRoot, bundleDir, containerID, &out, &out); err != nil {
+	if err := requireZeroExit(runOneShotContainer(ctx, l.runtimeRoot, bundleDir, containerID, nil, &out, &out)); err != nil {
 		return fmt.Errorf("%w, output: %s", err, strings.TrimSpace(out.String()))
 	}
 
@@ -219,24 +220,33 @@ func (l *Loader) loadAllImagesFromDir(imagesDir string) (int, error) {
 	return loaded, nil
 }
 
-func runOneShotContainer(ctx context.Context, runtimeRoot, bundleDir, containerID string, stdout, stderr io.Writer) error {
+// containerStopGracePeriod 取消或超时时，从发送 SIGTERM 到强制 SIGKILL 的等待时间。
+const containerStopGracePeriod = 10 * time.Second
+
+// runOneShotContainer 运行一次性容器并等待其退出，返回进程的真实退出码，以及非 0 退出是否因超出内存限制被 OOM 终止。
+// 容器正常退出时即使退出码非 0 也不返回错误；仅在创建/启动容器失败（退出码 -1）或 ctx 取消时返回错误。
+// specProcess 非 nil 时代替 config.json 中的进程配置（含敏感值的真实参数只在内存中传递）。
+func runOneShotContainer(ctx context.Context, runtimeRoot, bundleDir, containerID string, specProcess *specs.Process, stdout, stderr io.Writer) (exitCode int, oomKilled bool, err error) {
 	if strings.TrimSpace(runtimeRoot) == "" {
-		return fmt.Errorf("OCI runtime state root 不能为空")
+		return -1, false, fmt.Errorf("OCI runtime state root 不能为空")
 	}
 
 	spec, err := readRuntimeSpec(bundleDir)
 	if err != nil {
-		return err
+		return -1, false, err
+	}
+	if specProcess != nil {
+		spec.Process = specProcess
 	}
 	// 仅在非 root 时启用 rootless（UserNamespace）
 	if os.Geteuid() != 0 {
 		if err := ensureRootlessRuntimeSpec(spec); err != nil {
-			return err
+			return -1, false, err
 		}
 	}
 
 	if err := os.MkdirAll(runtimeRoot, 0700); err != nil {
-		return fmt.Errorf("创建 OCI runtime state root 失败 %s: %w", runtimeRoot, err)
+		return -1, false, fmt.Errorf("创建 OCI runtime state root 失败 %s: %w", runtimeRoot, err)
 	}
 
 	rootless := os.Geteuid() != 0
@@ -247,12 +257,12 @@ func runOneShotContainer(ctx context.Context, runtimeRoot, bundleDir, containerI
 		RootlessCgroups: rootless,
 	})
 	if err != nil {
-		return fmt.Errorf("根据 OCI spec 构建容器配置失败: %w", err)
+		return -1, false, fmt.Errorf("根据 OCI spec 构建容器配置失败: %w", err)
 	}
 
 	container, err := libcontainer.Create(runtimeRoot, containerID, containerCfg)
 	if err != nil {
-		return fmt.Errorf("创建 OCI 容器失败: %w", err)
+		return -1, false, fmt.Errorf("创建 OCI 容器失败: %w", err)
 	}
 	defer func() {
 		_ = container.Destroy()
@@ -270,12 +280,12 @@ func runOneShotContainer(ctx context.Context, runtimeRoot, bundleDir, containerI
 
 	process, err := toLibcontainerProcess(spec.Process, stdoutWriter, stderrWriter)
 	if err != nil {
-		return err
+		return -1, false, err
 	}
 	process.Init = true
 
 	if err := container.Run(process); err != nil {
-		return fmt.Errorf("一次性容器运行失败: %w, 输出: %s", err, strings.TrimSpace(out.String()))
+		return -1, false, fmt.Errorf("一次性容器运行失败: %w, 输出: %s", err, strings.TrimSpace(out.String()))
 	}
 
 	type waitResult struct {
@@ -290,18 +300,70 @@ func runOneShotContainer(ctx context.Context, runtimeRoot, bundleDir, containerI
 
 	select {
 	case r := <-waitCh:
-		if r.err != nil {
-			return fmt.Errorf("等待一次性容器退出失败: %w, 输出: %s", r.err, strings.TrimSpace(out.String()))
-		}
-		if code := r.state.ExitCode(); code != 0 {
-			return fmt.Errorf("一次性容器退出码非 0: %d, 输出: %s", code, strings.TrimSpace(out.String()))
+		if r.err != nil && r.state == nil {
+			return -1, false, fmt.Errorf("等待一次性容器退出失败: %w, 输出: %s", r.err, strings.TrimSpace(out.String()))
 		}
-		return nil
+		// 非 0 退出码不视为错误，由调用方按需判断（流水线步骤可配置 successExitCodes）
+		exitCode = exitCodeOf(r.state)
+		return exitCode, exitCode != 0 && cgroupOOMKilled(container), nil
 	case <-ctx.Done():
-		_ = container.Signal(syscall.SIGKILL)
-		<-waitCh
-		return fmt.Errorf("一次性容器运行被取消: %w, 输出: %s", ctx.Err(), strings.TrimSpace(out.String()))
+		// 先发送 SIGTERM 让进程有机会优雅退出，宽限期内未退出再 SIGKILL
+		if err := container.Signal(syscall.SIGTERM); err != nil {
+			logrus.Debugf("向容器 %s 发送 SIGTERM 失败: %v", containerID, err)
+		}
+		var r waitResult
+		select {
+		case r = <-waitCh:
+		case <-time.After(containerStopGracePeriod):
+			logrus.Warnf("容器 %s 在 %s 内未退出，发送 SIGKILL", containerID, containerStopGracePeriod)
+			_ = container.Signal(syscall.SIGKILL)
+			r = <-waitCh
+		}
+		return exitCodeOf(r.state), false, fmt.Errorf("一次性容器运行被取消: %w, 输出: %s", ctx.Err(), strings.TrimSpace(out.String()))
+	}
+}
+
+// cgroupOOMKilled 判断容器 cgroup 内是否有进程被 OOM killer 终止（cgroup v2 读取 memory.events，v1 读取 memory.oom_control）。
+// 需在 Destroy 删除 cgroup 之前调用；无法读取（如 rootless 未委派 cgroup）时返回 false。
+func cgroupOOMKilled(container *libcontainer.Container) bool {
+	state, err := container.State()
+	if err != nil {
+		return false
+	}
+	if dir, ok := state.CgroupPaths[""]; ok {
+		n, err := fscommon.GetValueByKey(dir, "memory.events", "oom_kill")
+		return err == nil && n > 0
 	}
+	if dir, ok := state.CgroupPaths["memory"]; ok {
+		n, err := fscommon.GetValueByKey(dir, "memory.oom_control", "oom_kill")
+		return err == nil && n > 0
+	}
+	return false
+}
+
+// exitCodeOf 返回容器进程的退出码；被信号终止时按 shell 约定返回 128+信号值，state 为 nil 时返回 -1。
+func exitCodeOf(state *os.ProcessState) int {
+	if state == nil {
+		return -1
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return state.ExitCode()
+}
+
+// requireZeroExit 将 runOneShotContainer 的非 0 退出码转为错误，供只关心成功与否的调用方（如加载流水线）使用。
+func requireZeroExit(exitCode int, oomKilled bool, err error) error {
+	if err != nil {
+		return err
+	}
+	if oomKilled {
+		return fmt.Errorf("一次性容器被 OOM 终止，退出码: %d", exitCode)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("一次性容器退出码非 0: %d", exitCode)
+	}
+	return nil
 }
 
 func readRuntimeSpec(bundleDir string) (*specs.Spec, error) {
@@ -0,0 +1,304 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// leasePollInterval 等待节点租约时的轮询间隔。
+var leasePollInterval = 2 * time.Second
+
+// NodeLease 节点租约：任务执行期间独占其目标节点，防止多个任务同时操作同一批主机。
+// 持久化为 arRoot/leases/<ip>.json，任务结束、停止或启动对账时释放。
+type NodeLease struct {
+	IP           string    `json:"ip"`
+	TaskID       string    `json:"taskId"`
+	PipelineName string    `json:"pipelineName"`
+	PID          int       `json:"pid"`                // 持有租约的执行进程
+	PIDStart     string    `json:"pidStart,omitempty"` // 执行进程的启动标识，见 processStart
+	AcquiredAt   time.Time `json:"acquiredAt"`
+}
+
+// nodeLeaseWaitKey context 中标记“节点被占用时排队等待”的键。
+type nodeLeaseWaitKey struct{}
+
+// WithNodeLeaseWait 返回在目标节点被其他任务占用时排队等待（而不是直接拒绝执行）的 ctx。
+func WithNodeLeaseWait(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nodeLeaseWaitKey{}, true)
+}
+
+func nodeLeaseWaitFrom(ctx context.Context) bool {
+	wait, _ := ctx.Value(nodeLeaseWaitKey{}).(bool)
+	return wait
+}
+
+func leasesDir(arRoot string) string {
+	return filepath.Join(arRoot, "leases")
+}
+
+func leasePath(arRoot, ip string) string {
+	return filepath.Join(leasesDir(arRoot), strings.ReplaceAll(ip, "/", "_")+".json")
+}
+
+// ReadNodeLease 返回节点当前有效的租约；无租约或租约已失效（持有任务已结束或执行进程已退出）时返回 nil。
+func ReadNodeLease(arRoot, ip string) (*NodeLease, error) {
+	lease, err := readLeaseFile(leasePath(arRoot, ip))
+	if err != nil || lease == nil {
+		return nil, err
+	}
+	if leaseStale(arRoot, lease) {
+		return nil, nil
+	}
+	return lease, nil
+}
+
+func readLeaseFile(path string) (*NodeLease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取节点租约失败 %s: %w", path, err)
+	}
+	var lease NodeLease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return nil, fmt.Errorf("解析节点租约失败 %s: %w", path, err)
+	}
+	return &lease, nil
+}
+
+// leaseStale 判断租约是否已失效：执行进程已退出，或持有租约的任务已结束 / 不存在。
+func leaseStale(arRoot string, lease *NodeLease) bool {
+	if !isCurrentProcess(lease.PID, lease.PIDStart) && !processAlive(lease.PID, lease.PIDStart) {
+		return true
+	}
+	runDir, err := FindRunDirByTaskID(arRoot, lease.TaskID)
+	if err != nil {
+		return true
+	}
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		return true
+	}
+	switch runData.Status {
+	case StatusPending, StatusRunning, StatusWaiting, StatusPaused:
+		return false
+	}
+	return true
+}
+
+// runNodeIPs 返回任务目标节点的 IP（去重、排序）。
+func runNodeIPs(nodes []RunNode) []string {
+	seen := make(map[string]bool, len(nodes))
+	ips := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if n.IP == "" || seen[n.IP] {
+			continue
+		}
+		seen[n.IP] = true
+		ips = append(ips, n.IP)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// leaseOwners 返回可共用租约的任务：任务自身及其祖先任务（子流水线在父任务持有的租约下执行）。
+func leaseOwners(arRoot string, runData *PipelineRunData) map[string]bool {
+	owners := map[string]bool{runData.TaskID: true}
+	parent := runData.ParentTaskID
+	for parent != "" && !owners[parent] {
+		owners[parent] = true
+		runDir, err := FindRunDirByTaskID(arRoot, parent)
+		if err != nil {
+			break
+		}
+		parentData, err := ReadPipelineJSON(runDir)
+		if err != nil {
+			break
+		}
+		parent = parentData.ParentTaskID
+	}
+	return owners
+}
+
+// nodeLeaseConflict 返回 ips 中被 owners 以外的活动任务占用的第一个租约，全部空闲时返回 nil。
+func nodeLeaseConflict(arRoot string, ips []string, owners map[string]bool) (*NodeLease, error) {
+	for _, ip := range ips {
+		lease, err := ReadNodeLease(arRoot, ip)
+		if err != nil {
+			return nil, err
+		}
+		if lease != nil && !owners[lease.TaskID] {
+			return lease, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkNodeLeases 在提交任务前检查目标节点是否被 owners 以外的活动任务占用，占用时返回错误。
+// 仅用于尽早拒绝冲突的任务，真正的独占由执行时获取租约保证。
+func checkNodeLeases(arRoot string, ips []string, owners map[string]bool) error {
+	conflict, err := nodeLeaseConflict(arRoot, ips, owners)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return nodeLeaseConflictError(conflict)
+	}
+	return nil
+}
+
+// errTaskRunningElsewhere 任务仍由另一个存活的进程执行，不能再次执行（如对执行中的任务 resume）。
+var errTaskRunningElsewhere = errors.New("任务仍在执行中")
+
+// taskRunningElsewhere 判断任务是否正由当前进程以外的存活进程执行。执行进程结束任务时清除 RunnerPID，
+// 因此只看记录的执行进程是否存活，不看任务状态：task stop 将任务记为 cancelled 后，原执行进程可能尚未退出。
+func taskRunningElsewhere(runData *PipelineRunData) bool {
+	return !isCurrentProcess(runData.RunnerPID, runData.RunnerStart) && processAlive(runData.RunnerPID, runData.RunnerStart)
+}
+
+func nodeLeaseConflictError(lease *NodeLease) error {
+	return fmt.Errorf("节点 %s 正被任务 %s（流水线 %s）占用，请等待其结束或使用 --wait-nodes 排队", lease.IP, lease.TaskID, lease.PipelineName)
+}
+
+// tryAcquireNodeLeases 以排他创建文件的方式为任务获取全部目标节点的租约（全部成功或全部不取）。
+// 失效的租约会被接管；已由 owners 持有的租约直接复用；本任务的租约仅在持有进程已退出（崩溃后恢复）时接管，
+// 仍由其他存活进程持有时视为冲突。返回冲突的租约，成功时为 nil。
+func tryAcquireNodeLeases(arRoot string, runData *PipelineRunData, ips []string, owners map[string]bool) (*NodeLease, error) {
+	if err := os.MkdirAll(leasesDir(arRoot), 0755); err != nil {
+		return nil, fmt.Errorf("创建节点租约目录失败: %w", err)
+	}
+	var acquired []string
+	rollback := func() {
+		for _, path := range acquired {
+			_ = os.Remove(path)
+		}
+	}
+	for _, ip := range ips {
+		lease := NodeLease{IP: ip, TaskID: runData.TaskID, PipelineName: runData.PipelineName, PID: os.Getpid(), PIDStart: currentProcessStart(), AcquiredAt: time.Now()}
+		data, err := json.MarshalIndent(lease, "", "  ")
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("序列化节点租约失败: %w", err)
+		}
+		path := leasePath(arRoot, ip)
+		for {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err == nil {
+				_, err = f.Write(data)
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					_ = os.Remove(path)
+					rollback()
+					return nil, fmt.Errorf("写入节点租约失败 %s: %w", path, err)
+				}
+				acquired = append(acquired, path)
+				break
+			}
+			if !errors.Is(err, os.ErrExist) {
+				rollback()
+				return nil, fmt.Errorf("创建节点租约失败 %s: %w", path, err)
+			}
+			holder, err := readLeaseFile(path)
+			if err != nil {
+				rollback()
+				return nil, err
+			}
+			if holder == nil {
+				continue // 持有者恰好释放，重试创建
+			}
+			if owners[holder.TaskID] && holder.TaskID != runData.TaskID {
+				break // 祖先任务持有，子流水线直接使用
+			}
+			if holder.TaskID == runData.TaskID {
+				if !isCurrentProcess(holder.PID, holder.PIDStart) && processAlive(holder.PID, holder.PIDStart) {
+					rollback()
+					return holder, nil
+				}
+			} else if !leaseStale(arRoot, holder) {
+				rollback()
+				return holder, nil
+			}
+			// 失效的租约或本任务上次执行（进程已退出）遗留的租约：删除后重新创建
+			logrus.Infof("接管节点 %s 的失效租约: taskId=%s", ip, holder.TaskID)
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				rollback()
+				return nil, fmt.Errorf("删除失效的节点租约失败 %s: %w", path, err)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// acquireNodeLeases 为任务获取目标节点的租约。节点被其他任务占用时，ctx 带有 WithNodeLeaseWait 标记则排队等待，
+// 否则返回错误。
+func (r *Runner) acquireNodeLeases(ctx context.Context, runData *PipelineRunData) error {
+	ips := runNodeIPs(runData.RunNodes)
+	if len(ips) == 0 {
+		return nil
+	}
+	owners := leaseOwners(r.arRoot, runData)
+	waiting := false
+	for {
+		conflict, err := tryAcquireNodeLeases(r.arRoot, runData, ips, owners)
+		if err != nil {
+			return err
+		}
+		if conflict == nil {
+			if waiting {
+				logrus.Infof("已获取节点租约，开始执行: taskId=%s", runData.TaskID)
+			}
+			return nil
+		}
+		if conflict.TaskID == runData.TaskID {
+			// 同一任务不排队：等待的结果只会是重复执行
+			return fmt.Errorf("%w: 任务 %s 正由进程 %d 执行，不能重复执行", errTaskRunningElsewhere, runData.TaskID, conflict.PID)
+		}
+		if !nodeLeaseWaitFrom(ctx) {
+			return nodeLeaseConflictError(conflict)
+		}
+		if !waiting {
+			logrus.Infof("节点 %s 正被任务 %s（流水线 %s）占用，排队等待: taskId=%s", conflict.IP, conflict.TaskID, conflict.PipelineName, runData.TaskID)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待节点租约时被取消: %w", ctx.Err())
+		case <-time.After(leasePollInterval):
+		}
+	}
+}
+
+// ReleaseNodeLeases 释放任务持有的全部节点租约，不存在时忽略。
+func ReleaseNodeLeases(arRoot, taskID string) {
+	entries, err := os.ReadDir(leasesDir(arRoot))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(leasesDir(arRoot), e.Name())
+		lease, err := readLeaseFile(path)
+		if err != nil || lease == nil || lease.TaskID != taskID {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("释放节点租约失败 %s: %v", path, err)
+			continue
+		}
+		logrus.Debugf("已释放节点 %s 的租约: taskId=%s", lease.IP, taskID)
+	}
+}
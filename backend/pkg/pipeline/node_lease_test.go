@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func writeLeaseTestTask(t *testing.T, arRoot string, runData *PipelineRunData) {
+	t.Helper()
+	runDir := RunDir(arRoot, runData.PipelineName, runData.TaskID)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNodeLeases_ConflictReuseAndRelease(t *testing.T) {
+	arRoot := t.TempDir()
+	a := &PipelineRunData{TaskID: "a", PipelineName: "k8s", Status: StatusRunning, RunNodes: []RunNode{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}}
+	b := &PipelineRunData{TaskID: "b", PipelineName: "uninstall", Status: StatusPending, RunNodes: []RunNode{{IP: "10.0.0.3"}, {IP: "10.0.0.2"}}}
+	child := &PipelineRunData{TaskID: "c", PipelineName: "cni", Status: StatusPending, ParentTaskID: "a", RunNodes: []RunNode{{IP: "10.0.0.1"}}}
+	for _, d := range []*PipelineRunData{a, b, child} {
+		writeLeaseTestTask(t, arRoot, d)
+	}
+	runner := NewRunner(arRoot, "", "", "", 0, StepContainerConfig{})
+
+	if err := runner.acquireNodeLeases(context.Background(), a); err != nil {
+		t.Fatalf("acquire for a returned error: %v", err)
+	}
+	if lease, _ := ReadNodeLease(arRoot, "10.0.0.2"); lease == nil || lease.TaskID != "a" {
+		t.Fatalf("expected lease held by a, got %+v", lease)
+	}
+
+	if err := runner.acquireNodeLeases(context.Background(), b); err == nil {
+		t.Fatalf("conflicting task should be refused")
+	}
+	if lease, _ := ReadNodeLease(arRoot, "10.0.0.3"); lease != nil {
+		t.Fatalf("refused task should not keep partial leases, got %+v", lease)
+	}
+	ctx, cancel := context.WithCancel(WithNodeLeaseWait(context.Background()))
+	cancel()
+	if err := runner.acquireNodeLeases(ctx, b); err == nil {
+		t.Fatalf("waiting for leases should stop when ctx is cancelled")
+	}
+
+	// 子流水线在父任务持有的租约下执行，释放时不影响父任务的租约
+	if err := runner.acquireNodeLeases(context.Background(), child); err != nil {
+		t.Fatalf("child task should reuse parent's lease: %v", err)
+	}
+	ReleaseNodeLeases(arRoot, "c")
+	if lease, _ := ReadNodeLease(arRoot, "10.0.0.1"); lease == nil || lease.TaskID != "a" {
+		t.Fatalf("parent lease should be kept, got %+v", lease)
+	}
+
+	// 持有任务结束后租约失效，可被接管
+	a.Status = StatusSuccess
+	writeLeaseTestTask(t, arRoot, a)
+	if lease, _ := ReadNodeLease(arRoot, "10.0.0.2"); lease != nil {
+		t.Fatalf("lease of a finished task should be stale, got %+v", lease)
+	}
+	if err := runner.acquireNodeLeases(context.Background(), b); err != nil {
+		t.Fatalf("acquire after stale lease returned error: %v", err)
+	}
+	ReleaseNodeLeases(arRoot, "b")
+	for _, ip := range []string{"10.0.0.2", "10.0.0.3"} {
+		if _, err := os.Stat(leasePath(arRoot, ip)); !os.IsNotExist(err) {
+			t.Fatalf("lease of %s should be released", ip)
+		}
+	}
+}
+
+func TestNodeLeases_SameTaskTakeoverRequiresDeadHolder(t *testing.T) {
+	arRoot := t.TempDir()
+	// 任务仍由另一个存活进程（此处借用父进程）执行
+	a := &PipelineRunData{TaskID: "a", PipelineName: "k8s", Status: StatusRunning, RunnerPID: os.Getppid(), RunNodes: []RunNode{{IP: "10.0.0.1"}}}
+	writeLeaseTestTask(t, arRoot, a)
+	writeHolder := func(pid int) {
+		data, _ := json.Marshal(NodeLease{IP: "10.0.0.1", TaskID: "a", PipelineName: "k8s", PID: pid})
+		if err := os.MkdirAll(leasesDir(arRoot), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(leasePath(arRoot, "10.0.0.1"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeHolder(os.Getppid())
+	runner := NewRunner(arRoot, "", "", "", 0, StepContainerConfig{})
+
+	if _, err := runner.prepareResumeRun("a", ResumeOptions{}); !errors.Is(err, errTaskRunningElsewhere) {
+		t.Fatalf("resuming a task executed by a live process should be refused, got %v", err)
+	}
+	if err := runner.acquireNodeLeases(WithNodeLeaseWait(context.Background()), a); !errors.Is(err, errTaskRunningElsewhere) {
+		t.Fatalf("lease of the same task held by a live process should conflict, got %v", err)
+	}
+	if lease, _ := readLeaseFile(leasePath(arRoot, "10.0.0.1")); lease == nil || lease.PID != os.Getppid() {
+		t.Fatalf("live holder's lease should be kept, got %+v", lease)
+	}
+
+	// 持有进程已退出（崩溃后恢复）时接管
+	writeHolder(1 << 30)
+	if err := runner.acquireNodeLeases(context.Background(), a); err != nil {
+		t.Fatalf("lease of a dead holder should be taken over: %v", err)
+	}
+	if lease, _ := readLeaseFile(leasePath(arRoot, "10.0.0.1")); lease == nil || lease.PID != os.Getpid() {
+		t.Fatalf("expected lease held by this process, got %+v", lease)
+	}
+}
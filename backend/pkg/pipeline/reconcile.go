@@ -0,0 +1,235 @@
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tangxusc/ar/backend/pkg/container"
+)
+
+// ReconciledTask 启动对账时被标记为 interrupted 的任务。
+type ReconciledTask struct {
+	TaskID       string
+	PipelineName string
+	ParentTaskID string
+	// Steps 被标记为 interrupted 的步骤名
+	Steps []string
+	// Resumable 为 false 表示仍有孤儿容器在运行（未清理），此时不宜自动恢复，需人工确认后处理
+	Resumable bool
+}
+
+// Reconcile 在 server 启动时扫描 arRoot/tasks 下处于 running / waiting 的任务，对其执行进程已不存在的任务进行对账：
+// 运行中或等待审批的步骤标记为 interrupted，queued 步骤退回 pending，任务状态标记为 interrupted 并写回 pipeline.json，同时释放其节点租约。
+// 对于容器步骤，按 runtimeRoot 下的 libcontainer 状态处理：容器不存在或已停止时清理容器与 bundle 目录；
+// 容器仍在运行（孤儿容器）时仅在 killOrphans 为 true 时强制停止并清理，否则保留并将任务标记为不可自动恢复。
+// 仍由存活进程托管的任务（如另一个终端中的 pipeline run）不做处理。
+func (r *Runner) Reconcile(killOrphans bool) ([]ReconciledTask, error) {
+	root := filepath.Join(r.arRoot, "tasks")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取任务根目录失败 %s: %w", root, err)
+	}
+	var reconciled []ReconciledTask
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		pipelineTasksDir := filepath.Join(root, e.Name())
+		taskEntries, err := os.ReadDir(pipelineTasksDir)
+		if err != nil {
+			logrus.WithError(err).Warnf("读取流水线任务目录失败: %s", pipelineTasksDir)
+			continue
+		}
+		for _, te := range taskEntries {
+			if !te.IsDir() {
+				continue
+			}
+			runDir := filepath.Join(pipelineTasksDir, te.Name())
+			runData, err := ReadPipelineJSON(runDir)
+			if err != nil {
+				logrus.WithError(err).Warnf("读取任务状态失败: %s", filepath.Join(runDir, "pipeline.json"))
+				continue
+			}
+			if !needsReconcile(runData) {
+				continue
+			}
+			if !isCurrentProcess(runData.RunnerPID, runData.RunnerStart) && processAlive(runData.RunnerPID, runData.RunnerStart) {
+				logrus.Infof("任务仍由进程 %d 执行，跳过对账: pipeline=%s taskId=%s", runData.RunnerPID, runData.PipelineName, runData.TaskID)
+				continue
+			}
+			task, err := r.reconcileTask(runDir, runData, killOrphans)
+			if err != nil {
+				logrus.WithError(err).Warnf("任务对账失败: taskId=%s", runData.TaskID)
+				continue
+			}
+			reconciled = append(reconciled, task)
+		}
+	}
+	return reconciled, nil
+}
+
+// needsReconcile 判断任务是否停留在执行中的状态：任务为 running / waiting / paused，或存在 running / waiting / queued 的步骤。
+func needsReconcile(runData *PipelineRunData) bool {
+	if runData.Status == StatusRunning || runData.Status == StatusWaiting || runData.Status == StatusPaused {
+		return true
+	}
+	for _, group := range runData.stepGroups() {
+		for _, s := range group.steps {
+			switch s.Status {
+			case StatusRunning, StatusWaiting, StatusQueued:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// processAlive 判断 pid 对应的进程是否仍是记录时的那个进程；pid 为 0（旧版本写入的任务）视为不存在。
+// start 为记录时的启动标识（见 processStart），非空且与进程当前的启动标识不同时视为已退出：
+// 主机重启后 PID 可能被无关进程复用，仅凭 kill(pid, 0) 会把崩溃的任务误判为仍在执行。
+func processAlive(pid int, start string) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = proc.Signal(syscall.Signal(0))
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	if start == "" {
+		return true
+	}
+	// 读取失败（如进程恰好退出）时以启动标识不一致处理
+	return processStart(pid) == start
+}
+
+// processStart 返回进程的启动标识：本次开机的 boot_id 与 /proc/<pid>/stat 中的进程启动时间（开机后的时钟节拍数）。
+// 二者共同确定一个进程，不受 PID 复用影响；无法读取（如非 Linux）时返回空字符串，此时仅按 PID 判断。
+func processStart(pid int) string {
+	bootID, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// 进程名（第 2 个字段）可能包含空格与括号，从最后一个 ')' 之后开始按空格切分，starttime 为第 22 个字段
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return ""
+	}
+	return strings.TrimSpace(string(bootID)) + "/" + fields[19]
+}
+
+// currentProcessStart 当前进程的启动标识，写入 pipeline.json、节点租约等记录。
+var currentProcessStart = sync.OnceValue(func() string { return processStart(os.Getpid()) })
+
+// isCurrentProcess 判断记录的 pid / start 是否为当前进程。
+func isCurrentProcess(pid int, start string) bool {
+	return pid == os.Getpid() && (start == "" || start == currentProcessStart())
+}
+
+// reconcileTask 对单个任务执行对账并写回 pipeline.json。
+func (r *Runner) reconcileTask(runDir string, runData *PipelineRunData, killOrphans bool) (ReconciledTask, error) {
+	task := ReconciledTask{
+		TaskID:       runData.TaskID,
+		PipelineName: runData.PipelineName,
+		ParentTaskID: runData.ParentTaskID,
+		Resumable:    true,
+	}
+	now := time.Now()
+	for _, group := range runData.stepGroups() {
+		for i := range group.steps {
+			step := &group.steps[i]
+			switch step.Status {
+			case StatusRunning, StatusWaiting:
+				if !r.reconcileStep(runDir, step, killOrphans) {
+					task.Resumable = false
+				}
+				finishStep(step, StatusInterrupted, now)
+				task.Steps = append(task.Steps, step.Name)
+			case StatusQueued:
+				step.Status = StatusPending
+			}
+		}
+	}
+	runData.Status = StatusInterrupted
+	runData.FinishedAt = &now
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		return task, fmt.Errorf("写入 pipeline.json 失败: %w", err)
+	}
+	ReleaseNodeLeases(r.arRoot, runData.TaskID)
+	logrus.Warnf("任务执行进程已退出，已标记为 interrupted: pipeline=%s taskId=%s steps=%v", task.PipelineName, task.TaskID, task.Steps)
+	return task, nil
+}
+
+// reconcileStep 记录步骤被中断的原因，并在安全时清理其容器与 bundle 目录。返回 false 表示保留了仍在运行的孤儿容器。
+func (r *Runner) reconcileStep(runDir string, step *PipelineStepState, killOrphans bool) bool {
+	switch {
+	case step.Pipeline != "":
+		// 子流水线任务有独立的 pipeline.json，由对账单独处理
+		step.Error = fmt.Sprintf("执行进程异常退出，子流水线任务 %s 被中断", step.ChildTaskID)
+		return true
+	case step.Approval != nil:
+		step.Error = "执行进程异常退出，审批等待被中断"
+		return true
+	}
+	cid := step.ContainerID
+	var status string
+	var err error
+	if cid != "" {
+		status, err = container.OCIContainerStatus(r.runtimeRoot, cid)
+	}
+	if err != nil {
+		// 无法确认容器状态时不做清理，避免误删
+		logrus.WithError(err).Warnf("查询容器状态失败，保留容器与 bundle: %s", cid)
+		step.Error = fmt.Sprintf("执行进程异常退出，容器 %s 状态未知", cid)
+		return false
+	}
+	if status != "" && status != "stopped" && !killOrphans {
+		logrus.Warnf("步骤 %s 的容器 %s 仍在运行（%s），执行进程已退出，未自动清理", step.Name, cid, status)
+		step.Error = fmt.Sprintf("执行进程异常退出，容器 %s 仍在运行，需人工确认后清理", cid)
+		return false
+	}
+	if status != "" {
+		if err := container.DestroyOCIContainer(r.runtimeRoot, cid); err != nil {
+			logrus.WithError(err).Warnf("清理残留容器失败: %s", cid)
+			step.Error = fmt.Sprintf("执行进程异常退出，残留容器 %s 清理失败", cid)
+			return false
+		}
+		logrus.Infof("已清理残留容器: %s（%s）", cid, status)
+	}
+	removeStepSecretFiles(cid)
+	bundleDir := filepath.Join(runDir, "bundles", step.Name)
+	// 先卸载步骤的 overlayfs 并释放 rootfs 缓存引用，再删除 bundle
+	if err := unmountRootfs(filepath.Join(bundleDir, "rootfs")); err != nil {
+		logrus.WithError(err).Warnf("卸载步骤 rootfs 失败，保留 bundle 目录: %s", bundleDir)
+		step.Error = "执行进程异常退出，步骤被中断，退出码未记录"
+		return true
+	}
+	releaseRootfsCacheRefs(RootfsCacheDir(r.arRoot), cid)
+	if err := os.RemoveAll(bundleDir); err != nil {
+		logrus.WithError(err).Warnf("清理 bundle 目录失败: %s", bundleDir)
+	}
+	step.Error = "执行进程异常退出，步骤被中断，退出码未记录"
+	return true
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReconcile_MarksOrphanedTaskInterrupted(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "k8s", "task")
+	bundleDir := filepath.Join(runDir, "bundles", "install")
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePipelineJSON(runDir, &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "k8s",
+		Status:       StatusRunning,
+		Steps: []PipelineStepState{
+			{Name: "start", Status: StatusSuccess},
+			{Name: "install", Status: StatusRunning, ContainerID: "ar_k8s_install_2"},
+			{Name: "confirm", Status: StatusWaiting, Approval: &ApprovalSpec{Message: "ok?"}},
+			{Name: "verify", Status: StatusQueued},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// 仍由存活进程执行的任务不应被对账
+	aliveDir := RunDir(arRoot, "k8s", "alive")
+	if err := os.MkdirAll(aliveDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePipelineJSON(aliveDir, &PipelineRunData{
+		TaskID:       "alive",
+		PipelineName: "k8s",
+		Status:       StatusRunning,
+		RunnerPID:    os.Getppid(),
+		Steps:        []PipelineStepState{{Name: "a", Status: StatusRunning}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(arRoot, "", "", t.TempDir(), 0, StepContainerConfig{})
+	reconciled, err := runner.Reconcile(false)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if len(reconciled) != 1 || reconciled[0].TaskID != "task" || !reconciled[0].Resumable {
+		t.Fatalf("unexpected reconciled tasks %+v", reconciled)
+	}
+	if len(reconciled[0].Steps) != 2 {
+		t.Fatalf("expected install and confirm interrupted, got %v", reconciled[0].Steps)
+	}
+
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runData.Status != StatusInterrupted || runData.FinishedAt == nil {
+		t.Fatalf("task should be interrupted, got %s", runData.Status)
+	}
+	want := map[string]string{"start": StatusSuccess, "install": StatusInterrupted, "confirm": StatusInterrupted, "verify": StatusPending}
+	for _, s := range runData.Steps {
+		if s.Status != want[s.Name] {
+			t.Errorf("step %s: status %s, want %s", s.Name, s.Status, want[s.Name])
+		}
+	}
+	if _, err := os.Stat(bundleDir); !os.IsNotExist(err) {
+		t.Fatalf("bundle of interrupted step should be removed")
+	}
+	if alive, _ := ReadPipelineJSON(aliveDir); alive.Status != StatusRunning {
+		t.Fatalf("task owned by a live process should be left alone, got %s", alive.Status)
+	}
+
+	completed, err := prepareResume(runDir, runData, ResumeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(completed) != 1 || !completed["start"] {
+		t.Fatalf("interrupted steps should be rerun on resume, completed=%v", completed)
+	}
+}
+
+func TestProcessAlive_ComparesStartIdentity(t *testing.T) {
+	start := processStart(os.Getppid())
+	if start == "" {
+		t.Skip("无法读取 /proc 中的进程启动标识")
+	}
+	if !processAlive(os.Getppid(), start) || !processAlive(os.Getppid(), "") {
+		t.Fatalf("parent process should be alive")
+	}
+	// 主机重启后 PID 被无关进程复用：PID 存在但启动标识不同
+	if processAlive(os.Getppid(), "other-boot/1") {
+		t.Fatalf("a reused PID should not be treated as the recorded process")
+	}
+	if !isCurrentProcess(os.Getpid(), currentProcessStart()) || isCurrentProcess(os.Getpid(), "other-boot/1") {
+		t.Fatalf("unexpected current process check")
+	}
+
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "k8s", "reused")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePipelineJSON(runDir, &PipelineRunData{
+		TaskID:       "reused",
+		PipelineName: "k8s",
+		Status:       StatusRunning,
+		RunnerPID:    os.Getppid(),
+		RunnerStart:  "other-boot/1",
+		RunNodes:     []RunNode{{IP: "10.0.0.1"}},
+		Steps:        []PipelineStepState{{Name: "a", Status: StatusRunning}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	lease := NodeLease{IP: "10.0.0.1", TaskID: "reused", PipelineName: "k8s", PID: os.Getppid(), PIDStart: "other-boot/1"}
+	if !leaseStale(arRoot, &lease) {
+		t.Fatalf("lease held by a reused PID should be stale")
+	}
+	reconciled, err := NewRunner(arRoot, "", "", t.TempDir(), 0, StepContainerConfig{}).Reconcile(false)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if len(reconciled) != 1 || reconciled[0].TaskID != "reused" {
+		t.Fatalf("task whose runner PID was reused should be reconciled, got %+v", reconciled)
+	}
+}
@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rootfs 缓存：按镜像 digest 解包一次，保存在 arRoot/rootfs-cache/<digest>/rootfs，作为各步骤 overlayfs 的只读 lower 层。
+// 每个使用缓存的步骤在 <digest>/refs/<containerId>.json 登记引用，步骤结束后删除；image rm / prune 在缓存被引用时拒绝删除镜像。
+// 同一缓存条目的解包、引用登记与删除通过 <digest>.lock 文件锁互斥。
+
+// RootfsCacheDir 返回 rootfs 缓存根目录。
+func RootfsCacheDir(arRoot string) string {
+	return filepath.Join(arRoot, "rootfs-cache")
+}
+
+// rootfsCacheRef 缓存条目的一个引用（使用该缓存作为 lower 层的步骤容器）。
+type rootfsCacheRef struct {
+	ContainerID string `json:"containerId"`
+	PID         int    `json:"pid"`                // 执行步骤的进程，进程退出后引用视为失效
+	PIDStart    string `json:"pidStart,omitempty"` // 执行进程的启动标识，见 processStart
+	BundleDir   string `json:"bundleDir"`
+}
+
+// rootfsCacheKey 将镜像 digest（sha256:xxx）转换为缓存目录名。
+func rootfsCacheKey(digest string) string {
+	return strings.ReplaceAll(digest, ":", "-")
+}
+
+func rootfsCacheEntryDir(cacheDir, digest string) string {
+	return filepath.Join(cacheDir, rootfsCacheKey(digest))
+}
+
+func rootfsCacheRefPath(cacheDir, digest, containerID string) string {
+	return filepath.Join(rootfsCacheEntryDir(cacheDir, digest), "refs", containerID+".json")
+}
+
+// lockRootfsCache 获取缓存条目的排他文件锁，返回解锁函数。
+func lockRootfsCache(cacheDir, digest string) (func(), error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建 rootfs 缓存目录失败: %w", err)
+	}
+	path := filepath.Join(cacheDir, rootfsCacheKey(digest)+".lock")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开 rootfs 缓存锁失败 %s: %w", path, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("获取 rootfs 缓存锁失败 %s: %w", path, err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// rootfsCacheUsers 返回缓存条目当前有效的引用（容器 ID，已排序），并删除执行进程已退出的失效引用。调用方需持有条目锁。
+func rootfsCacheUsers(cacheDir, digest string) []string {
+	refsDir := filepath.Join(rootfsCacheEntryDir(cacheDir, digest), "refs")
+	entries, err := os.ReadDir(refsDir)
+	if err != nil {
+		return nil
+	}
+	var users []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(refsDir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var ref rootfsCacheRef
+		if err := json.Unmarshal(data, &ref); err != nil || (!isCurrentProcess(ref.PID, ref.PIDStart) && !processAlive(ref.PID, ref.PIDStart)) {
+			logrus.Debugf("删除失效的 rootfs 缓存引用: %s", path)
+			_ = os.Remove(path)
+			continue
+		}
+		users = append(users, ref.ContainerID)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// addRootfsCacheRef 登记容器对缓存条目的引用。调用方需持有条目锁。
+func addRootfsCacheRef(cacheDir, digest, containerID, bundleDir string) error {
+	path := rootfsCacheRefPath(cacheDir, digest, containerID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建 rootfs 缓存引用目录失败: %w", err)
+	}
+	data, err := json.Marshal(rootfsCacheRef{ContainerID: containerID, PID: os.Getpid(), PIDStart: currentProcessStart(), BundleDir: bundleDir})
+	if err != nil {
+		return fmt.Errorf("序列化 rootfs 缓存引用失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入 rootfs 缓存引用失败 %s: %w", path, err)
+	}
+	return nil
+}
+
+// releaseRootfsCacheRefs 删除容器在全部缓存条目中的引用（步骤结束或对账清理时调用），不存在时忽略。
+func releaseRootfsCacheRefs(cacheDir, containerID string) {
+	if containerID == "" {
+		return
+	}
+	matches, _ := filepath.Glob(filepath.Join(cacheDir, "*", "refs", containerID+".json"))
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("删除 rootfs 缓存引用失败 %s: %v", path, err)
+		}
+	}
+}
+
+// removeRootfsCache 删除镜像 digest 对应的缓存条目；条目仍被步骤容器引用时返回错误且不删除。
+func removeRootfsCache(cacheDir, digest string) error {
+	if digest == "" {
+		return nil
+	}
+	entryDir := rootfsCacheEntryDir(cacheDir, digest)
+	if _, err := os.Stat(entryDir); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := lockRootfsCache(cacheDir, digest)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if users := rootfsCacheUsers(cacheDir, digest); len(users) > 0 {
+		return fmt.Errorf("rootfs 缓存正被步骤容器使用: %s", strings.Join(users, ", "))
+	}
+	if err := os.RemoveAll(entryDir); err != nil {
+		return fmt.Errorf("删除 rootfs 缓存失败 %s: %w", entryDir, err)
+	}
+	logrus.Infof("已删除 rootfs 缓存: %s", entryDir)
+	return nil
+}
+
+// pruneRootfsCache 删除不属于 keep 中任一镜像 digest 且未被引用的缓存条目（镜像已删除或被同名镜像覆盖后遗留的缓存）。
+func pruneRootfsCache(cacheDir string, keep map[string]bool) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		digest := strings.Replace(e.Name(), "-", ":", 1)
+		if keep[digest] {
+			continue
+		}
+		if err := removeRootfsCache(cacheDir, digest); err != nil {
+			logrus.Warnf("清理 rootfs 缓存 %s 跳过: %v", e.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestDeleteImage_RefusesWhileRootfsCacheInUse(t *testing.T) {
+	arRoot := t.TempDir()
+	storeDir := t.TempDir()
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeImageToStore(img, "installer:v1", storeDir); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := hash.String()
+	cacheDir := RootfsCacheDir(arRoot)
+	if err := os.MkdirAll(filepath.Join(rootfsCacheEntryDir(cacheDir, digest), "rootfs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := addRootfsCacheRef(cacheDir, digest, "ar_k8s_install_2", "/bundle"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = DeleteImage(storeDir, arRoot, "installer:v1")
+	if err == nil || !strings.Contains(err.Error(), "ar_k8s_install_2") {
+		t.Fatalf("delete should be refused while the cache is referenced, got %v", err)
+	}
+	if pruned, err := PruneAllImages(storeDir, arRoot); err != nil || len(pruned) != 0 {
+		t.Fatalf("prune should skip images in use, pruned=%v err=%v", pruned, err)
+	}
+
+	releaseRootfsCacheRefs(cacheDir, "ar_k8s_install_2")
+	if err := DeleteImage(storeDir, arRoot, "installer:v1"); err != nil {
+		t.Fatalf("delete after release returned error: %v", err)
+	}
+	if _, err := os.Stat(rootfsCacheEntryDir(cacheDir, digest)); !os.IsNotExist(err) {
+		t.Fatalf("rootfs cache of the deleted image should be removed")
+	}
+}
+
+func TestRootfsCacheUsers_DropsStaleRefs(t *testing.T) {
+	cacheDir := t.TempDir()
+	digest := "sha256:abc"
+	if err := addRootfsCacheRef(cacheDir, digest, "live", "/bundle"); err != nil {
+		t.Fatal(err)
+	}
+	// 执行进程已退出的引用视为失效
+	stale := rootfsCacheRefPath(cacheDir, digest, "stale")
+	if err := os.WriteFile(stale, []byte(`{"containerId":"stale","pid":999999999}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if users := rootfsCacheUsers(cacheDir, digest); len(users) != 1 || users[0] != "live" {
+		t.Fatalf("unexpected users %v", users)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale reference should be removed")
+	}
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// stepRootfs 步骤容器的 rootfs：缓存可用时为 overlayfs（lower 为只读缓存，upper/work 位于 bundle 内，随 bundle 丢弃），
+// 否则为直接解包到 bundle 内的完整 rootfs。
+type stepRootfs struct {
+	path        string
+	overlay     bool
+	cacheDir    string
+	containerID string
+}
+
+// prepareStepRootfs 为步骤准备 bundleDir/rootfs：优先使用按镜像 digest 缓存的只读 rootfs 作为 overlayfs 的 lower 层，
+// 缓存不存在时先解包到缓存；挂载 overlayfs 失败（如 rootless 或宿主机文件系统不支持）时退回到直接解包。
+func prepareStepRootfs(cacheDir string, img v1.Image, bundleDir, containerID string) (*stepRootfs, error) {
+	rootfsDir := filepath.Join(bundleDir, "rootfs")
+	if err := os.MkdirAll(rootfsDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建 rootfs 目录失败: %w", err)
+	}
+	if cacheDir != "" {
+		rootfs, err := mountCachedRootfs(cacheDir, img, bundleDir, containerID)
+		if err == nil {
+			return rootfs, nil
+		}
+		logrus.Warnf("使用 rootfs 缓存失败，改为直接解包: %v", err)
+	}
+	logrus.Infof("解包步骤镜像: %s", rootfsDir)
+	if err := extractRootfsFromImage(img, rootfsDir); err != nil {
+		return nil, fmt.Errorf("解包步骤镜像失败: %w", err)
+	}
+	return &stepRootfs{path: rootfsDir}, nil
+}
+
+// mountCachedRootfs 确保镜像的缓存 rootfs 已解包并登记引用，然后在 bundleDir/rootfs 挂载 overlayfs。
+func mountCachedRootfs(cacheDir string, img v1.Image, bundleDir, containerID string) (*stepRootfs, error) {
+	hash, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("读取镜像 digest 失败: %w", err)
+	}
+	digest := hash.String()
+	lowerDir, err := ensureRootfsCache(cacheDir, digest, img, containerID, bundleDir)
+	if err != nil {
+		return nil, err
+	}
+	upperDir := filepath.Join(bundleDir, "upper")
+	workDir := filepath.Join(bundleDir, "work")
+	rootfsDir := filepath.Join(bundleDir, "rootfs")
+	for _, dir := range []string{upperDir, workDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			releaseRootfsCacheRefs(cacheDir, containerID)
+			return nil, fmt.Errorf("创建 overlay 目录失败: %w", err)
+		}
+	}
+	options := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", lowerDir, upperDir, workDir)
+	if err := syscall.Mount("overlay", rootfsDir, "overlay", 0, options); err != nil {
+		releaseRootfsCacheRefs(cacheDir, containerID)
+		return nil, fmt.Errorf("挂载 overlayfs 失败 %s: %w", rootfsDir, err)
+	}
+	logrus.Infof("步骤 rootfs 使用缓存: %s -> %s", lowerDir, rootfsDir)
+	return &stepRootfs{path: rootfsDir, overlay: true, cacheDir: cacheDir, containerID: containerID}, nil
+}
+
+// ensureRootfsCache 在条目锁内检查缓存是否已解包（未解包时解包到临时目录后原子重命名），并登记 containerID 的引用。
+// 返回缓存 rootfs 的绝对路径。
+func ensureRootfsCache(cacheDir, digest string, img v1.Image, containerID, bundleDir string) (string, error) {
+	unlock, err := lockRootfsCache(cacheDir, digest)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	entryDir, err := filepath.Abs(rootfsCacheEntryDir(cacheDir, digest))
+	if err != nil {
+		return "", fmt.Errorf("解析 rootfs 缓存目录绝对路径失败: %w", err)
+	}
+	lowerDir := filepath.Join(entryDir, "rootfs")
+	if _, err := os.Stat(lowerDir); os.IsNotExist(err) {
+		tmpDir := filepath.Join(entryDir, fmt.Sprintf("rootfs.tmp-%d", os.Getpid()))
+		_ = os.RemoveAll(tmpDir)
+		logrus.Infof("解包镜像到 rootfs 缓存: %s -> %s", digest, lowerDir)
+		if err := extractRootfsFromImage(img, tmpDir); err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return "", fmt.Errorf("解包镜像到 rootfs 缓存失败: %w", err)
+		}
+		if err := os.Rename(tmpDir, lowerDir); err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return "", fmt.Errorf("写入 rootfs 缓存失败: %w", err)
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("访问 rootfs 缓存失败 %s: %w", lowerDir, err)
+	}
+	if err := addRootfsCacheRef(cacheDir, digest, containerID, bundleDir); err != nil {
+		return "", err
+	}
+	return lowerDir, nil
+}
+
+// release 卸载 overlayfs 并释放缓存引用；直接解包的 rootfs 无需处理（随 bundle 目录删除）。
+func (r *stepRootfs) release() {
+	if r == nil || !r.overlay {
+		return
+	}
+	if err := unmountRootfs(r.path); err != nil {
+		logrus.Warnf("卸载步骤 rootfs 失败 %s: %v", r.path, err)
+	}
+	releaseRootfsCacheRefs(r.cacheDir, r.containerID)
+}
+
+// unmountRootfs 卸载 bundle 中的 overlayfs rootfs，未挂载或不存在时忽略。
+func unmountRootfs(rootfsDir string) error {
+	err := syscall.Unmount(rootfsDir, syscall.MNT_DETACH)
+	if err == nil || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOENT) {
+		return nil
+	}
+	return err
+}
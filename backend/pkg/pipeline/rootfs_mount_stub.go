@@ -0,0 +1,9 @@
+//go:build !linux
+
+package pipeline
+
+// unmountRootfs 在非 Linux 上无 overlayfs 挂载，直接返回。
+func unmountRootfs(rootfsDir string) error {
+	_ = rootfsDir
+	return nil
+}
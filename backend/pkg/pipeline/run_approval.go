@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 审批结果
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+const (
+	// stepApprovalFile 审批决定文件，位于审批步骤的节点目录。approve/reject 只写该文件，由执行任务的进程读取后记录到 pipeline.json，
+	// 避免与执行进程并发改写 pipeline.json
+	stepApprovalFile = "approval.json"
+	// approvalPollInterval 等待审批时检查决定文件的间隔
+	approvalPollInterval = time.Second
+)
+
+// ApprovalSpec 审批步骤配置：设置后步骤不启动容器，进入 waiting 状态直到有人批准或拒绝。
+type ApprovalSpec struct {
+	// Message 提示审批人的说明，如“即将卸载 containerd 与 k8s，确认继续？”
+	Message string `json:"message,omitempty"`
+}
+
+// StepApproval 审批步骤的审批记录。
+type StepApproval struct {
+	Decision string    `json:"decision"` // approved | rejected
+	By       string    `json:"by"`
+	At       time.Time `json:"at"`
+	Comment  string    `json:"comment,omitempty"`
+}
+
+// validateApprovalStep 校验审批步骤：不能同时指定容器相关字段或子流水线。
+func validateApprovalStep(step TemplateStep) error {
+	if step.Approval == nil {
+		return nil
+	}
+	if step.Pipeline != "" || step.Image != "" || step.Entrypoint != "" || len(step.Args) > 0 || len(step.Env) > 0 || hasContainerOptions(step.ContainerOptions) {
+		return fmt.Errorf("步骤 %s 为审批步骤，不能同时设置 image/entrypoint/args/env/pipeline/mounts/workingDir/user/hostname", step.Name)
+	}
+	return nil
+}
+
+// readStepApproval 读取节点目录中的审批决定，文件不存在时返回 nil。
+func readStepApproval(nodeDir string) (*StepApproval, error) {
+	data, err := os.ReadFile(filepath.Join(nodeDir, stepApprovalFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var approval StepApproval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return nil, fmt.Errorf("解析审批文件失败: %w", err)
+	}
+	return &approval, nil
+}
+
+// waitForApproval 执行审批步骤：将步骤与任务置为 waiting，轮询审批决定文件直到批准、拒绝或 ctx 结束。
+// 此前被拒绝的决定在重新等待时清除（恢复任务即重新发起审批），已批准的决定直接生效。调用方不得持有 mu。
+func (r *Runner) waitForApproval(ctx context.Context, runDir, nodeDir string, runData *PipelineRunData, mu *sync.Mutex, state *PipelineStepState, step *PipelineStepState) RunStepResult {
+	if approval, _ := readStepApproval(nodeDir); approval != nil && approval.Decision == ApprovalRejected {
+		if err := os.Remove(filepath.Join(nodeDir, stepApprovalFile)); err != nil && !os.IsNotExist(err) {
+			return RunStepResult{ExitCode: -1, Err: fmt.Errorf("清理审批文件失败: %w", err)}
+		}
+	}
+
+	mu.Lock()
+	state.Status = StatusWaiting
+	state.ApprovalResult = nil
+	runData.Status = activeTaskStatus(runData)
+	writeErr := WritePipelineJSON(runDir, runData)
+	mu.Unlock()
+	if writeErr != nil {
+		return RunStepResult{ExitCode: -1, Err: writeErr}
+	}
+	logrus.Infof("步骤 %s 等待审批: %s（使用 ar pipeline task approve/reject -t %s -s %s 处理）", step.Name, step.Approval.Message, runData.TaskID, step.Name)
+
+	ticker := time.NewTicker(approvalPollInterval)
+	defer ticker.Stop()
+	for {
+		approval, err := readStepApproval(nodeDir)
+		if err != nil {
+			logrus.Warnf("读取步骤 %s 的审批决定失败: %v", step.Name, err)
+		}
+		if approval != nil {
+			mu.Lock()
+			state.ApprovalResult = approval
+			state.Status = StatusRunning
+			runData.Status = activeTaskStatus(runData)
+			_ = WritePipelineJSON(runDir, runData)
+			mu.Unlock()
+			if approval.Decision != ApprovalApproved {
+				return RunStepResult{ExitCode: -1, Err: fmt.Errorf("审批被拒绝（审批人 %s）", approval.By)}
+			}
+			logrus.Infof("步骤 %s 已审批通过（审批人 %s）", step.Name, approval.By)
+			return RunStepResult{ExitCode: 0}
+		}
+		select {
+		case <-ctx.Done():
+			return RunStepResult{ExitCode: -1, Err: ctx.Err()}
+		case <-ticker.C:
+		}
+	}
+}
+
+// hasWaitingSteps 判断任务中是否仍有等待审批的步骤。调用方需持有保护 runData 的锁。
+func hasWaitingSteps(runData *PipelineRunData) bool {
+	for _, group := range runData.stepGroups() {
+		for _, s := range group.steps {
+			if s.Status == StatusWaiting {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DecideApproval 批准或拒绝任务中等待审批的步骤：将决定写入该步骤节点目录的审批文件，由执行任务的进程记录到 pipeline.json 并继续调度。
+// stepName 为空时处理唯一一个 waiting 步骤；approver 为空时使用当前系统用户。
+func DecideApproval(arRoot, taskID, stepName, approver, comment string, approved bool) error {
+	runDir, err := FindRunDirByTaskID(arRoot, taskID)
+	if err != nil {
+		return err
+	}
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		return fmt.Errorf("读取 pipeline.json 失败: %w", err)
+	}
+
+	nodeDir, target := "", ""
+	var waiting []string
+	for _, group := range runData.stepGroups() {
+		for i, s := range group.steps {
+			if s.Status != StatusWaiting {
+				continue
+			}
+			waiting = append(waiting, s.Name)
+			if stepName == "" || s.Name == stepName {
+				nodeDir, target = NodeDir(runDir, group.indexBase+i), s.Name
+			}
+		}
+	}
+	switch {
+	case len(waiting) == 0:
+		return fmt.Errorf("任务 %s 没有等待审批的步骤", taskID)
+	case stepName == "" && len(waiting) > 1:
+		return fmt.Errorf("任务 %s 有多个等待审批的步骤 %v，请通过 -s 指定步骤", taskID, waiting)
+	case nodeDir == "":
+		return fmt.Errorf("步骤 %s 不在等待审批状态（等待审批的步骤: %v）", stepName, waiting)
+	}
+
+	if approver == "" {
+		approver = currentUsername()
+	}
+	decision := StepApproval{Decision: ApprovalRejected, By: approver, At: time.Now(), Comment: comment}
+	if approved {
+		decision.Decision = ApprovalApproved
+	}
+	data, err := json.MarshalIndent(decision, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化审批决定失败: %w", err)
+	}
+	if err := os.MkdirAll(nodeDir, 0755); err != nil {
+		return fmt.Errorf("创建节点目录失败 %s: %w", nodeDir, err)
+	}
+	// 先写临时文件再重命名，避免执行进程读到不完整的内容
+	path := filepath.Join(nodeDir, stepApprovalFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("写入审批文件失败: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("写入审批文件失败: %w", err)
+	}
+	logrus.Infof("任务 %s 的步骤 %s 审批结果已提交: %s（审批人 %s）", taskID, target, decision.Decision, approver)
+	return nil
+}
+
+// currentUsername 返回当前系统用户名，通过 sudo 执行时优先使用 SUDO_USER。
+func currentUsername() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
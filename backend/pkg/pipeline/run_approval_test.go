@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForApproval_RecordsDecision(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "storage-rook", "task")
+	runData := &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "storage-rook",
+		Status:       StatusRunning,
+		Steps:        []PipelineStepState{{Name: "confirm-wipe", Status: StatusRunning, Approval: &ApprovalSpec{Message: "wipe disks?"}}},
+	}
+	nodeDir := NodeDir(runDir, 0)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecideApproval(arRoot, "task", "", "", "", true); err == nil {
+		t.Fatalf("expected error before the step is waiting")
+	}
+
+	var mu sync.Mutex
+	done := make(chan RunStepResult, 1)
+	go func() {
+		r := NewRunner(arRoot, "", "", "", 0, StepContainerConfig{})
+		state := &runData.Steps[0]
+		done <- r.waitForApproval(context.Background(), runDir, nodeDir, runData, &mu, state, state)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := DecideApproval(arRoot, "task", "", "alice", "ok", true)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("DecideApproval never succeeded: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case result := <-done:
+		if result.Err != nil {
+			t.Fatalf("expected approval to succeed, got %v", result.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("waitForApproval did not observe the decision")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	got := runData.Steps[0].ApprovalResult
+	if got == nil || got.Decision != ApprovalApproved || got.By != "alice" || got.At.IsZero() {
+		t.Fatalf("unexpected approval record %+v", got)
+	}
+	if runData.Status != StatusRunning {
+		t.Fatalf("expected task back to running, got %s", runData.Status)
+	}
+}
+
+func TestStopTask_CancelsWaitingApproval(t *testing.T) {
+	arRoot := t.TempDir()
+	pipelinesDir := filepath.Join(arRoot, "pipelines")
+	if err := os.MkdirAll(pipelinesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pipelinesDir, "gate.template.json"), []byte(`[{"name": "gate", "approval": {}}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRunner(arRoot, pipelinesDir, "", "", 0, StepContainerConfig{})
+	type runResult struct {
+		taskID string
+		err    error
+	}
+	done := make(chan runResult, 1)
+	go func() {
+		taskID, err := r.Run(context.Background(), "gate", []RunNode{{IP: "10.0.0.1"}}, nil, "")
+		done <- runResult{taskID, err}
+	}()
+
+	var taskID string
+	deadline := time.Now().Add(5 * time.Second)
+	for taskID == "" {
+		if time.Now().After(deadline) {
+			t.Fatalf("approval step never started waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+		dirs, _ := filepath.Glob(filepath.Join(RunDir(arRoot, "gate", "*")))
+		for _, dir := range dirs {
+			if runData, err := ReadPipelineJSON(dir); err == nil && runData.Status == StatusWaiting {
+				taskID = runData.TaskID
+			}
+		}
+	}
+
+	if err := r.Stop(taskID); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	select {
+	case res := <-done:
+		if res.err == nil {
+			t.Fatalf("expected the stopped run to return an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the running process did not observe the stop marker")
+	}
+	if err := DecideApproval(arRoot, taskID, "", "alice", "", true); err == nil {
+		t.Fatalf("approval of a stopped task should be refused")
+	}
+	runData, err := ReadPipelineJSON(RunDir(arRoot, "gate", taskID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runData.Status != StatusCancelled || runData.Steps[0].Status != StatusCancelled {
+		t.Fatalf("expected the task to stay cancelled, got task %s step %s", runData.Status, runData.Steps[0].Status)
+	}
+}
+
+func TestRunSingleStep_NoRetryAfterStop(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "gate", "task")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runData := &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "gate",
+		Status:       StatusRunning,
+		Steps: []PipelineStepState{{
+			Name:        "gate",
+			Status:      StatusPending,
+			Approval:    &ApprovalSpec{},
+			StepOptions: StepOptions{Retries: 2},
+		}},
+	}
+	// 停止方已结束步骤，执行进程尚未轮询到停止标记（ctx 未取消）
+	runData.stopper = newTaskStopWatcher(runDir, func() {})
+	var mu sync.Mutex
+	r := NewRunner(arRoot, "", "", "", 0, StepContainerConfig{})
+	done := make(chan error, 1)
+	go func() {
+		done <- r.runSingleStep(context.Background(), runDir, "", runData, &mu, runData.stepGroups()[0], runData.Steps[0], nil)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := requestTaskStop(runDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := DecideApproval(arRoot, "task", "gate", "alice", "", false); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("approval step never started waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected the rejected step to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("a stopped task must not retry its steps")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := runData.Steps[0]; got.Status != StatusCancelled || len(got.Attempts) != 1 {
+		t.Fatalf("expected one cancelled attempt, got %s with %d attempts", got.Status, len(got.Attempts))
+	}
+}
@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/tangxusc/ar/backend/pkg/config"
+)
+
+// defaultStepHostname 步骤未设置 hostname 时容器使用的主机名。
+const defaultStepHostname = "ar-run"
+
+// reservedMountTargets 内置挂载点，步骤的 mounts 不能覆盖它们或挂载到其下。
+var reservedMountTargets = []string{"/proc", "/dev", "/sys", "/tasks", "/current-task", "/ar-data", stepSecretsTarget}
+
+var (
+	volumeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	hostnamePattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+)
+
+// StepContainerConfig 步骤容器的全局配置（来自全局参数），由 NewRunner 传入。
+type StepContainerConfig struct {
+	// Resources 默认资源限制，步骤模板中的 resources 未设置的字段使用这些值
+	Resources StepResources
+	// AllowedMountPaths 步骤 mounts 可挂载的宿主机路径白名单，目录包含其下的全部路径；为空时不允许挂载宿主机路径
+	AllowedMountPaths []string
+	// Secrets 解析步骤命令中密钥引用（{{secret "name"}}）的密钥存储，为 nil 时含引用的步骤启动失败
+	Secrets *SecretStore
+}
+
+// ConfiguredStepContainerConfig 返回全局配置（--step-cpu / --step-memory / --step-pids / --allowed-mount-paths / --secret-key-file）中的步骤容器配置。
+func ConfiguredStepContainerConfig() StepContainerConfig {
+	return StepContainerConfig{
+		Resources:         StepResources{CPU: config.StepCPU, Memory: config.StepMemory, Pids: config.StepPids},
+		AllowedMountPaths: config.AllowedMountPaths,
+		Secrets:           ConfiguredSecretStore(),
+	}
+}
+
+// ContainerOptions 步骤容器的运行配置（挂载、工作目录、用户与主机名），模板与 pipeline.json 共用，渲染时原样复制。
+type ContainerOptions struct {
+	// Mounts 额外挂载：宿主机路径（须在 --allowed-mount-paths 白名单内）或命名卷
+	Mounts []StepMount `json:"mounts,omitempty"`
+	// WorkingDir 容器进程的工作目录（绝对路径），为空时使用镜像的 WorkingDir，镜像未设置时为 /
+	WorkingDir string `json:"workingDir,omitempty"`
+	// User 运行进程的用户：uid[:gid] 或镜像 /etc/passwd、/etc/group 中的用户名[:组名]，为空时为 root
+	User string `json:"user,omitempty"`
+	// Hostname 容器主机名，为空时为 ar-run
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// StepMount 步骤的一个额外挂载。Source 与 Volume 二选一：Source 为宿主机绝对路径（文件、目录或 socket），
+// Volume 为命名卷名称，对应 arRoot/volumes/<name> 目录（首次使用时创建），可在步骤与任务之间共享数据。
+type StepMount struct {
+	Source   string `json:"source,omitempty"`
+	Volume   string `json:"volume,omitempty"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+}
+
+// validateContainerOptions 校验步骤容器配置的格式（不访问文件系统），在模板解析后调用。宿主机路径白名单在步骤启动前校验。
+func validateContainerOptions(stepName string, opts ContainerOptions) error {
+	targets := make(map[string]bool, len(opts.Mounts))
+	for _, m := range opts.Mounts {
+		if (m.Source == "") == (m.Volume == "") {
+			return fmt.Errorf("步骤 %s 的挂载 %s 必须且只能指定 source 或 volume 之一", stepName, m.Target)
+		}
+		if m.Source != "" && !filepath.IsAbs(m.Source) {
+			return fmt.Errorf("步骤 %s 的挂载源必须为绝对路径: %s", stepName, m.Source)
+		}
+		if m.Volume != "" && !volumeNamePattern.MatchString(m.Volume) {
+			return fmt.Errorf("步骤 %s 的卷名无效: %s（仅允许字母、数字、_ . -）", stepName, m.Volume)
+		}
+		if !filepath.IsAbs(m.Target) {
+			return fmt.Errorf("步骤 %s 的挂载点必须为绝对路径: %q", stepName, m.Target)
+		}
+		target := filepath.Clean(m.Target)
+		if target == "/" {
+			return fmt.Errorf("步骤 %s 不能挂载到根目录", stepName)
+		}
+		for _, reserved := range reservedMountTargets {
+			if pathWithin(target, reserved) {
+				return fmt.Errorf("步骤 %s 的挂载点 %s 与内置挂载 %s 冲突", stepName, target, reserved)
+			}
+		}
+		if targets[target] {
+			return fmt.Errorf("步骤 %s 的挂载点重复: %s", stepName, target)
+		}
+		targets[target] = true
+	}
+	if opts.WorkingDir != "" && !filepath.IsAbs(opts.WorkingDir) {
+		return fmt.Errorf("步骤 %s 的 workingDir 必须为绝对路径: %s", stepName, opts.WorkingDir)
+	}
+	if opts.User != "" && strings.TrimSpace(opts.User) != opts.User {
+		return fmt.Errorf("步骤 %s 的 user 无效: %q", stepName, opts.User)
+	}
+	if opts.Hostname != "" && (len(opts.Hostname) > 253 || !hostnamePattern.MatchString(opts.Hostname)) {
+		return fmt.Errorf("步骤 %s 的 hostname 无效: %s", stepName, opts.Hostname)
+	}
+	return nil
+}
+
+// hasContainerOptions 判断是否设置了任一容器运行配置，用于拒绝子流水线与审批步骤上的这些字段。
+func hasContainerOptions(opts ContainerOptions) bool {
+	return len(opts.Mounts) > 0 || opts.WorkingDir != "" || opts.User != "" || opts.Hostname != ""
+}
+
+// stepMounts 将步骤的 mounts 转换为 OCI 挂载：宿主机路径须存在且（解析符号链接后）位于白名单内，命名卷目录按需创建。
+func (r *Runner) stepMounts(step *PipelineStepState) ([]specs.Mount, error) {
+	if len(step.Mounts) == 0 {
+		return nil, nil
+	}
+	mounts := make([]specs.Mount, 0, len(step.Mounts))
+	for _, m := range step.Mounts {
+		source := m.Source
+		if m.Volume != "" {
+			source = filepath.Join(r.arRoot, "volumes", m.Volume)
+			if err := os.MkdirAll(source, 0755); err != nil {
+				return nil, fmt.Errorf("创建卷目录失败 %s: %w", source, err)
+			}
+			abs, err := filepath.Abs(source)
+			if err != nil {
+				return nil, fmt.Errorf("解析卷目录绝对路径失败: %w", err)
+			}
+			source = abs
+		} else {
+			resolved, err := filepath.EvalSymlinks(source)
+			if err != nil {
+				return nil, fmt.Errorf("挂载源不可用 %s: %w", source, err)
+			}
+			if !hostPathAllowed(resolved, r.stepConfig.AllowedMountPaths) {
+				return nil, fmt.Errorf("挂载源 %s 不在允许的宿主机路径内（--allowed-mount-paths）", m.Source)
+			}
+			source = resolved
+		}
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		mounts = append(mounts, specs.Mount{
+			Destination: filepath.Clean(m.Target),
+			Type:        "bind",
+			Source:      source,
+			Options:     []string{"rbind", mode},
+		})
+	}
+	return mounts, nil
+}
+
+// hostPathAllowed 判断宿主机路径是否等于白名单中的某一项或位于其下；白名单项同样解析符号链接后比较。
+func hostPathAllowed(path string, allowed []string) bool {
+	for _, a := range allowed {
+		a = strings.TrimSpace(a)
+		if a == "" || !filepath.IsAbs(a) {
+			continue
+		}
+		a = filepath.Clean(a)
+		if resolved, err := filepath.EvalSymlinks(a); err == nil {
+			a = resolved
+		}
+		if pathWithin(path, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathWithin 判断 path 是否等于 dir 或位于 dir 之下（两者均为清理后的绝对路径）。
+func pathWithin(path, dir string) bool {
+	if dir == "/" {
+		return true
+	}
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateContainerOptions(t *testing.T) {
+	valid := ContainerOptions{
+		Mounts: []StepMount{
+			{Source: "/etc/hosts", Target: "/etc/hosts", ReadOnly: true},
+			{Volume: "k8s-cache", Target: "/cache"},
+		},
+		WorkingDir: "/work",
+		User:       "1000:1000",
+		Hostname:   "installer-1",
+	}
+	if err := validateContainerOptions("ok", valid); err != nil {
+		t.Fatalf("expected valid options, got %v", err)
+	}
+	invalid := []ContainerOptions{
+		{Mounts: []StepMount{{Source: "/etc/hosts", Volume: "v", Target: "/x"}}},
+		{Mounts: []StepMount{{Target: "/x"}}},
+		{Mounts: []StepMount{{Source: "etc/hosts", Target: "/x"}}},
+		{Mounts: []StepMount{{Volume: "../escape", Target: "/x"}}},
+		{Mounts: []StepMount{{Volume: "v", Target: "/tasks/sub"}}},
+		{Mounts: []StepMount{{Volume: "v", Target: "/"}}},
+		{Mounts: []StepMount{{Volume: "a", Target: "/x"}, {Volume: "b", Target: "/x/"}}},
+		{WorkingDir: "work"},
+		{Hostname: "bad_host"},
+	}
+	for _, opts := range invalid {
+		if err := validateContainerOptions("bad", opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+}
+
+func TestStepMounts_EnforcesAllowedHostPaths(t *testing.T) {
+	arRoot := t.TempDir()
+	allowedDir := t.TempDir()
+	outside := t.TempDir()
+	sock := filepath.Join(allowedDir, "agent.sock")
+	if err := os.WriteFile(sock, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// 白名单内指向白名单外的符号链接不能绕过校验
+	link := filepath.Join(allowedDir, "escape")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRunner(arRoot, "", "", "", 0, StepContainerConfig{AllowedMountPaths: []string{allowedDir}})
+
+	step := &PipelineStepState{Name: "install", ContainerOptions: ContainerOptions{Mounts: []StepMount{
+		{Source: sock, Target: "/run/ssh-agent.sock", ReadOnly: true},
+		{Volume: "cache", Target: "/cache"},
+	}}}
+	mounts, err := r.stepMounts(step)
+	if err != nil {
+		t.Fatalf("stepMounts returned error: %v", err)
+	}
+	if len(mounts) != 2 || mounts[0].Options[1] != "ro" || mounts[1].Options[1] != "rw" {
+		t.Fatalf("unexpected mounts %+v", mounts)
+	}
+	if info, err := os.Stat(filepath.Join(arRoot, "volumes", "cache")); err != nil || !info.IsDir() {
+		t.Fatalf("named volume directory should be created")
+	}
+
+	for _, source := range []string{outside, link} {
+		step.Mounts = []StepMount{{Source: source, Target: "/data"}}
+		if _, err := r.stepMounts(step); err == nil || !strings.Contains(err.Error(), "不在允许的宿主机路径内") {
+			t.Errorf("mount of %s should be rejected, got %v", source, err)
+		}
+	}
+}
@@ -15,26 +15,46 @@ import (
 
 const (
 	StatusPending   = "pending"
+	StatusQueued    = "queued" // 依赖已满足，等待并发槽位
 	StatusRunning   = "running"
 	StatusSuccess   = "success"
 	StatusFailed    = "failed"
+	StatusTimeout   = "timeout" // 步骤或流水线超时
 	StatusCancelled = "cancelled"
+	StatusSkipped   = "skipped" // when 条件不满足未执行，后继步骤视其为已满足
+	StatusWaiting   = "waiting" // 审批步骤等待人工批准或拒绝
+	StatusPaused    = "paused"  // 任务被暂停：运行中的步骤容器被冻结，不再启动新步骤（仅用于任务状态）
+
+	StatusInterrupted = "interrupted" // 执行进程异常退出（如宿主机或 server 崩溃），由启动时的对账标记，可通过 resume 继续
 )
 
 // LoadTemplate 从 pipelinesDir 读取 pipelineName.template.json（纯 JSON），返回步骤列表。
 // 若模板内含 Go template 语法（如 {{range .nodes}}），请使用 LoadAndRenderTemplate。
 func LoadTemplate(pipelinesDir, pipelineName string) ([]TemplateStep, error) {
-	return loadTemplateWithContext(pipelinesDir, pipelineName, nil, nil)
+	tpl, err := loadTemplateWithContext(pipelinesDir, pipelineName, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return tpl.Steps, nil
 }
 
 // LoadAndRenderTemplate 读取模板文件，用 nodes 作为上下文渲染（支持 {{.nodes}}、{{range}} 等），再解析为步骤列表。
 // args 为可选键值对参数（来自 --args 指定的 JSON 文件），在模板中通过 {{index .args "key"}} 或 {{arg .args "key"}} 读取。
 // 用于模板中含 Go template 语法的 pipeline_name.template.json。
 func LoadAndRenderTemplate(pipelinesDir, pipelineName string, nodes []RunNode, args map[string]interface{}) ([]TemplateStep, error) {
+	tpl, err := loadTemplateWithContext(pipelinesDir, pipelineName, nodes, args)
+	if err != nil {
+		return nil, err
+	}
+	return tpl.Steps, nil
+}
+
+// LoadAndRenderPipelineTemplate 与 LoadAndRenderTemplate 相同，但返回完整的模板（含 maxParallel 等流水线级配置）。
+func LoadAndRenderPipelineTemplate(pipelinesDir, pipelineName string, nodes []RunNode, args map[string]interface{}) (*PipelineTemplate, error) {
 	return loadTemplateWithContext(pipelinesDir, pipelineName, nodes, args)
 }
 
-func loadTemplateWithContext(pipelinesDir, pipelineName string, nodes []RunNode, args map[string]interface{}) ([]TemplateStep, error) {
+func loadTemplateWithContext(pipelinesDir, pipelineName string, nodes []RunNode, args map[string]interface{}) (*PipelineTemplate, error) {
 	name := sanitizePipelineName(pipelineName)
 	if name == "" {
 		return nil, fmt.Errorf("流水线名称无效: %s", pipelineName)
@@ -50,12 +70,17 @@ func loadTemplateWithContext(pipelinesDir, pipelineName string, nodes []RunNode,
 
 	var toParse []byte
 	if nodes != nil {
-		tpl, err := template.New("").Funcs(templateFuncs).Parse(string(data))
+		// 步骤输出此时尚不可用，output 原样保留（JSON 转义）待步骤启动前渲染
+		tpl, err := template.New("").Funcs(templateFuncs).Funcs(template.FuncMap{"output": deferredOutputJSON}).Parse(string(data))
 		if err != nil {
 			return nil, fmt.Errorf("解析流水线模板语法失败 %s: %w", path, err)
 		}
+		// .node / .index 仅在 forEach 步骤中有意义，此处先保留占位，展开为各节点实例时再渲染
+		ctx := buildRenderContext(nodes, args)
+		ctx["node"] = deferredNode
+		ctx["index"] = "{{.index}}"
 		var buf bytes.Buffer
-		if err := tpl.Execute(&buf, buildRenderContext(nodes, args)); err != nil {
+		if err := tpl.Execute(&buf, ctx); err != nil {
 			return nil, fmt.Errorf("渲染流水线模板失败 %s: %w", path, err)
 		}
 		toParse = buf.Bytes()
@@ -63,14 +88,66 @@ func loadTemplateWithContext(pipelinesDir, pipelineName string, nodes []RunNode,
 		toParse = data
 	}
 
-	var steps []TemplateStep
-	if err := json.Unmarshal(toParse, &steps); err != nil {
+	tpl, err := parsePipelineTemplate(toParse)
+	if err != nil {
 		return nil, fmt.Errorf("解析流水线模板失败 %s: %w", path, err)
 	}
-	if len(steps) == 0 {
+	if len(tpl.Steps) == 0 {
 		return nil, fmt.Errorf("流水线模板为空: %s", path)
 	}
-	return steps, nil
+	for _, steps := range [][]TemplateStep{tpl.Steps, tpl.OnSuccess, tpl.OnFailure, tpl.Always} {
+		for _, step := range steps {
+			if err := validateStepOptions(step); err != nil {
+				return nil, fmt.Errorf("流水线模板无效 %s: %w", path, err)
+			}
+			if err := validateContainerOptions(step.Name, step.ContainerOptions); err != nil {
+				return nil, fmt.Errorf("流水线模板无效 %s: %w", path, err)
+			}
+			if err := validateSubPipelineStep(step); err != nil {
+				return nil, fmt.Errorf("流水线模板无效 %s: %w", path, err)
+			}
+			if err := validateApprovalStep(step); err != nil {
+				return nil, fmt.Errorf("流水线模板无效 %s: %w", path, err)
+			}
+		}
+	}
+	if nodes != nil {
+		// 按节点展开 forEach 步骤（主流程与钩子各自展开，边只在同一列表内重写）
+		for _, steps := range []*[]TemplateStep{&tpl.Steps, &tpl.OnSuccess, &tpl.OnFailure, &tpl.Always} {
+			expanded, err := expandForEach(*steps, nodes)
+			if err != nil {
+				return nil, fmt.Errorf("流水线模板无效 %s: %w", path, err)
+			}
+			*steps = expanded
+		}
+	}
+	return tpl, nil
+}
+
+// parsePipelineTemplate 解析渲染后的模板 JSON，支持数组形式 [ ... ] 与对象形式 { "steps": [ ... ] }。
+func parsePipelineTemplate(data []byte) (*PipelineTemplate, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var tpl PipelineTemplate
+		if err := json.Unmarshal(trimmed, &tpl); err != nil {
+			return nil, err
+		}
+		if tpl.MaxParallel < 0 {
+			return nil, fmt.Errorf("maxParallel 不能为负数: %d", tpl.MaxParallel)
+		}
+		if _, err := parseOptionalDuration(tpl.Timeout); err != nil {
+			return nil, fmt.Errorf("timeout 无效: %w", err)
+		}
+		if err := validateFailurePolicy(tpl.FailurePolicy); err != nil {
+			return nil, err
+		}
+		return &tpl, nil
+	}
+	var steps []TemplateStep
+	if err := json.Unmarshal(trimmed, &steps); err != nil {
+		return nil, err
+	}
+	return &PipelineTemplate{Steps: steps}, nil
 }
 
 // TopoOrder 返回 DAG 的拓扑序（无依赖或依赖已列出的先执行）。steps 中 nodes 表示后继，即 name -> nodes 的边。
@@ -227,10 +304,8 @@ func buildRenderContext(nodes []RunNode, args map[string]interface{}) map[string
 			LabelsStr:  labelsString(n.Labels),
 		})
 	}
-	if args == nil {
-		args = map[string]interface{}{}
-	}
-	return map[string]interface{}{"nodes": list, "args": args}
+	// 敏感参数（{"secret": "..."}）在模板中直接使用其值
+	return map[string]interface{}{"nodes": list, "args": unwrapSecretArgs(args)}
 }
 
 // RenderStep 用 nodes 数组渲染 step 的 entrypoint/args/env。模板中使用 .nodes（如 {{(index .nodes 0).IP}}、{{range .nodes}}）。
@@ -245,12 +320,22 @@ func RenderStep(step TemplateStep, nodes []RunNode) TemplateStep {
 		args = append(args, renderString(a, ctx))
 	}
 	return TemplateStep{
-		Name:       step.Name,
-		Image:      step.Image,
-		Entrypoint: renderString(step.Entrypoint, ctx),
-		Args:       args,
-		Env:        env,
-		Nodes:      step.Nodes,
+		Name:             step.Name,
+		Image:            step.Image,
+		Entrypoint:       renderString(step.Entrypoint, ctx),
+		Args:             args,
+		Env:              env,
+		Nodes:            step.Nodes,
+		ForEach:          step.ForEach,
+		StepOptions:      step.StepOptions,
+		ContainerOptions: step.ContainerOptions,
+
+		Pipeline:     step.Pipeline,
+		PipelineArgs: step.PipelineArgs,
+		Approval:     step.Approval,
+
+		forEachParent: step.forEachParent,
+		forEachNode:   step.forEachNode,
 	}
 }
 
@@ -320,6 +405,9 @@ var templateFuncs = template.FuncMap{
 		}
 		return ""
 	},
+	// secret 返回密钥存储（ar secret）中指定密钥的引用 ((secret:<name>))，步骤启动前才解析为真实值，pipeline.json 中只保存引用。
+	// 用法：{{secret "registry-password"}}
+	"secret": SecretRef,
 	"ipsByLabel": func(nodes []NodeTemplateData, key, value string) []string {
 		ips := make([]string, 0, len(nodes))
 		for _, n := range nodes {
@@ -347,6 +435,8 @@ var templateFuncs = template.FuncMap{
 		}
 		return indices
 	},
+	// output 引用其他步骤的输出：{{output "init-master-0" "joinToken"}}。生成 pipeline.json 时原样保留，在步骤启动前用已记录的输出渲染
+	"output": deferredOutput,
 	"indicesByNotLabel": func(nodes []NodeTemplateData, key, value string) []int {
 		indices := make([]int, 0, len(nodes))
 		for i, n := range nodes {
@@ -385,24 +475,65 @@ func renderString(s string, data interface{}) string {
 
 // BuildRunData 根据拓扑序与节点列表生成初始 PipelineRunData（所有步骤 pending）。模板渲染时使用完整 nodes 数组。
 func BuildRunData(taskID, pipelineName string, orderedSteps []TemplateStep, nodes []RunNode) *PipelineRunData {
-	steps := make([]PipelineStepState, 0, len(orderedSteps))
-	for _, s := range orderedSteps {
+	return &PipelineRunData{
+		TaskID:       taskID,
+		PipelineName: pipelineName,
+		Status:       StatusPending,
+		CreatedAt:    time.Now(),
+		RunNodes:     nodes,
+		Steps:        buildStepStates(orderedSteps, nodes),
+	}
+}
+
+// buildStepStates 用 nodes 渲染模板步骤，生成初始状态为 pending 的步骤列表（主流程与钩子共用）。
+func buildStepStates(templateSteps []TemplateStep, nodes []RunNode) []PipelineStepState {
+	if len(templateSteps) == 0 {
+		return nil
+	}
+	steps := make([]PipelineStepState, 0, len(templateSteps))
+	for _, s := range templateSteps {
 		rendered := RenderStep(s, nodes)
 		steps = append(steps, PipelineStepState{
-			Name:       rendered.Name,
-			Image:      rendered.Image,
-			Status:     StatusPending,
-			Entrypoint: rendered.Entrypoint,
-			Args:       rendered.Args,
-			Env:        rendered.Env,
-			Nodes:      rendered.Nodes,
+			Unrendered:       deferredCommandOf(rendered),
+			Name:             rendered.Name,
+			Image:            rendered.Image,
+			Status:           StatusPending,
+			Entrypoint:       rendered.Entrypoint,
+			Args:             rendered.Args,
+			Env:              rendered.Env,
+			Nodes:            rendered.Nodes,
+			StepOptions:      rendered.StepOptions,
+			ContainerOptions: rendered.ContainerOptions,
+
+			ForEach:       rendered.ForEach,
+			ForEachParent: rendered.forEachParent,
+			ForEachNode:   rendered.forEachNode,
+
+			Pipeline:     rendered.Pipeline,
+			PipelineArgs: rendered.PipelineArgs,
+			Approval:     rendered.Approval,
 		})
 	}
-	return &PipelineRunData{
-		TaskID:       taskID,
-		PipelineName: pipelineName,
-		Steps:        steps,
+	return steps
+}
+
+// CancelTask 将任务中尚未结束的步骤（running / waiting / pending / queued）标记为 cancelled，并记录任务的取消状态与结束时间。
+// 仅修改 runData，容器的停止与 pipeline.json 的写回由调用方负责。
+func CancelTask(runData *PipelineRunData) {
+	now := time.Now()
+	for _, group := range runData.stepGroups() {
+		for i := range group.steps {
+			step := &group.steps[i]
+			switch step.Status {
+			case StatusRunning, StatusWaiting:
+				finishStep(step, StatusCancelled, now)
+			case StatusPending, StatusQueued:
+				step.Status = StatusCancelled
+			}
+		}
 	}
+	runData.Status = StatusCancelled
+	runData.FinishedAt = &now
 }
 
 // RunDir 返回流水线运行目录：/var/lib/ar/tasks/pipelineName/taskID/
@@ -440,13 +571,39 @@ func sanitizeStepNameForContainerID(stepName string, stepIndex int) string {
 	return fmt.Sprintf("step%d", stepIndex)
 }
 
-// WritePipelineJSON 将 runData 写入 runDir/pipeline.json，并 Sync 确保容器启动前落盘。
+// stepContainerID 返回步骤某次尝试的容器 ID：ar_<pipeline>_<step>_<index>，重试时追加 _retry<attempt>。
+// 第一次尝试保持原有格式，便于按前缀停止同一步骤的所有尝试。
+func stepContainerID(pipelineName, stepName string, stepIndex, attempt int) string {
+	id := fmt.Sprintf("ar_%s_%s_%d",
+		sanitizePipelineName(pipelineName),
+		sanitizeStepNameForContainerID(stepName, stepIndex+1),
+		stepIndex+1)
+	if attempt > 1 {
+		id = fmt.Sprintf("%s_retry%d", id, attempt)
+	}
+	return id
+}
+
+// latestContainerID 返回步骤最近一次尝试的容器 ID；尚未执行过时返回第一次尝试的容器 ID。
+func latestContainerID(pipelineName string, stepIndex int, step PipelineStepState) string {
+	if n := len(step.Attempts); n > 0 && step.Attempts[n-1].ContainerID != "" {
+		return step.Attempts[n-1].ContainerID
+	}
+	return stepContainerID(pipelineName, step.Name, stepIndex, 1)
+}
+
+// WritePipelineJSON 将 runData 写入 runDir/pipeline.json（敏感值掩码为 ******，见 maskedRunData），并 Sync 确保容器启动前落盘。
 func WritePipelineJSON(runDir string, runData *PipelineRunData) error {
 	if runData == nil {
 		return fmt.Errorf("runData 不能为空")
 	}
 	path := filepath.Join(runDir, "pipeline.json")
-	data, err := json.MarshalIndent(runData, "", "  ")
+	refreshForEachGroups(runData)
+	toWrite := runData
+	if runData.maskOnWrite {
+		toWrite = maskedRunData(runData)
+	}
+	data, err := json.MarshalIndent(toWrite, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化 pipeline.json 失败: %w", err)
 	}
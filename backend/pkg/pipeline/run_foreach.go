@@ -0,0 +1,260 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// forEachAllNodes forEach 取该值时对全部节点展开。
+const forEachAllNodes = "*"
+
+// deferredNode 加载模板（整体渲染模板文件）时 .node 的占位值：各字段原样保留 {{.node.Xxx}}，
+// 在按 forEach 展开为每个节点的实例时再用实际节点渲染。占位值不含引号，可直接出现在 JSON 字符串中。
+var deferredNode = NodeTemplateData{
+	IP:         "{{.node.IP}}",
+	IntranetIP: "{{.node.IntranetIP}}",
+	Port:       "{{.node.Port}}",
+	Username:   "{{.node.Username}}",
+	Password:   "{{.node.Password}}",
+	LabelsStr:  "{{.node.LabelsStr}}",
+}
+
+// ForEachGroup pipeline.json 中按父步骤汇总的 forEach 实例视图，每次写入 pipeline.json 前根据步骤状态刷新。
+type ForEachGroup struct {
+	Step      string            `json:"step"`      // 模板中的父步骤名
+	ForEach   string            `json:"forEach"`   // 节点标签选择器
+	Status    string            `json:"status"`    // 汇总状态，规则见 aggregateStatus
+	Instances []ForEachInstance `json:"instances"` // 各节点实例，无匹配节点时为空
+}
+
+// ForEachInstance forEach 展开后某个节点上的步骤实例。
+type ForEachInstance struct {
+	Name   string `json:"name"` // 实例步骤名：<父步骤名>@<节点 IP>
+	Node   string `json:"node"`
+	Status string `json:"status"`
+}
+
+// parseForEachSelector 解析 forEach 节点标签选择器：* 表示全部节点，否则为逗号分隔的 key=value（需全部满足）。
+func parseForEachSelector(selector string) ([]Label, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == forEachAllNodes {
+		return nil, nil
+	}
+	var labels []Label
+	for _, part := range strings.Split(selector, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("forEach 选择器无效: %s（格式为 * 或 key=value[,key=value]）", selector)
+		}
+		labels = append(labels, Label{Key: strings.TrimSpace(kv[0]), Value: strings.TrimSpace(kv[1])})
+	}
+	return labels, nil
+}
+
+// matchForEach 返回满足选择器的节点下标。
+func matchForEach(nodes []RunNode, selector []Label) []int {
+	var matched []int
+	for i, n := range nodes {
+		labelsStr := labelsString(n.Labels)
+		ok := true
+		for _, l := range selector {
+			if !labelHas(labelsStr, l.Key, l.Value) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+// forEachInstanceName 返回 forEach 步骤在某节点上的实例名。
+func forEachInstanceName(stepName, nodeIP string) string {
+	return stepName + "@" + nodeIP
+}
+
+// expandForEach 将带 forEach 的步骤展开为每个匹配节点一个实例，并重写 DAG 边：
+//   - 普通步骤 -> forEach 步骤：连接到全部实例；forEach 步骤 -> 普通步骤：全部实例都连接到该步骤；
+//   - forEach 步骤 -> forEach 步骤：后继在同一节点上有实例时按节点一一连接，否则连接到后继的全部实例。
+//
+// 实例的 entrypoint/args/env 以 .node（当前节点）与 .index（节点下标）渲染。没有匹配节点的 forEach 步骤保留为
+// 一个 when 为 false 的步骤，执行时标记为 skipped，后继照常调度。
+func expandForEach(steps []TemplateStep, nodes []RunNode) ([]TemplateStep, error) {
+	type instance struct {
+		name   string
+		nodeIP string
+	}
+	expanded := make(map[string][]instance, len(steps))
+	for _, s := range steps {
+		if s.ForEach == "" {
+			continue
+		}
+		selector, err := parseForEachSelector(s.ForEach)
+		if err != nil {
+			return nil, fmt.Errorf("步骤 %s: %w", s.Name, err)
+		}
+		var insts []instance
+		for _, i := range matchForEach(nodes, selector) {
+			insts = append(insts, instance{name: forEachInstanceName(s.Name, nodes[i].IP), nodeIP: nodes[i].IP})
+		}
+		expanded[s.Name] = insts
+	}
+
+	// successorsOf 计算 from（nodeIP 为空表示非 forEach 实例）指向模板步骤 next 时应连接的实际步骤名
+	successorsOf := func(nodeIP, next string) []string {
+		insts, ok := expanded[next]
+		if !ok || len(insts) == 0 {
+			return []string{next}
+		}
+		if nodeIP != "" {
+			for _, inst := range insts {
+				if inst.nodeIP == nodeIP {
+					return []string{inst.name}
+				}
+			}
+		}
+		names := make([]string, 0, len(insts))
+		for _, inst := range insts {
+			names = append(names, inst.name)
+		}
+		return names
+	}
+	rewriteNodes := func(nodeIP string, nexts []string) []string {
+		var out []string
+		for _, next := range nexts {
+			out = append(out, successorsOf(nodeIP, next)...)
+		}
+		return out
+	}
+
+	result := make([]TemplateStep, 0, len(steps))
+	for _, s := range steps {
+		insts, ok := expanded[s.Name]
+		if !ok {
+			if referencesNode(s) {
+				return nil, fmt.Errorf("步骤 %s 未设置 forEach，不能引用 .node / .index", s.Name)
+			}
+			s.Nodes = rewriteNodes("", s.Nodes)
+			result = append(result, s)
+			continue
+		}
+		if len(insts) == 0 {
+			logrus.Warnf("步骤 %s 的 forEach 选择器 %s 没有匹配的节点，将被跳过", s.Name, s.ForEach)
+			s.Nodes = rewriteNodes("", s.Nodes)
+			s.When = "false"
+			s.forEachParent = s.Name
+			result = append(result, s)
+			continue
+		}
+		for _, inst := range insts {
+			nodeIndex := indexOfNode(nodes, inst.nodeIP)
+			ctx := buildRenderContext(nodes, nil)
+			ctx["node"] = ctx["nodes"].([]NodeTemplateData)[nodeIndex]
+			ctx["index"] = nodeIndex
+			inst2 := s
+			inst2.Name = inst.name
+			inst2.Entrypoint = renderString(s.Entrypoint, ctx)
+			inst2.Args = renderStrings(s.Args, ctx)
+			inst2.Env = renderStrings(s.Env, ctx)
+			inst2.Nodes = rewriteNodes(inst.nodeIP, s.Nodes)
+			inst2.forEachParent = s.Name
+			inst2.forEachNode = inst.nodeIP
+			if inst2.ParallelGroup == "" {
+				// 各实例共享父步骤的并发上限，否则 maxParallel 按实例名各自成组而失效
+				inst2.ParallelGroup = s.Name
+			}
+			result = append(result, inst2)
+		}
+	}
+	return result, nil
+}
+
+// referencesNode 判断步骤是否引用了仅 forEach 实例可用的 .node / .index 占位值。
+func referencesNode(s TemplateStep) bool {
+	for _, v := range append(append([]string{s.Entrypoint}, s.Args...), s.Env...) {
+		if strings.Contains(v, "{{.node.") || strings.Contains(v, "{{.index}}") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOfNode 返回 IP 对应的节点下标，不存在时返回 -1。
+func indexOfNode(nodes []RunNode, ip string) int {
+	for i := range nodes {
+		if nodes[i].IP == ip {
+			return i
+		}
+	}
+	return -1
+}
+
+// renderStrings 逐项渲染字符串列表。
+func renderStrings(list []string, ctx map[string]interface{}) []string {
+	if list == nil {
+		return nil
+	}
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		out = append(out, renderString(s, ctx))
+	}
+	return out
+}
+
+// refreshForEachGroups 根据各实例步骤的当前状态重建 runData.ForEach 汇总视图。
+func refreshForEachGroups(runData *PipelineRunData) {
+	var groups []ForEachGroup
+	index := make(map[string]int)
+	for _, s := range runData.Steps {
+		if s.ForEachParent == "" {
+			continue
+		}
+		i, ok := index[s.ForEachParent]
+		if !ok {
+			i = len(groups)
+			index[s.ForEachParent] = i
+			groups = append(groups, ForEachGroup{Step: s.ForEachParent, ForEach: s.ForEach, Instances: []ForEachInstance{}})
+		}
+		if s.ForEachNode == "" {
+			// 无匹配节点的占位步骤
+			groups[i].Status = s.Status
+			continue
+		}
+		groups[i].Instances = append(groups[i].Instances, ForEachInstance{Name: s.Name, Node: s.ForEachNode, Status: s.Status})
+	}
+	for i := range groups {
+		if len(groups[i].Instances) == 0 {
+			continue
+		}
+		statuses := make([]string, 0, len(groups[i].Instances))
+		for _, inst := range groups[i].Instances {
+			statuses = append(statuses, inst.Status)
+		}
+		groups[i].Status = aggregateStatus(statuses)
+	}
+	runData.ForEach = groups
+}
+
+// aggregateStatus 汇总多个实例的状态：有运行中的为 running，其次等待审批/失败/中断/超时/取消（按此优先级），
+// 全部 success 或 skipped 为 success，其余（含部分 queued）为 pending。
+func aggregateStatus(statuses []string) string {
+	has := make(map[string]bool, len(statuses))
+	for _, s := range statuses {
+		has[s] = true
+	}
+	for _, s := range []string{StatusRunning, StatusWaiting, StatusFailed, StatusInterrupted, StatusTimeout, StatusCancelled} {
+		if has[s] {
+			return s
+		}
+	}
+	for _, s := range statuses {
+		if s != StatusSuccess && s != StatusSkipped {
+			return StatusPending
+		}
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadAndRenderTemplate_ExpandsForEachPerNode(t *testing.T) {
+	dir := t.TempDir()
+	tpl := `[
+  {"name": "start", "image": "img", "nodes": ["copy"]},
+  {"name": "copy", "image": "img", "forEach": "role=master", "nodes": ["firewall"], "args": ["scp {{.node.IP}} #{{.index}}"]},
+  {"name": "firewall", "image": "img", "forEach": "*", "nodes": ["done"], "env": ["HOST={{.node.IP}}"]},
+  {"name": "done", "image": "img"}
+]`
+	if err := os.WriteFile(filepath.Join(dir, "k8s.template.json"), []byte(tpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nodes := []RunNode{
+		{IP: "10.0.0.1", Labels: []Label{{Key: "role", Value: "master"}}},
+		{IP: "10.0.0.2", Labels: []Label{{Key: "role", Value: "worker"}}},
+	}
+	steps, err := LoadAndRenderTemplate(dir, "k8s", nodes, nil)
+	if err != nil {
+		t.Fatalf("LoadAndRenderTemplate returned error: %v", err)
+	}
+	edges := map[string][]string{}
+	for _, s := range steps {
+		edges[s.Name] = s.Nodes
+	}
+	want := map[string][]string{
+		"start":             {"copy@10.0.0.1"},
+		"copy@10.0.0.1":     {"firewall@10.0.0.1"},
+		"firewall@10.0.0.1": {"done"},
+		"firewall@10.0.0.2": {"done"},
+		"done":              nil,
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Fatalf("unexpected edges %v", edges)
+	}
+
+	runData := BuildRunData("task", "k8s", steps, nodes)
+	inst := runData.Steps[1]
+	if inst.Args[0] != "scp 10.0.0.1 #0" || inst.Unrendered != nil {
+		t.Fatalf("unexpected rendered instance %+v", inst)
+	}
+	if runData.Steps[3].Env[0] != "HOST=10.0.0.2" || runData.Steps[3].ForEachParent != "firewall" {
+		t.Fatalf("unexpected rendered instance %+v", runData.Steps[3])
+	}
+
+	runData.Steps[2].Status = StatusSuccess
+	runData.Steps[3].Status = StatusFailed
+	refreshForEachGroups(runData)
+	if len(runData.ForEach) != 2 || runData.ForEach[1].Status != StatusFailed || len(runData.ForEach[1].Instances) != 2 {
+		t.Fatalf("unexpected forEach groups %+v", runData.ForEach)
+	}
+}
+
+func TestExpandForEach_NoMatchingNodesSkipsStep(t *testing.T) {
+	steps := []TemplateStep{
+		{Name: "a", Nodes: []string{"b"}},
+		{Name: "b", ForEach: "role=gpu", Nodes: []string{"c"}},
+		{Name: "c"},
+	}
+	expanded, err := expandForEach(steps, []RunNode{{IP: "10.0.0.1"}})
+	if err != nil {
+		t.Fatalf("expandForEach returned error: %v", err)
+	}
+	if len(expanded) != 3 || expanded[1].Name != "b" || expanded[1].When != "false" {
+		t.Fatalf("expected placeholder step skipped by when, got %+v", expanded)
+	}
+
+	if _, err := expandForEach([]TemplateStep{{Name: "x", Args: []string{"{{.node.IP}}"}}}, nil); err == nil {
+		t.Fatalf("expected error for .node outside forEach step")
+	}
+	if _, err := expandForEach([]TemplateStep{{Name: "x", ForEach: "role"}}, nil); err == nil {
+		t.Fatalf("expected error for invalid selector")
+	}
+}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 步骤分组：主流程与三类流水线钩子。钩子在主流程结束（成功、失败、超时或被停止）后执行。
+const (
+	stepGroupMain = "steps"
+	HookOnSuccess = "onSuccess" // 主流程成功后执行
+	HookOnFailure = "onFailure" // 主流程失败、超时或被停止后执行
+	HookAlways    = "always"    // 无论主流程结果如何都执行，在 onSuccess / onFailure 之后
+)
+
+// hookStopGracePeriod 主流程被停止（或 server 退出）后钩子最多还能运行的时间，到期后取消钩子，
+// 避免没有 timeout 的钩子步骤使 task stop 与 server 退出无限等待。
+var hookStopGracePeriod = 5 * time.Minute
+
+// stepGroup 一组按 DAG 独立调度的步骤（主流程或某类钩子）。
+// steps 与 runData 共享底层数组，修改元素即修改 runData；indexBase 为组内首个步骤的全局序号，
+// 用于节点目录与容器 ID，保证不同分组的步骤互不冲突。
+type stepGroup struct {
+	kind      string
+	steps     []PipelineStepState
+	indexBase int
+}
+
+// indexOf 返回步骤在组内的下标，不存在时返回 -1。
+func (g stepGroup) indexOf(name string) int {
+	for i := range g.steps {
+		if g.steps[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// stepGroups 按主流程、onSuccess、onFailure、always 的顺序返回 runData 中的全部步骤分组。
+func (d *PipelineRunData) stepGroups() []stepGroup {
+	groups := []stepGroup{{kind: stepGroupMain, steps: d.Steps}}
+	base := len(d.Steps)
+	for _, h := range []struct {
+		kind  string
+		steps []PipelineStepState
+	}{{HookOnSuccess, d.OnSuccess}, {HookOnFailure, d.OnFailure}, {HookAlways, d.Always}} {
+		groups = append(groups, stepGroup{kind: h.kind, steps: h.steps, indexBase: base})
+		base += len(h.steps)
+	}
+	return groups
+}
+
+// failedStepNames 返回主流程中失败、超时或被中断的步骤名（含 allowFailure 的步骤），供钩子判断需要清理的范围。
+func failedStepNames(runData *PipelineRunData) []string {
+	var names []string
+	for _, s := range runData.Steps {
+		if s.Status == StatusFailed || s.Status == StatusTimeout || s.Status == StatusInterrupted {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// runHooks 在主流程结束后按结果执行钩子：成功执行 onSuccess，否则执行 onFailure，最后执行 always。
+// 钩子不受流水线超时影响，任务被停止后清理步骤仍能运行，但最多运行 hookStopGracePeriod；钩子运行期间停止任务则立即取消，见 hooksContext。
+// 单个钩子步骤仍受其自身 timeout 约束。
+// 钩子容器可通过环境变量 AR_PIPELINE_RESULT（success | failed）与 AR_FAILED_STEPS（逗号分隔的失败步骤名）获取主流程结果。
+func (r *Runner) runHooks(ctx context.Context, runDir, hostDataDir string, runData *PipelineRunData, mu *sync.Mutex, mainErr error) error {
+	kinds := map[string]bool{HookAlways: true}
+	result := StatusSuccess
+	if mainErr != nil {
+		kinds[HookOnFailure] = true
+		result = StatusFailed
+	} else {
+		kinds[HookOnSuccess] = true
+	}
+
+	mu.Lock()
+	failed := failedStepNames(runData)
+	mu.Unlock()
+	env := []string{
+		"AR_PIPELINE_RESULT=" + result,
+		"AR_FAILED_STEPS=" + strings.Join(failed, ","),
+	}
+	hookCtx, cancelHooks := hooksContext(ctx, runData.stopper)
+	defer cancelHooks()
+
+	var errs []error
+	for _, group := range runData.stepGroups() {
+		if !kinds[group.kind] || len(group.steps) == 0 {
+			continue
+		}
+		logrus.Infof("执行流水线钩子 %s: %v（失败步骤: %v）", group.kind, stepNames(group.steps), failed)
+		mu.Lock()
+		// 恢复执行后钩子会重新运行，先重置上一次的状态；尝试记录保留以保证容器 ID 唯一
+		for i := range group.steps {
+			group.steps[i].Status = StatusPending
+		}
+		mu.Unlock()
+
+		scheduler, err := r.newScheduler(runDir, runData, group, mu)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("解析钩子 %s 的 DAG 失败: %w", group.kind, err))
+			continue
+		}
+		g := group
+		if err := scheduler.run(hookCtx, nil, func(ctx context.Context, step PipelineStepState) error {
+			return r.runSingleStep(ctx, runDir, hostDataDir, runData, mu, g, step, env)
+		}); err != nil {
+			logrus.Errorf("流水线钩子 %s 执行失败: %v", group.kind, err)
+			errs = append(errs, fmt.Errorf("流水线钩子 %s 执行失败: %w", group.kind, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hooksContext 返回执行钩子的上下文：不随流水线超时（ctx 的截止时间）取消；ctx 被取消（task stop、server 退出）时，
+// 钩子再运行 hookStopGracePeriod 后取消；钩子运行期间收到停止请求（stopper 检查到停止标记）时立即取消。
+func hooksContext(ctx context.Context, stopper *taskStopWatcher) (context.Context, context.CancelFunc) {
+	hookCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	grace := hookStopGracePeriod
+	var graceTimer *time.Timer
+	var timerMu sync.Mutex
+	stopAfter := context.AfterFunc(ctx, func() {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		timerMu.Lock()
+		defer timerMu.Unlock()
+		logrus.Infof("任务已停止，钩子最多继续运行 %s", grace)
+		graceTimer = time.AfterFunc(grace, cancel)
+	})
+	stopper.setHooksCancel(cancel)
+	return hookCtx, func() {
+		stopper.setHooksCancel(nil)
+		stopAfter()
+		timerMu.Lock()
+		if graceTimer != nil {
+			graceTimer.Stop()
+		}
+		timerMu.Unlock()
+		cancel()
+	}
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStepGroups_AssignsDistinctIndexes(t *testing.T) {
+	runData := &PipelineRunData{
+		Steps:     []PipelineStepState{{Name: "a", Status: StatusFailed}, {Name: "b", Status: StatusSuccess}},
+		OnFailure: []PipelineStepState{{Name: "cleanup"}},
+		Always:    []PipelineStepState{{Name: "report"}},
+	}
+	groups := runData.stepGroups()
+	bases := map[string]int{}
+	for _, g := range groups {
+		bases[g.kind] = g.indexBase
+	}
+	if bases[stepGroupMain] != 0 || bases[HookOnFailure] != 2 || bases[HookAlways] != 3 {
+		t.Fatalf("unexpected index bases %v", bases)
+	}
+
+	// 分组与 runData 共享底层数组，修改分组即修改 runData
+	groups[2].steps[0].Status = StatusRunning
+	if runData.OnFailure[0].Status != StatusRunning {
+		t.Fatalf("expected group steps to alias runData.OnFailure")
+	}
+
+	if failed := failedStepNames(runData); len(failed) != 1 || failed[0] != "a" {
+		t.Fatalf("expected [a], got %v", failed)
+	}
+}
+
+func TestHooksContext_StopCancelsHooksButTimeoutDoesNot(t *testing.T) {
+	old := hookStopGracePeriod
+	hookStopGracePeriod = 50 * time.Millisecond
+	defer func() { hookStopGracePeriod = old }()
+	done := func(ctx context.Context, within time.Duration) bool {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-time.After(within):
+			return false
+		}
+	}
+
+	// 流水线超时后钩子照常运行
+	timedOut, cancelTimedOut := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelTimedOut()
+	<-timedOut.Done()
+	hookCtx, cancel := hooksContext(timedOut, nil)
+	if done(hookCtx, 200*time.Millisecond) {
+		t.Fatalf("hooks must not be cancelled by the pipeline timeout")
+	}
+	cancel()
+
+	// 任务被停止（或 server 退出）后钩子只在宽限期内运行
+	stopped, stop := context.WithCancel(context.Background())
+	stop()
+	hookCtx, cancel = hooksContext(stopped, nil)
+	if !done(hookCtx, time.Second) {
+		t.Fatalf("hooks should be cancelled after the grace period once the task is stopped")
+	}
+	cancel()
+
+	// 钩子运行期间写入停止标记（task stop）时立即取消
+	runDir := t.TempDir()
+	hookStopGracePeriod = time.Hour
+	stopper := newTaskStopWatcher(runDir, func() {})
+	hookCtx, cancel = hooksContext(context.Background(), stopper)
+	defer cancel()
+	if err := requestTaskStop(runDir); err != nil {
+		t.Fatal(err)
+	}
+	if !stopper.check() || !done(hookCtx, 100*time.Millisecond) {
+		t.Fatalf("a stop request while hooks run should cancel them")
+	}
+}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+// stepOutputsFile 步骤在 /current-task/ 下写入的输出文件名，内容为 JSON 对象 { "key": "value" }。
+const stepOutputsFile = "outputs.json"
+
+// StepCommand 步骤的 entrypoint/args/env，用于保存引用了其他步骤输出、需在启动前再渲染的原始内容。
+type StepCommand struct {
+	Entrypoint string   `json:"entrypoint,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	Env        []string `json:"env,omitempty"`
+}
+
+// deferredOutput 在步骤输出尚不可用时（加载模板、生成 pipeline.json）原样保留 {{output "step" "key"}}，留待步骤启动前渲染。
+func deferredOutput(stepName, key string) string {
+	return fmt.Sprintf("{{output %q %q}}", stepName, key)
+}
+
+// deferredOutputJSON 与 deferredOutput 相同，但对引号做 JSON 转义，用于整体渲染模板文件（结果仍需按 JSON 解析）时。
+func deferredOutputJSON(stepName, key string) string {
+	b, _ := json.Marshal(deferredOutput(stepName, key))
+	return string(b[1 : len(b)-1])
+}
+
+// deferredOutputPattern 匹配 deferredOutput 保留的输出引用；其余花括号（转义的 {{"{{"}}、传给工具的 JSON / Go 模板文本）是字面内容，不再渲染。
+var deferredOutputPattern = regexp.MustCompile(`\{\{output ("(?:[^"\\]|\\.)*") ("(?:[^"\\]|\\.)*")\}\}`)
+
+// hasDeferredOutput 判断渲染后的字符串中是否含待解析的步骤输出引用。
+func hasDeferredOutput(s string) bool {
+	return deferredOutputPattern.MatchString(s)
+}
+
+// deferredCommandOf 若步骤的 entrypoint/args/env 引用了其他步骤的输出，返回其原始内容，否则返回 nil。
+func deferredCommandOf(step TemplateStep) *StepCommand {
+	deferred := hasDeferredOutput(step.Entrypoint)
+	for _, s := range append(append([]string{}, step.Args...), step.Env...) {
+		deferred = deferred || hasDeferredOutput(s)
+	}
+	if !deferred {
+		return nil
+	}
+	return &StepCommand{Entrypoint: step.Entrypoint, Args: step.Args, Env: step.Env}
+}
+
+// stepOutputFuncs 返回可读取 runData 中已记录步骤输出的 output 模板函数。调用方需持有保护 runData 的锁。
+func stepOutputFuncs(runData *PipelineRunData) template.FuncMap {
+	return template.FuncMap{
+		"output": func(stepName, key string) (string, error) {
+			return stepOutput(runData, stepName, key)
+		},
+	}
+}
+
+// stepOutput 返回 runData 中已记录的步骤输出。调用方需持有保护 runData 的锁。
+func stepOutput(runData *PipelineRunData, stepName, key string) (string, error) {
+	for _, s := range runData.Steps {
+		if s.Name != stepName {
+			continue
+		}
+		if v, ok := s.Outputs[key]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("步骤 %s 没有输出 %s（状态: %s）", stepName, key, s.Status)
+	}
+	return "", fmt.Errorf("引用的步骤不存在: %s", stepName)
+}
+
+// renderDeferredCommand 用已记录的步骤输出替换 cmd 中的输出引用，任一引用无法解析时返回错误。
+// 只替换 deferredOutput 保留的引用，其余内容（含字面花括号）原样保留。调用方需持有保护 runData 的锁。
+func renderDeferredCommand(cmd *StepCommand, runData *PipelineRunData) (StepCommand, error) {
+	render := func(s string) (string, error) {
+		var renderErr error
+		rendered := deferredOutputPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			m := deferredOutputPattern.FindStringSubmatch(ref)
+			stepName, err1 := strconv.Unquote(m[1])
+			key, err2 := strconv.Unquote(m[2])
+			if err := errors.Join(err1, err2); err != nil {
+				renderErr = errors.Join(renderErr, fmt.Errorf("无效的输出引用 %s: %w", ref, err))
+				return ref
+			}
+			v, err := stepOutput(runData, stepName, key)
+			if err != nil {
+				renderErr = errors.Join(renderErr, err)
+				return ref
+			}
+			return v
+		})
+		return rendered, renderErr
+	}
+	out := StepCommand{Args: make([]string, 0, len(cmd.Args)), Env: make([]string, 0, len(cmd.Env))}
+	var err error
+	if out.Entrypoint, err = render(cmd.Entrypoint); err != nil {
+		return out, err
+	}
+	for _, a := range cmd.Args {
+		rendered, err := render(a)
+		if err != nil {
+			return out, err
+		}
+		out.Args = append(out.Args, rendered)
+	}
+	for _, e := range cmd.Env {
+		rendered, err := render(e)
+		if err != nil {
+			return out, err
+		}
+		out.Env = append(out.Env, rendered)
+	}
+	return out, nil
+}
+
+// readStepOutputs 读取步骤写入 nodeDir/outputs.json 的输出，文件不存在时返回 nil。
+// 非字符串的值按 JSON 编码保存，便于在模板中原样引用。
+func readStepOutputs(nodeDir string) (map[string]string, error) {
+	path := filepath.Join(nodeDir, stepOutputsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取步骤输出失败 %s: %w", path, err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析步骤输出失败 %s（需为 JSON 对象）: %w", path, err)
+	}
+	outputs := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			outputs[k] = s
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("编码步骤输出 %s 失败: %w", k, err)
+		}
+		outputs[k] = string(b)
+	}
+	return outputs, nil
+}
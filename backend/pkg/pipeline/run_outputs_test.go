@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAndRenderTemplate_DefersOutputReferences(t *testing.T) {
+	dir := t.TempDir()
+	tpl := `[
+  {"name": "init-master-0", "image": "img", "nodes": ["join-0"]},
+  {"name": "join-0", "image": "img", "args": ["kubeadm join {{(index .nodes 0).IP}} --token {{output "init-master-0" "joinToken"}}"]}
+]`
+	if err := os.WriteFile(filepath.Join(dir, "k8s.template.json"), []byte(tpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nodes := []RunNode{{IP: "10.0.0.1"}}
+	steps, err := LoadAndRenderTemplate(dir, "k8s", nodes, nil)
+	if err != nil {
+		t.Fatalf("LoadAndRenderTemplate returned error: %v", err)
+	}
+	runData := BuildRunData("task", "k8s", steps, nodes)
+	join := runData.Steps[1]
+	if join.Unrendered == nil {
+		t.Fatalf("expected join-0 to keep its unrendered command")
+	}
+	want := `kubeadm join 10.0.0.1 --token {{output "init-master-0" "joinToken"}}`
+	if join.Args[0] != want {
+		t.Fatalf("expected deferred arg %q, got %q", want, join.Args[0])
+	}
+	if runData.Steps[0].Unrendered != nil {
+		t.Fatalf("step without output references should not be deferred")
+	}
+
+	if _, err := renderDeferredCommand(join.Unrendered, runData); err == nil {
+		t.Fatalf("expected error before init-master-0 has outputs")
+	}
+	runData.Steps[0].Outputs = map[string]string{"joinToken": "abc.123"}
+	cmd, err := renderDeferredCommand(join.Unrendered, runData)
+	if err != nil {
+		t.Fatalf("renderDeferredCommand returned error: %v", err)
+	}
+	if cmd.Args[0] != "kubeadm join 10.0.0.1 --token abc.123" {
+		t.Fatalf("unexpected rendered arg %q", cmd.Args[0])
+	}
+}
+
+func TestReadStepOutputs(t *testing.T) {
+	dir := t.TempDir()
+	if outputs, err := readStepOutputs(dir); err != nil || outputs != nil {
+		t.Fatalf("expected nil outputs for missing file, got %v, %v", outputs, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, stepOutputsFile), []byte(`{"token": "abc", "port": 6443}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := readStepOutputs(dir)
+	if err != nil {
+		t.Fatalf("readStepOutputs returned error: %v", err)
+	}
+	if outputs["token"] != "abc" || outputs["port"] != "6443" {
+		t.Fatalf("unexpected outputs %v", outputs)
+	}
+}
+
+func TestRenderDeferredCommand_KeepsLiteralBraces(t *testing.T) {
+	// 模板中转义的 {{"{{"}} 或传给工具的 Go 模板文本，渲染后以字面花括号保留在参数中
+	literal := TemplateStep{Name: "render", Args: []string{"docker inspect --format '{{json .State}}'"}}
+	if deferredCommandOf(literal) != nil {
+		t.Fatalf("literal braces must not be treated as an output reference")
+	}
+	step := TemplateStep{Name: "join", Args: []string{`join --token {{output "init" "token"}} --format '{{json .}}'`}}
+	deferred := deferredCommandOf(step)
+	if deferred == nil {
+		t.Fatalf("expected join to keep its unrendered command")
+	}
+
+	runData := &PipelineRunData{Steps: []PipelineStepState{{Name: "init", Outputs: map[string]string{"token": "abc.123"}}}}
+	cmd, err := renderDeferredCommand(deferred, runData)
+	if err != nil {
+		t.Fatalf("renderDeferredCommand returned error: %v", err)
+	}
+	if want := "join --token abc.123 --format '{{json .}}'"; cmd.Args[0] != want {
+		t.Fatalf("expected %q, got %q", want, cmd.Args[0])
+	}
+}
@@ -0,0 +1,381 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tangxusc/ar/backend/pkg/container"
+)
+
+const (
+	// taskPauseFile 暂停标记文件，位于任务运行目录。pause 写入、unpause 删除该文件，由执行任务的进程冻结/解冻容器并记录到 pipeline.json，
+	// 与审批文件一样避免与执行进程并发改写 pipeline.json
+	taskPauseFile = "pause.json"
+	// pausePollInterval 执行进程检查暂停标记的间隔；暂停期间每次检查还会冻结新启动的步骤容器
+	pausePollInterval = time.Second
+	// pauseAckTimeout pause/unpause 等待执行进程在 pipeline.json 中确认的时限
+	pauseAckTimeout = 10 * time.Second
+)
+
+// TaskPause 任务的暂停记录。
+type TaskPause struct {
+	By string    `json:"by"`
+	At time.Time `json:"at"`
+}
+
+// taskPauser 执行进程内任务的暂停状态：暂停期间调度器不再启动新步骤（含重试），正在运行的步骤容器通过 cgroup freezer 冻结，
+// 步骤与流水线的 timeout 停止计时（见 withPauseAwareTimeout）。
+// 字段由保护 runData 的 mu 保护；nil 表示不支持暂停（如未经 execute 执行的步骤）。
+type taskPauser struct {
+	runDir      string
+	runtimeRoot string
+	paused      bool
+	pausing     chan struct{}   // 未暂停期间有效，暂停时关闭
+	resumed     chan struct{}   // 暂停期间有效，恢复时关闭
+	frozen      map[string]bool // 由本进程冻结的容器
+}
+
+func newTaskPauser(runDir, runtimeRoot string) *taskPauser {
+	return &taskPauser{runDir: runDir, runtimeRoot: runtimeRoot, pausing: make(chan struct{}), frozen: make(map[string]bool)}
+}
+
+// readTaskPause 读取任务运行目录中的暂停标记，文件不存在时返回 nil。
+func readTaskPause(runDir string) (*TaskPause, error) {
+	data, err := os.ReadFile(filepath.Join(runDir, taskPauseFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pause TaskPause
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return nil, fmt.Errorf("解析暂停标记失败: %w", err)
+	}
+	return &pause, nil
+}
+
+// wait 任务暂停时阻塞直到恢复或 ctx 结束；未暂停时立即返回。调用方不得持有 mu。
+func (p *taskPauser) wait(ctx context.Context, mu *sync.Mutex) error {
+	if p == nil {
+		return nil
+	}
+	mu.Lock()
+	paused, resumed := p.paused, p.resumed
+	mu.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isPaused 判断任务是否处于暂停状态。调用方需持有保护 runData 的锁。
+func (p *taskPauser) isPaused() bool {
+	return p != nil && p.paused
+}
+
+// clearTaskPause 删除任务运行目录中的暂停标记，不存在时忽略。
+func clearTaskPause(runDir string) error {
+	if err := os.Remove(filepath.Join(runDir, taskPauseFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除暂停标记失败: %w", err)
+	}
+	return nil
+}
+
+// watch 轮询暂停标记直到 ctx 结束：出现标记时暂停任务，标记删除后恢复。
+// 结束时解冻本进程冻结的容器并删除标记，由调用方随后记录任务最终状态。
+func (p *taskPauser) watch(ctx context.Context, runData *PipelineRunData, mu *sync.Mutex) {
+	ticker := time.NewTicker(pausePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			if p.paused {
+				p.unpause(runData)
+			}
+			mu.Unlock()
+			if err := clearTaskPause(p.runDir); err != nil {
+				logrus.Warn(err)
+			}
+			return
+		case <-ticker.C:
+		}
+		pause, err := readTaskPause(p.runDir)
+		if err != nil {
+			logrus.Warnf("读取任务 %s 的暂停标记失败: %v", runData.TaskID, err)
+			continue
+		}
+		mu.Lock()
+		switch {
+		case pause != nil && !p.paused:
+			p.paused = true
+			p.resumed = make(chan struct{})
+			close(p.pausing)
+			runData.Pause = pause
+			runData.Status = StatusPaused
+			p.freezeRunning(runData)
+			logrus.Infof("任务已暂停: taskId=%s（操作人 %s，使用 ar pipeline task unpause -t %s 恢复）", runData.TaskID, pause.By, runData.TaskID)
+			if err := WritePipelineJSON(p.runDir, runData); err != nil {
+				logrus.Warnf("写入任务暂停状态失败: %v", err)
+			}
+		case pause != nil:
+			// 暂停前已进入启动流程的步骤可能在暂停后才创建容器，每次检查时补充冻结
+			p.freezeRunning(runData)
+		case p.paused:
+			p.unpause(runData)
+			logrus.Infof("任务已恢复执行: taskId=%s", runData.TaskID)
+			if err := WritePipelineJSON(p.runDir, runData); err != nil {
+				logrus.Warnf("写入任务恢复状态失败: %v", err)
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+// freezeRunning 冻结正在运行且尚未冻结的步骤容器。调用方需持有保护 runData 的锁。
+func (p *taskPauser) freezeRunning(runData *PipelineRunData) {
+	for _, c := range runningStepContainers(filepath.Base(filepath.Dir(p.runDir)), runData) {
+		if p.frozen[c.containerID] {
+			continue
+		}
+		frozen, err := container.PauseOCIContainer(p.runtimeRoot, c.containerID)
+		if err != nil {
+			logrus.Warnf("冻结步骤 %s 的容器 %s 失败: %v", c.stepName, c.containerID, err)
+			continue
+		}
+		if frozen {
+			p.frozen[c.containerID] = true
+			logrus.Infof("已冻结步骤 %s 的容器 %s", c.stepName, c.containerID)
+		}
+	}
+}
+
+// unpause 解冻本进程冻结的容器，恢复任务状态并放行等待中的步骤。调用方需持有保护 runData 的锁。
+func (p *taskPauser) unpause(runData *PipelineRunData) {
+	ids := make([]string, 0, len(p.frozen))
+	for id := range p.frozen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := container.ResumeOCIContainer(p.runtimeRoot, id); err != nil {
+			logrus.Warnf("解冻容器 %s 失败: %v", id, err)
+			continue
+		}
+		logrus.Infof("已解冻容器 %s", id)
+	}
+	p.frozen = make(map[string]bool)
+	p.paused = false
+	p.pausing = make(chan struct{})
+	close(p.resumed)
+	runData.Pause = nil
+	runData.Status = activeTaskStatus(runData)
+}
+
+// pauseAwareTimeoutCtx 暂停期间不计时的超时上下文：到期时 Err 返回 context.DeadlineExceeded，与 context.WithTimeout 一致。
+// 自带 done 通道，子上下文经 Err 获取结束原因而不是直接挂在父上下文上。
+type pauseAwareTimeoutCtx struct {
+	context.Context
+	done chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+	err  error
+}
+
+func (c *pauseAwareTimeoutCtx) Done() <-chan struct{} { return c.done }
+
+func (c *pauseAwareTimeoutCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *pauseAwareTimeoutCtx) finish(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
+
+// withPauseAwareTimeout 返回 timeout 后超时的 ctx，任务暂停期间不计入时间：冻结的容器在暂停期间无法响应 SIGTERM / SIGKILL，
+// 计时不停会让暂停超过 timeout 的步骤在恢复后立即以 timeout 结束。timeout 不大于 0 时不设置时限；pauser 为 nil 时等同 context.WithTimeout。
+func withPauseAwareTimeout(ctx context.Context, timeout time.Duration, pauser *taskPauser, mu *sync.Mutex) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if pauser == nil {
+		return context.WithTimeout(ctx, timeout)
+	}
+	c := &pauseAwareTimeoutCtx{Context: ctx, done: make(chan struct{})}
+	go func() {
+		remaining := timeout
+		for {
+			mu.Lock()
+			paused, pausing, resumed := pauser.paused, pauser.pausing, pauser.resumed
+			mu.Unlock()
+			if paused {
+				select {
+				case <-resumed:
+					continue
+				case <-ctx.Done():
+					c.finish(ctx.Err())
+				case <-c.done:
+				}
+				return
+			}
+			start := time.Now()
+			timer := time.NewTimer(remaining)
+			select {
+			case <-timer.C:
+				c.finish(context.DeadlineExceeded)
+				return
+			case <-pausing:
+				timer.Stop()
+				remaining -= time.Since(start)
+			case <-ctx.Done():
+				timer.Stop()
+				c.finish(ctx.Err())
+				return
+			case <-c.done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return c, func() { c.finish(context.Canceled) }
+}
+
+// activeTaskStatus 返回执行中任务应记录的状态：暂停中为 paused，有等待审批的步骤为 waiting，否则为 running。
+// 调用方需持有保护 runData 的锁。
+func activeTaskStatus(runData *PipelineRunData) string {
+	switch {
+	case runData.pauser.isPaused():
+		return StatusPaused
+	case hasWaitingSteps(runData):
+		return StatusWaiting
+	default:
+		return StatusRunning
+	}
+}
+
+// PauseTask 暂停流水线任务：写入暂停标记，由执行任务的进程冻结正在运行的步骤容器、不再启动新步骤，并将任务状态记为 paused；
+// 正在运行的子流水线步骤级联暂停其子任务。等待执行进程确认后返回任务当前状态。by 为空时使用当前系统用户。
+func PauseTask(arRoot, taskID, by string) (*PipelineRunData, error) {
+	runDir, runData, err := activeTaskRun(arRoot, taskID)
+	if err != nil {
+		return nil, err
+	}
+	switch runData.Status {
+	case StatusRunning, StatusWaiting:
+	case StatusPaused:
+		return nil, fmt.Errorf("任务 %s 已处于暂停状态", taskID)
+	default:
+		return nil, fmt.Errorf("任务 %s 当前状态为 %s，仅 running / waiting 的任务可以暂停", taskID, runData.Status)
+	}
+	if by == "" {
+		by = currentUsername()
+	}
+	data, err := json.MarshalIndent(TaskPause{By: by, At: time.Now()}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化暂停标记失败: %w", err)
+	}
+	// 先写临时文件再重命名，避免执行进程读到不完整的内容
+	path := filepath.Join(runDir, taskPauseFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return nil, fmt.Errorf("写入暂停标记失败: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return nil, fmt.Errorf("写入暂停标记失败: %w", err)
+	}
+	for _, childTaskID := range runningChildTasks(runData) {
+		if _, err := PauseTask(arRoot, childTaskID, by); err != nil {
+			logrus.WithError(err).Warnf("暂停子流水线任务失败: %s", childTaskID)
+		}
+	}
+	logrus.Infof("任务 %s 暂停请求已提交，等待执行进程冻结容器", taskID)
+	return waitTaskStatus(runDir, taskID, func(status string) bool { return status == StatusPaused })
+}
+
+// UnpauseTask 恢复已暂停的流水线任务：删除暂停标记，由执行任务的进程解冻容器并继续调度；暂停中的子流水线任务一并恢复。
+// 等待执行进程确认后返回任务当前状态。
+func UnpauseTask(arRoot, taskID string) (*PipelineRunData, error) {
+	runDir, runData, err := activeTaskRun(arRoot, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if runData.Status != StatusPaused {
+		return nil, fmt.Errorf("任务 %s 当前状态为 %s，不在暂停状态", taskID, runData.Status)
+	}
+	for _, childTaskID := range runningChildTasks(runData) {
+		if _, err := UnpauseTask(arRoot, childTaskID); err != nil {
+			logrus.WithError(err).Warnf("恢复子流水线任务失败: %s", childTaskID)
+		}
+	}
+	if err := clearTaskPause(runDir); err != nil {
+		return nil, err
+	}
+	logrus.Infof("任务 %s 恢复请求已提交，等待执行进程解冻容器", taskID)
+	return waitTaskStatus(runDir, taskID, func(status string) bool { return status != StatusPaused })
+}
+
+// activeTaskRun 读取任务的运行目录与 pipeline.json，并确认执行任务的进程仍然存在（暂停与恢复均由该进程完成）。
+func activeTaskRun(arRoot, taskID string) (string, *PipelineRunData, error) {
+	runDir, err := FindRunDirByTaskID(arRoot, taskID)
+	if err != nil {
+		return "", nil, err
+	}
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("读取 pipeline.json 失败: %w", err)
+	}
+	if !isCurrentProcess(runData.RunnerPID, runData.RunnerStart) && !processAlive(runData.RunnerPID, runData.RunnerStart) {
+		return "", nil, fmt.Errorf("任务 %s 的执行进程已退出（状态 %s），无法暂停或恢复", taskID, runData.Status)
+	}
+	return runDir, runData, nil
+}
+
+// runningChildTasks 返回正在运行的子流水线步骤的子任务 ID。
+func runningChildTasks(runData *PipelineRunData) []string {
+	var ids []string
+	for _, group := range runData.stepGroups() {
+		for _, step := range group.steps {
+			if step.Status == StatusRunning && step.Pipeline != "" && step.ChildTaskID != "" {
+				ids = append(ids, step.ChildTaskID)
+			}
+		}
+	}
+	return ids
+}
+
+// waitTaskStatus 轮询 pipeline.json 直到任务状态满足 done 或超过 pauseAckTimeout。
+func waitTaskStatus(runDir, taskID string, done func(status string) bool) (*PipelineRunData, error) {
+	deadline := time.Now().Add(pauseAckTimeout)
+	for {
+		runData, err := ReadPipelineJSON(runDir)
+		if err == nil && done(runData.Status) {
+			return runData, nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, fmt.Errorf("读取 pipeline.json 失败: %w", err)
+			}
+			return runData, fmt.Errorf("执行进程未在 %s 内确认（任务 %s 当前状态 %s），请求已提交，稍后查看任务状态", pauseAckTimeout, taskID, runData.Status)
+		}
+		time.Sleep(pausePollInterval / 5)
+	}
+}
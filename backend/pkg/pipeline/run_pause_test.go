@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPauseTask_GatesSchedulerUntilUnpause(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "k8s", "task")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runData := &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "k8s",
+		Status:       StatusRunning,
+		RunnerPID:    os.Getpid(),
+		Steps: []PipelineStepState{
+			{Name: "install", Status: StatusRunning, ContainerID: "ar_k8s_install_1"},
+			{Name: "verify", Status: StatusPending},
+		},
+	}
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	pauser := newTaskPauser(runDir, t.TempDir())
+	runData.pauser = pauser
+	ctx, cancel := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		pauser.watch(ctx, runData, &mu)
+	}()
+
+	paused, err := PauseTask(arRoot, "task", "alice")
+	if err != nil {
+		t.Fatalf("PauseTask returned error: %v", err)
+	}
+	if paused.Status != StatusPaused || paused.Pause == nil || paused.Pause.By != "alice" {
+		t.Fatalf("pipeline.json should record the pause: %+v", paused)
+	}
+	if _, err := PauseTask(arRoot, "task", "alice"); err == nil {
+		t.Fatalf("expected error when pausing a paused task")
+	}
+
+	released := make(chan error, 1)
+	go func() { released <- pauser.wait(context.Background(), &mu) }()
+	select {
+	case <-released:
+		t.Fatalf("new steps must not start while the task is paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	resumed, err := UnpauseTask(arRoot, "task")
+	if err != nil {
+		t.Fatalf("UnpauseTask returned error: %v", err)
+	}
+	if resumed.Status != StatusRunning || resumed.Pause != nil {
+		t.Fatalf("unexpected status after unpause: %+v", resumed)
+	}
+	select {
+	case err := <-released:
+		if err != nil {
+			t.Fatalf("wait returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiting steps should start after unpause")
+	}
+
+	cancel()
+	<-watchDone
+	if _, err := os.Stat(filepath.Join(runDir, taskPauseFile)); !os.IsNotExist(err) {
+		t.Fatalf("pause marker should be removed, got %v", err)
+	}
+	if _, err := UnpauseTask(arRoot, "task"); err == nil {
+		t.Fatalf("expected error when unpausing a running task")
+	}
+}
+
+func TestPauseAwareTimeout_StopsCountingWhilePaused(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "k8s", "task")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runData := &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "k8s",
+		Status:       StatusRunning,
+		RunnerPID:    os.Getpid(),
+		Steps:        []PipelineStepState{{Name: "install", Status: StatusRunning}},
+	}
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	pauser := newTaskPauser(runDir, t.TempDir())
+	runData.pauser = pauser
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+	defer cancelWatch()
+	go pauser.watch(watchCtx, runData, &mu)
+
+	if _, err := PauseTask(arRoot, "task", "alice"); err != nil {
+		t.Fatalf("PauseTask returned error: %v", err)
+	}
+	// 暂停确认需要一个轮询周期，步骤时限在暂停后开始计时
+	const stepTimeout = 300 * time.Millisecond
+	stepCtx, cancel := withPauseAwareTimeout(context.Background(), stepTimeout, pauser, &mu)
+	defer cancel()
+	child, cancelChild := context.WithCancel(stepCtx)
+	defer cancelChild()
+	select {
+	case <-stepCtx.Done():
+		t.Fatalf("step timeout must not count while paused: %v", stepCtx.Err())
+	case <-time.After(3 * stepTimeout):
+	}
+
+	if _, err := UnpauseTask(arRoot, "task"); err != nil {
+		t.Fatalf("UnpauseTask returned error: %v", err)
+	}
+	select {
+	case <-child.Done():
+		if stepCtx.Err() != context.DeadlineExceeded || child.Err() != context.DeadlineExceeded {
+			t.Fatalf("expected deadline exceeded after unpause, got %v / %v", stepCtx.Err(), child.Err())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("step timeout should resume counting after unpause")
+	}
+}
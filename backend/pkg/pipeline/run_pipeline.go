@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -17,35 +19,68 @@ type Runner struct {
 	pipelinesDir   string
 	imagesStoreDir string
 	runtimeRoot    string
+	maxParallel    int // 全局并发上限（--max-parallel），0 表示不限制
+	// stepConfig 步骤容器的全局配置（默认资源限制、宿主机挂载白名单）
+	stepConfig StepContainerConfig
 }
 
 // NewRunner 构造 Runner。arRoot 为流水线运行根目录，通常为 filepath.Dir(PipelinesDir)。
-func NewRunner(arRoot, pipelinesDir, imagesStoreDir, runtimeRoot string) *Runner {
+// maxParallel 为同一任务内同时运行的最大步骤数，0 表示不限制；模板中的 maxParallel 可进一步收紧该值。
+// stepConfig 为步骤容器的全局配置：默认资源限制（步骤模板中的 resources 优先）与宿主机挂载白名单。
+func NewRunner(arRoot, pipelinesDir, imagesStoreDir, runtimeRoot string, maxParallel int, stepConfig StepContainerConfig) *Runner {
 	return &Runner{
 		arRoot:         arRoot,
 		pipelinesDir:   pipelinesDir,
 		imagesStoreDir: imagesStoreDir,
 		runtimeRoot:    runtimeRoot,
+		maxParallel:    maxParallel,
+		stepConfig:     stepConfig,
 	}
 }
 
-// Run 执行流水线：加载模板、用节点渲染生成 pipeline.json、解析为 DAG、按拓扑序执行并更新 pipeline.json。
-// 若某步退出码非 0 则停止后续步骤并返回错误。
+// taskRun 已写入 pipeline.json、等待调度执行的任务（新建或恢复）。
+type taskRun struct {
+	runDir    string
+	runData   *PipelineRunData
+	completed map[string]bool // 恢复执行时视为已完成的步骤，新建任务为 nil
+}
+
+// Run 执行流水线：加载模板、用节点渲染生成 pipeline.json、解析为 DAG、按依赖调度执行并更新 pipeline.json。
+// 若某步退出码不在其 successExitCodes（默认仅 0）中且未配置 allowFailure，则按模板的 failurePolicy 停止调度：
+// failFast 不再启动新的步骤，finishIndependentBranches 仅停止其下游；等待已启动的步骤结束后返回错误。
 // args 为可选键值对参数（来自 --args 指定的 JSON 文件），传入模板渲染上下文 .args。
 // taskID 若为空则自动生成；调用方可传入预生成的 taskID 以便与停止/恢复时注册的 cancel 对应。
 // 返回 taskID 与错误。
 func (r *Runner) Run(ctx context.Context, pipelineName string, nodes []RunNode, args map[string]interface{}, taskID string) (string, error) {
+	run, err := r.prepareRun(ctx, pipelineName, nodes, args, taskID)
+	if err != nil {
+		return "", err
+	}
+	taskID = run.runData.TaskID
+
+	logrus.Infof("开始执行流水线: pipeline=%s taskId=%s runDir=%s", pipelineName, taskID, run.runDir)
+	// 3. 按 DAG 依赖调度：某步骤的全部前驱成功后立即启动，并受并发上限约束；结束后执行钩子
+	if err := r.execute(ctx, run.runDir, run.runData, nil); err != nil {
+		return taskID, err
+	}
+
+	logrus.Infof("流水线执行完成: pipeline=%s taskId=%s", pipelineName, taskID)
+	return taskID, nil
+}
+
+// prepareRun 加载并渲染模板、生成任务目录与初始 pipeline.json，不执行任何步骤。模板或节点有误时返回错误且不创建任务。
+func (r *Runner) prepareRun(ctx context.Context, pipelineName string, nodes []RunNode, args map[string]interface{}, taskID string) (*taskRun, error) {
 	logrus.Debugf("Runner.Run: pipelineName=%s nodes=%d", pipelineName, len(nodes))
 	if len(nodes) == 0 {
 		logrus.Error("Runner.Run: 节点列表为空")
-		return "", fmt.Errorf("节点列表不能为空（请通过 -n 指定节点 JSON 文件）")
+		return nil, fmt.Errorf("节点列表不能为空（请通过 -n 指定节点 JSON 文件）")
 	}
 
 	// 1. 加载模板并用节点渲染（支持 .template.json 内 Go template 语法），得到带 DAG 的步骤列表（不在此处拓扑排序）
-	renderedSteps, err := LoadAndRenderTemplate(r.pipelinesDir, pipelineName, nodes, args)
+	tpl, err := LoadAndRenderPipelineTemplate(r.pipelinesDir, pipelineName, nodes, args)
 	if err != nil {
 		logrus.Errorf("Runner.Run: 加载模板失败 pipeline=%s: %v", pipelineName, err)
-		return "", err
+		return nil, err
 	}
 
 	if taskID == "" {
@@ -53,108 +88,195 @@ func (r *Runner) Run(ctx context.Context, pipelineName string, nodes []RunNode,
 	}
 	runDir := RunDir(r.arRoot, pipelineName, taskID)
 	if err := os.MkdirAll(runDir, 0755); err != nil {
-		return "", fmt.Errorf("创建运行目录失败 %s: %w", runDir, err)
+		return nil, fmt.Errorf("创建运行目录失败 %s: %w", runDir, err)
 	}
 
 	// 2. 生成 pipeline.json（执行计划 DAG）并写入任务目录
-	runData := BuildRunData(taskID, pipelineName, renderedSteps, nodes)
-	if err := WritePipelineJSON(runDir, runData); err != nil {
-		return "", err
+	runData := BuildRunData(taskID, pipelineName, tpl.Steps, nodes)
+	runData.MaxParallel = tpl.MaxParallel
+	runData.Timeout = tpl.Timeout
+	runData.FailurePolicy = tpl.FailurePolicy
+	runData.Args = args
+	runData.ParentTaskID = subPipelineCallFrom(ctx).parentTaskID
+	runData.OnSuccess = buildStepStates(tpl.OnSuccess, nodes)
+	runData.OnFailure = buildStepStates(tpl.OnFailure, nodes)
+	runData.Always = buildStepStates(tpl.Always, nodes)
+	// 敏感值的真实值另存，pipeline.json 中掩码
+	if err := enableSecretMasking(r.arRoot, r.secretStore(), runData); err != nil {
+		return nil, err
 	}
-
-	// 步骤名 -> runData.Steps 下标，用于按执行顺序更新状态
-	nameToIndex := make(map[string]int)
-	for i := range runData.Steps {
-		nameToIndex[runData.Steps[i].Name] = i
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		return nil, err
 	}
+	return &taskRun{runDir: runDir, runData: runData}, nil
+}
 
-	logrus.Infof("开始执行流水线: pipeline=%s taskId=%s runDir=%s", pipelineName, taskID, runDir)
-	hostDataDir := filepath.Join(r.arRoot, "data")
+// Resume 从 pipeline.json 恢复流水线：读取任务目录、解析 DAG，跳过已 success / skipped 的步骤，其余步骤按依赖重新调度执行。
+func (r *Runner) Resume(ctx context.Context, taskID string) error {
+	return r.ResumeWith(ctx, taskID, ResumeOptions{})
+}
 
-	// 4. 按 DAG 层级执行：同一层内并行，跨层顺序
-	levels, err := StepsToLevels(runData.Steps)
-	if err != nil {
-		logrus.Errorf("Runner.Run: 解析 DAG 层级失败: %v", err)
-		return taskID, err
+// ResumeWith 按 opts 恢复流水线：可从指定步骤重新执行（重置其全部后继），或仅重新执行单个步骤，见 ResumeOptions。
+func (r *Runner) ResumeWith(ctx context.Context, taskID string, opts ResumeOptions) error {
+	run, err := r.prepareResumeRun(taskID, opts)
+	if err != nil || run == nil {
+		return err
 	}
-
-	var mu sync.Mutex
-	for levelIdx, levelSteps := range levels {
-		logrus.Infof("执行第 %d 层，共 %d 个步骤: %v", levelIdx+1, len(levelSteps), stepNames(levelSteps))
-		if err := r.runLevel(ctx, pipelineName, runDir, hostDataDir, runData, &mu, nameToIndex, levelSteps); err != nil {
-			return taskID, err
-		}
+	pipelineName := run.runData.PipelineName
+	if err := r.execute(ctx, run.runDir, run.runData, run.completed); err != nil {
+		return err
 	}
 
-	logrus.Infof("流水线执行完成: pipeline=%s taskId=%s", pipelineName, taskID)
-	return taskID, nil
+	logrus.Infof("流水线恢复执行完成: pipeline=%s taskId=%s", pipelineName, taskID)
+	return nil
 }
 
-// Resume 从 pipeline.json 恢复流水线：读取任务目录、解析 DAG 层级，从第一个未完全成功的层级开始并行执行。
-func (r *Runner) Resume(ctx context.Context, taskID string) error {
+// prepareResumeRun 读取任务的 pipeline.json 并按 opts 调整步骤状态，返回待恢复执行的任务；全部步骤均已完成时返回 nil。
+func (r *Runner) prepareResumeRun(taskID string, opts ResumeOptions) (*taskRun, error) {
 	runDir, err := FindRunDirByTaskID(r.arRoot, taskID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	runData, err := ReadPipelineJSON(runDir)
 	if err != nil {
-		return fmt.Errorf("读取 pipeline.json 失败: %w", err)
+		return nil, fmt.Errorf("读取 pipeline.json 失败: %w", err)
+	}
+	if taskRunningElsewhere(runData) {
+		return nil, fmt.Errorf("%w: 任务 %s 正由进程 %d 执行（状态 %s），请先停止任务再恢复", errTaskRunningElsewhere, taskID, runData.RunnerPID, runData.Status)
+	}
+	// pipeline.json 中的敏感值已掩码，从任务敏感值文件还原真实值
+	if err := restoreTaskSecrets(r.arRoot, r.secretStore(), runData); err != nil {
+		return nil, err
 	}
 	pipelineName := runData.PipelineName
 
-	levels, err := StepsToLevels(runData.Steps)
+	// 已 success / skipped 的步骤视为完成，其余步骤（failed / cancelled / pending）按依赖重新调度
+	completed, err := prepareResume(runDir, runData, opts)
 	if err != nil {
-		return fmt.Errorf("解析 pipeline.json DAG 失败: %w", err)
+		return nil, err
 	}
-	nameToIndex := make(map[string]int)
-	for i := range runData.Steps {
-		nameToIndex[runData.Steps[i].Name] = i
+	if len(completed) == len(runData.Steps) {
+		logrus.Infof("流水线已全部完成，无需恢复: pipeline=%s taskId=%s", pipelineName, taskID)
+		return nil, nil
 	}
 
-	// 找到第一个"不是全部 success"的层级
-	startLevelIdx := -1
-	for i, levelSteps := range levels {
-		allSuccess := true
-		for _, s := range levelSteps {
-			if runData.Steps[nameToIndex[s.Name]].Status != StatusSuccess {
-				allSuccess = false
-				break
-			}
-		}
-		if !allSuccess {
-			startLevelIdx = i
-			break
-		}
+	logrus.Infof("恢复流水线: pipeline=%s taskId=%s runDir=%s 已完成 %d/%d 个步骤", pipelineName, taskID, runDir, len(completed), len(runData.Steps))
+	return &taskRun{runDir: runDir, runData: runData, completed: completed}, nil
+}
+
+// execute 调度执行 runData 主流程中未完成的步骤（completed 中的步骤视为已完成），随后按结果执行流水线钩子，
+// 并记录任务的最终状态。Run 与 Resume 共用。
+func (r *Runner) execute(ctx context.Context, runDir string, runData *PipelineRunData, completed map[string]bool) error {
+	var mu sync.Mutex
+	main := runData.stepGroups()[0]
+	scheduler, err := r.newScheduler(runDir, runData, main, &mu)
+	if err != nil {
+		logrus.Errorf("解析 pipeline.json DAG 失败: %v", err)
+		return fmt.Errorf("解析 pipeline.json DAG 失败: %w", err)
 	}
-	if startLevelIdx < 0 {
-		logrus.Infof("流水线已全部完成，无需恢复: pipeline=%s taskId=%s", pipelineName, taskID)
-		return nil
+	hostDataDir := filepath.Join(r.arRoot, "data")
+	// 执行期间响应 task stop：停止标记出现时取消 stopCtx（主流程与钩子的宽限期均由此触发）
+	stopCtx, stopTask := context.WithCancel(ctx)
+	defer stopTask()
+	stopper := newTaskStopWatcher(runDir, stopTask)
+	// 执行期间响应 pause / unpause（监听在任务置为 running 后启动），暂停期间流水线与步骤的 timeout 不计时
+	pauser := newTaskPauser(runDir, r.runtimeRoot)
+	mu.Lock()
+	runData.pauser = pauser
+	runData.stopper = stopper
+	mu.Unlock()
+	pipelineCtx, cancel := withPipelineTimeout(stopCtx, runData, &mu)
+	defer cancel()
+
+	// 执行期间独占目标节点，结束（含失败、停止）后释放
+	if err := r.acquireNodeLeases(pipelineCtx, runData); err != nil {
+		// 任务仍由其他进程执行时不能改写其 pipeline.json
+		if !errors.Is(err, errTaskRunningElsewhere) {
+			finishTask(pipelineCtx, runDir, runData, &mu, err)
+		}
+		return err
 	}
+	defer ReleaseNodeLeases(r.arRoot, runData.TaskID)
 
-	logrus.Infof("恢复流水线: pipeline=%s taskId=%s runDir=%s 从第 %d 层开始", pipelineName, taskID, runDir, startLevelIdx+1)
-	hostDataDir := filepath.Join(r.arRoot, "data")
-	var mu sync.Mutex
+	// 上次执行遗留的暂停标记（如暂停中进程退出）不再生效，恢复任务即继续执行；须在任务置为 running 前清除，
+	// 此后 pause 才会被接受
+	if err := clearTaskPause(runDir); err != nil {
+		logrus.Warn(err)
+	}
+	// 上次执行遗留的停止标记同样不再生效
+	if err := clearTaskStop(runDir); err != nil {
+		logrus.Warn(err)
+	}
+	if err := setTaskRunning(runDir, runData, &mu); err != nil {
+		return err
+	}
+	// 任务结束前停止监听并解冻容器
+	watchCtx, stopWatch := context.WithCancel(pipelineCtx)
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		pauser.watch(watchCtx, runData, &mu)
+	}()
+	stopPauseWatch := func() {
+		stopWatch()
+		<-watchDone
+	}
+	defer stopPauseWatch()
+	// 停止标记的监听覆盖钩子阶段，不随任务上下文取消
+	stopWatchCtx, stopStopWatch := context.WithCancel(context.WithoutCancel(ctx))
+	stopWatchDone := make(chan struct{})
+	go func() {
+		defer close(stopWatchDone)
+		stopper.watch(stopWatchCtx)
+	}()
+	stopTaskWatch := func() {
+		stopStopWatch()
+		<-stopWatchDone
+	}
+	defer stopTaskWatch()
 
-	for i := startLevelIdx; i < len(levels); i++ {
-		levelSteps := levels[i]
-		// 过滤掉该层内已 success 的步骤
-		toRun := make([]PipelineStepState, 0, len(levelSteps))
-		for _, s := range levelSteps {
-			if runData.Steps[nameToIndex[s.Name]].Status != StatusSuccess {
-				toRun = append(toRun, s)
-			}
-		}
-		if len(toRun) == 0 {
-			continue
+	err = scheduler.run(pipelineCtx, completed, func(ctx context.Context, step PipelineStepState) error {
+		return r.runSingleStep(ctx, runDir, hostDataDir, runData, &mu, main, step, nil)
+	})
+	if err != nil && errors.Is(pipelineCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("流水线执行超时: %w", err)
+	}
+	if err == nil {
+		// 仅重新执行部分步骤（resume --only）时，其余步骤可能仍未完成，此时任务不记为成功
+		mu.Lock()
+		pending := unfinishedSteps(runData.Steps)
+		mu.Unlock()
+		if len(pending) > 0 {
+			err = fmt.Errorf("以下步骤尚未成功完成: %v（可通过 resume 继续执行）", pending)
 		}
-		logrus.Infof("恢复执行第 %d 层，%d 个步骤: %v", i+1, len(toRun), stepNames(toRun))
-		if err := r.runLevel(ctx, pipelineName, runDir, hostDataDir, runData, &mu, nameToIndex, toRun); err != nil {
-			return err
+	}
+	hookErr := r.runHooks(stopCtx, runDir, hostDataDir, runData, &mu, err)
+	err = errors.Join(err, hookErr)
+	stopPauseWatch()
+	stopTaskWatch()
+	// 任务被停止时保持 cancelled：停止方已将任务记为 cancelled，执行进程不能以内存中的状态覆盖
+	if stopper.check() {
+		mu.Lock()
+		CancelTask(runData)
+		mu.Unlock()
+		err = errors.Join(err, errTaskStopped)
+	}
+	finishTask(pipelineCtx, runDir, runData, &mu, err)
+	if err == nil {
+		// 成功结束的任务不再恢复执行，删除敏感值文件，避免节点密码等长期留在磁盘上
+		if rmErr := removeTaskSecrets(r.arRoot, runData.TaskID); rmErr != nil {
+			logrus.Warn(rmErr)
 		}
 	}
+	return err
+}
 
-	logrus.Infof("流水线恢复执行完成: pipeline=%s taskId=%s", pipelineName, taskID)
-	return nil
+// secretStore 返回加密任务敏感值文件与解析密钥引用的密钥存储；未配置时使用 arRoot 下的默认位置（secrets/ 与 secret.key）。
+func (r *Runner) secretStore() *SecretStore {
+	if r.stepConfig.Secrets != nil {
+		return r.stepConfig.Secrets
+	}
+	return NewSecretStore(filepath.Join(r.arRoot, "secrets"), filepath.Join(r.arRoot, "secret.key"))
 }
 
 // RunDirFor 返回指定流水线任务的运行目录，便于 CLI 输出或恢复/停止逻辑使用。
@@ -162,96 +284,376 @@ func RunDirFor(arRoot, pipelineName, taskID string) string {
 	return RunDir(arRoot, pipelineName, taskID)
 }
 
-// runSingleStep 执行单个步骤，用 mu 保护 runData 状态写操作和 pipeline.json 持久化。
-// RunStep 本身（重操作）在锁外调用，保证并行度。
+// pipelineTimeoutKey context 中调用方指定的流水线执行时限（pipeline run --timeout / GraphQL timeout）的键。
+type pipelineTimeoutKey struct{}
+
+// WithPipelineTimeout 返回带流水线执行时限的 ctx，由 execute 与模板中的 timeout 合并后按暂停感知的方式计时
+// （暂停期间不计时，因此不能直接使用 context.WithTimeout）。
+func WithPipelineTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, pipelineTimeoutKey{}, timeout)
+}
+
+// withPipelineTimeout 按 pipeline.json 中的流水线级 timeout 与调用方指定的执行时限（取较小的非 0 值）为 ctx 设置时限，
+// 任务暂停期间不计时；均未配置时只返回可取消的 ctx。到期后运行中的步骤标记为 timeout。
+// 调用方的时限只作用于本任务，子流水线任务各自按其模板计时（仍受父任务时限约束）。
+func withPipelineTimeout(ctx context.Context, runData *PipelineRunData, mu *sync.Mutex) (context.Context, context.CancelFunc) {
+	timeout, _ := parseOptionalDuration(runData.Timeout)
+	if callerTimeout, _ := ctx.Value(pipelineTimeoutKey{}).(time.Duration); callerTimeout > 0 {
+		if timeout <= 0 || callerTimeout < timeout {
+			timeout = callerTimeout
+		}
+		ctx = WithPipelineTimeout(ctx, 0)
+	}
+	if timeout > 0 {
+		logrus.Infof("流水线执行时限: %s", timeout)
+	}
+	return withPauseAwareTimeout(ctx, timeout, runData.pauser, mu)
+}
+
+// setTaskRunning 将任务状态置为 running 并清空结束时间（恢复执行时同样调用），随后写回 pipeline.json。
+func setTaskRunning(runDir string, runData *PipelineRunData, mu *sync.Mutex) error {
+	mu.Lock()
+	defer mu.Unlock()
+	runData.Status = StatusRunning
+	runData.FinishedAt = nil
+	runData.RunnerPID = os.Getpid()
+	runData.RunnerStart = currentProcessStart()
+	return WritePipelineJSON(runDir, runData)
+}
+
+// finishTask 根据调度结果记录任务最终状态与结束时间：成功为 success，流水线超时为 timeout，
+// ctx 被取消（停止任务）为 cancelled，其余为 failed。写入失败仅记录日志，不覆盖调度错误。
+func finishTask(ctx context.Context, runDir string, runData *PipelineRunData, mu *sync.Mutex, runErr error) {
+	status := StatusSuccess
+	if runErr != nil {
+		switch {
+		case errors.Is(runErr, errTaskStopped):
+			status = StatusCancelled
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			status = StatusTimeout
+		case errors.Is(ctx.Err(), context.Canceled):
+			status = StatusCancelled
+		default:
+			status = StatusFailed
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	finishedAt := time.Now()
+	runData.Status = status
+	runData.FinishedAt = &finishedAt
+	// 执行进程即将结束对任务的处理，此后 resume / skip 不再因该进程仍存活而被拒绝
+	runData.RunnerPID = 0
+	runData.RunnerStart = ""
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		logrus.Warnf("写入任务状态 %s 失败: %v", status, err)
+	}
+}
+
+// newScheduler 为 runData 中的一个步骤分组（主流程或钩子）构造 DAG 调度器：并发上限取 Runner 全局值与 pipeline.json 中流水线级值的较小者，
+// 等待槽位的步骤在 mu 保护下写回 queued 状态。
+func (r *Runner) newScheduler(runDir string, runData *PipelineRunData, group stepGroup, mu *sync.Mutex) (*dagScheduler, error) {
+	maxParallel := effectiveMaxParallel(r.maxParallel, runData.MaxParallel)
+	scheduler, err := newDAGScheduler(group.steps, maxParallel)
+	if err != nil {
+		return nil, err
+	}
+	if maxParallel > 0 {
+		logrus.Infof("流水线并发上限: %d", maxParallel)
+	}
+	scheduler.setStatus = func(step PipelineStepState, status string) {
+		mu.Lock()
+		defer mu.Unlock()
+		group.steps[group.indexOf(step.Name)].Status = status
+		if err := WritePipelineJSON(runDir, runData); err != nil {
+			logrus.Warnf("写入步骤 %s 状态 %s 失败: %v", step.Name, status, err)
+		}
+	}
+	scheduler.finishIndependentBranches = runData.FailurePolicy == FailurePolicyFinishIndependentBranches
+	scheduler.gate = func(ctx context.Context) error {
+		if err := runData.pauser.wait(ctx, mu); err != nil {
+			return err
+		}
+		// 主流程启动新步骤前确认任务未被停止；钩子在停止后仍需执行清理
+		if group.kind == stepGroupMain && runData.stopper.check() {
+			return errTaskStopped
+		}
+		return nil
+	}
+	scheduler.skip = func(step PipelineStepState) (bool, error) {
+		if strings.TrimSpace(step.When) == "" {
+			return false, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		state := &group.steps[group.indexOf(step.Name)]
+		ok, err := evalWhen(step.When, runData)
+		if err != nil {
+			err = fmt.Errorf("步骤 %s 的 when 表达式求值失败: %w", step.Name, err)
+			state.Error = err.Error()
+			finishStep(state, StatusFailed, time.Now())
+		} else if !ok {
+			logrus.Infof("步骤 %s 的 when 条件不满足，跳过执行: %s", step.Name, step.When)
+			state.Error = ""
+			finishStep(state, StatusSkipped, time.Now())
+		}
+		if writeErr := WritePipelineJSON(runDir, runData); writeErr != nil && err == nil {
+			err = writeErr
+		}
+		return err == nil && !ok, err
+	}
+	return scheduler, nil
+}
+
+// runSingleStep 执行 group 中的单个步骤，用 mu 保护 runData 状态写操作和 pipeline.json 持久化。
+// RunStep 本身（重操作）在锁外调用，保证并行度。extraEnv 追加到容器环境变量（不写入 pipeline.json），用于向钩子传递主流程结果。
 func (r *Runner) runSingleStep(
 	ctx context.Context,
-	pipelineName, runDir, hostDataDir string,
+	runDir, hostDataDir string,
 	runData *PipelineRunData,
 	mu *sync.Mutex,
-	nameToIndex map[string]int,
+	group stepGroup,
 	step PipelineStepState,
+	extraEnv []string,
 ) error {
-	stepIndex := nameToIndex[step.Name]
+	pipelineName := runData.PipelineName
+	// 主流程步骤因 task stop 结束时记为 cancelled，与停止方写入的状态一致
+	failedStatus := func(errs ...error) string {
+		if group.kind == stepGroupMain && runData.stopper.isStopped() {
+			return StatusCancelled
+		}
+		return failureStatus(errs...)
+	}
+	// stopRequested 同步检查主流程步骤所属任务是否已被 task stop 停止：停止方直接结束容器，
+	// 执行进程的 ctx 要等到下一次轮询停止标记才取消，重试前须先检查，避免为已停止的任务启动新容器
+	stopRequested := func() bool {
+		return group.kind == stepGroupMain && runData.stopper.check()
+	}
+	localIndex := group.indexOf(step.Name)
+	stepIndex := group.indexBase + localIndex
 	nodeDir := NodeDir(runDir, stepIndex)
 	if err := os.MkdirAll(nodeDir, 0755); err != nil {
 		return fmt.Errorf("创建节点目录失败 %s: %w", nodeDir, err)
 	}
 
 	mu.Lock()
-	runData.Steps[stepIndex].Status = StatusRunning
+	state := &group.steps[localIndex]
+	state.Status = StatusRunning
+	// 清空上一次执行（如恢复前失败）留下的元数据
+	startedAt := time.Now()
+	state.StartedAt = &startedAt
+	state.FinishedAt = nil
+	state.DurationMs = 0
+	state.ExitCode = nil
+	state.Error = ""
+	state.OOMKilled = false
+	// 恢复执行时沿用已有尝试记录继续编号，保证每次尝试的容器 ID 唯一
+	firstAttempt := len(state.Attempts) + 1
+	state.Attempt = firstAttempt
+	state.ContainerID = attemptContainerID(pipelineName, step, stepIndex, firstAttempt)
+	state.Outputs = nil
+	// 引用了前驱步骤输出的 entrypoint/args/env 在启动前渲染，引用无法解析时步骤直接失败
+	if state.Unrendered != nil {
+		cmd, err := renderDeferredCommand(state.Unrendered, runData)
+		if err != nil {
+			err = fmt.Errorf("步骤 %s 渲染输出引用失败: %w", step.Name, err)
+			state.Error = err.Error()
+			finishStep(state, StatusFailed, time.Now())
+			_ = WritePipelineJSON(runDir, runData)
+			mu.Unlock()
+			return err
+		}
+		state.Entrypoint, state.Args, state.Env = cmd.Entrypoint, cmd.Args, cmd.Env
+	}
+	// 额外挂载在启动前解析：宿主机路径不存在或不在白名单内时步骤直接失败
+	mounts, err := r.stepMounts(state)
+	if err != nil {
+		err = fmt.Errorf("步骤 %s 的 mounts 无效: %w", step.Name, err)
+		state.Error = err.Error()
+		finishStep(state, StatusFailed, time.Now())
+		_ = WritePipelineJSON(runDir, runData)
+		mu.Unlock()
+		return err
+	}
+	// 密钥引用在启动前从密钥存储解析，真实值只进入 stepSnapshot，state 与 pipeline.json 中保留引用
+	cmd, secrets, err := resolveStepSecrets(stepCommandOf(*state), r.secretStore(), runData.secrets)
+	if err != nil {
+		err = fmt.Errorf("步骤 %s 解析密钥引用失败: %w", step.Name, err)
+		state.Error = err.Error()
+		finishStep(state, StatusFailed, time.Now())
+		_ = WritePipelineJSON(runDir, runData)
+		mu.Unlock()
+		return err
+	}
+	stepSnapshot := *state
+	stepSnapshot.Entrypoint, stepSnapshot.Args, stepSnapshot.Env = cmd.Entrypoint, cmd.Args, cmd.Env
+	masked := secretValueList(secrets)
+	if len(extraEnv) > 0 {
+		stepSnapshot.Env = append(append([]string{}, stepSnapshot.Env...), extraEnv...)
+	}
+	// 步骤未设置的资源限制使用全局默认值
+	stepSnapshot.Resources = effectiveStepResources(stepSnapshot.Resources, r.stepConfig.Resources)
 	snapErr := WritePipelineJSON(runDir, runData)
 	mu.Unlock()
 	if snapErr != nil {
 		return snapErr
 	}
 
-	containerID := fmt.Sprintf("ar_%s_%s_%d",
-		sanitizePipelineName(pipelineName),
-		sanitizeStepNameForContainerID(step.Name, stepIndex+1),
-		stepIndex+1)
+	maxAttempts := stepSnapshot.Retries + 1
+	stepTimeout, _ := parseOptionalDuration(stepSnapshot.Timeout)
+	var stepErr error
+	stopped := false
+	for n := 1; n <= maxAttempts; n++ {
+		attempt := firstAttempt + n - 1
+		containerID := attemptContainerID(pipelineName, step, stepIndex, attempt)
+		attemptStartedAt := time.Now()
+		if n > 1 {
+			// 任务暂停期间不启动重试，任务已被停止时不再重试
+			if err := runData.pauser.wait(ctx, mu); err != nil || stopRequested() {
+				mu.Lock()
+				finishStep(state, failedStatus(ctx.Err()), time.Now())
+				_ = WritePipelineJSON(runDir, runData)
+				mu.Unlock()
+				return stepErr
+			}
+			mu.Lock()
+			state.Attempt = attempt
+			state.ContainerID = containerID
+			_ = WritePipelineJSON(runDir, runData)
+			mu.Unlock()
+		}
 
-	// RunStep 不修改 runData，可在锁外并发调用
-	result := RunStep(ctx, r.runtimeRoot, r.imagesStoreDir, runDir, nodeDir, hostDataDir, containerID, &runData.Steps[stepIndex])
+		// 清理上一次尝试残留的输出文件，避免失败的尝试污染本次输出
+		if err := os.Remove(filepath.Join(nodeDir, stepOutputsFile)); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("清理步骤 %s 的旧输出文件失败: %v", step.Name, err)
+		}
 
-	mu.Lock()
-	defer mu.Unlock()
+		// 单次尝试的超时：到期后 runOneShotContainer 先 SIGTERM，宽限期后 SIGKILL
+		// 任务暂停期间不计时，避免恢复后冻结过的步骤直接以 timeout 结束
+		attemptCtx, cancelAttempt := withPauseAwareTimeout(ctx, stepTimeout, runData.pauser, mu)
+		var result RunStepResult
+		switch {
+		case stepSnapshot.Pipeline != "":
+			result = r.runSubPipeline(attemptCtx, runDir, runData, mu, state, &stepSnapshot)
+		case stepSnapshot.Approval != nil:
+			result = r.waitForApproval(attemptCtx, runDir, nodeDir, runData, mu, state, &stepSnapshot)
+		default:
+			// RunStep 不修改 runData，可在锁外并发调用
+			result = RunStep(attemptCtx, r.runtimeRoot, r.imagesStoreDir, RootfsCacheDir(r.arRoot), runDir, nodeDir, hostDataDir, containerID, &stepSnapshot, mounts, secrets)
+		}
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+		cancelAttempt()
+
+		stepErr = nil
+		if timedOut {
+			stepErr = fmt.Errorf("步骤 %s 执行超时", step.Name)
+		} else if result.Err != nil {
+			stepErr = fmt.Errorf("步骤 %s 执行失败: %w", step.Name, result.Err)
+		} else if result.OOMKilled {
+			stepErr = oomKilledError(step.Name, stepSnapshot.Resources, result.ExitCode)
+		} else if !isSuccessExitCode(stepSnapshot.StepOptions, result.ExitCode) {
+			stepErr = fmt.Errorf("步骤 %s 退出码 %d 不在成功退出码范围内（按设计停止后续步骤）", step.Name, result.ExitCode)
+		}
+		var outputs map[string]string
+		if stepErr == nil {
+			var outErr error
+			if outputs, outErr = readStepOutputs(nodeDir); outErr != nil {
+				stepErr = fmt.Errorf("步骤 %s 输出无效: %w", step.Name, outErr)
+			}
+		}
 
-	if result.Err != nil {
-		runData.Steps[stepIndex].Status = StatusFailed
-		_ = WritePipelineJSON(runDir, runData)
-		logrus.Errorf("步骤 %s 执行失败: %v", step.Name, result.Err)
-		return fmt.Errorf("步骤 %s 执行失败: %w", step.Name, result.Err)
+		record := StepAttempt{
+			Attempt:     attempt,
+			ContainerID: containerID,
+			ExitCode:    result.ExitCode,
+			TimedOut:    timedOut,
+			OOMKilled:   result.OOMKilled,
+			StartedAt:   attemptStartedAt,
+			FinishedAt:  time.Now(),
+		}
+		if result.Err != nil {
+			record.Error = maskSecrets(result.Err.Error(), masked)
+		}
+		stopped = stepErr != nil && stopRequested()
+		mu.Lock()
+		state.Attempts = append(state.Attempts, record)
+		exitCode := result.ExitCode
+		state.ExitCode = &exitCode
+		state.OOMKilled = result.OOMKilled
+		state.Error = ""
+		if stepErr != nil {
+			state.Error = maskSecrets(stepErr.Error(), masked)
+		}
+		if stepErr == nil {
+			state.Outputs = outputs
+			finishStep(state, StatusSuccess, record.FinishedAt)
+		} else if n == maxAttempts || ctx.Err() != nil || stopped {
+			finishStep(state, failedStatus(attemptCtx.Err(), ctx.Err()), record.FinishedAt)
+		}
+		writeErr := WritePipelineJSON(runDir, runData)
+		mu.Unlock()
+
+		if stepErr == nil {
+			if writeErr != nil {
+				return writeErr
+			}
+			logrus.Infof("步骤完成: %s", step.Name)
+			return nil
+		}
+		logrus.Errorf("步骤 %s 第 %d 次尝试失败: %s", step.Name, attempt, maskSecrets(stepErr.Error(), masked))
+		if n == maxAttempts || ctx.Err() != nil || stopped {
+			break
+		}
+
+		delay := retryDelayFor(stepSnapshot.StepOptions, n)
+		logrus.Infof("步骤 %s 将在 %s 后重试（%d/%d）", step.Name, delay, n, stepSnapshot.Retries)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			mu.Lock()
+			finishStep(state, failedStatus(ctx.Err()), time.Now())
+			_ = WritePipelineJSON(runDir, runData)
+			mu.Unlock()
+			return stepErr
+		}
 	}
-	if result.ExitCode != 0 {
-		runData.Steps[stepIndex].Status = StatusFailed
-		_ = WritePipelineJSON(runDir, runData)
-		logrus.Errorf("步骤 %s 退出码非 0: %d", step.Name, result.ExitCode)
-		return fmt.Errorf("步骤 %s 退出码非 0: %d（按设计停止后续步骤）", step.Name, result.ExitCode)
+	if stepSnapshot.AllowFailure && ctx.Err() == nil && !stopped {
+		// 允许失败的步骤保留 failed 状态与错误信息，但不阻断后继步骤与任务结果
+		logrus.Warnf("步骤 %s 失败但已配置 allowFailure，继续执行后续步骤: %s", step.Name, maskSecrets(stepErr.Error(), masked))
+		return nil
 	}
+	return stepErr
+}
 
-	runData.Steps[stepIndex].Status = StatusSuccess
-	if err := WritePipelineJSON(runDir, runData); err != nil {
-		return err
+// stepCompleted 判断步骤是否已完成：success、skipped 以及配置了 allowFailure 的 failed 步骤视为完成（与失败策略一致，后继已按依赖满足调度）。
+func stepCompleted(s PipelineStepState) bool {
+	switch s.Status {
+	case StatusSuccess, StatusSkipped:
+		return true
+	case StatusFailed:
+		return s.AllowFailure
 	}
-	logrus.Infof("步骤完成: %s", step.Name)
-	return nil
+	return false
 }
 
-// runLevel 并行执行同一层内所有步骤，等待全部完成后汇总错误。
-func (r *Runner) runLevel(
-	ctx context.Context,
-	pipelineName, runDir, hostDataDir string,
-	runData *PipelineRunData,
-	mu *sync.Mutex,
-	nameToIndex map[string]int,
-	levelSteps []PipelineStepState,
-) error {
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(levelSteps))
-
-	for _, step := range levelSteps {
-		wg.Add(1)
-		go func(s PipelineStepState) {
-			defer wg.Done()
-			if ctx.Err() != nil {
-				errCh <- ctx.Err()
-				return
-			}
-			if err := r.runSingleStep(ctx, pipelineName, runDir, hostDataDir, runData, mu, nameToIndex, s); err != nil {
-				errCh <- err
-			}
-		}(step)
+// unfinishedSteps 返回未完成的步骤名（见 stepCompleted）。调用方需持有保护 runData 的锁。
+func unfinishedSteps(steps []PipelineStepState) []string {
+	var names []string
+	for _, s := range steps {
+		if !stepCompleted(s) {
+			names = append(names, s.Name)
+		}
 	}
+	return names
+}
 
-	wg.Wait()
-	close(errCh)
-
-	var errs []error
-	for e := range errCh {
-		errs = append(errs, e)
+// finishStep 记录步骤的最终状态与结束时间，并按 StartedAt 计算耗时。调用方需持有保护 runData 的锁。
+func finishStep(state *PipelineStepState, status string, finishedAt time.Time) {
+	state.Status = status
+	state.FinishedAt = &finishedAt
+	if state.StartedAt != nil {
+		state.DurationMs = finishedAt.Sub(*state.StartedAt).Milliseconds()
 	}
-	return errors.Join(errs...)
 }
 
 // stepNames 返回步骤名列表，用于日志输出。
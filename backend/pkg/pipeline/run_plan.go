@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/v1"
+)
+
+// secretMask 敏感值在执行计划、日志与接口中的替代显示。
+const secretMask = "******"
+
+// sensitiveEnvKeywords 环境变量名中包含这些关键字（不区分大小写）时，其值视为敏感信息。
+var sensitiveEnvKeywords = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "PRIVATE_KEY", "ACCESS_KEY", "API_KEY", "CREDENTIAL"}
+
+// PipelinePlan dry-run 得到的执行计划：渲染后的步骤、按依赖划分的执行层级及各步骤镜像是否已导入。不创建任务目录与容器。
+type PipelinePlan struct {
+	PipelineName  string `json:"pipelineName"`
+	MaxParallel   int    `json:"maxParallel,omitempty"`
+	Timeout       string `json:"timeout,omitempty"`
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+	// Levels 主流程步骤按依赖划分的层级，同一层内的步骤互不依赖，可并行执行
+	Levels    [][]string    `json:"levels"`
+	Steps     []PlannedStep `json:"steps"`
+	OnSuccess []PlannedStep `json:"onSuccess,omitempty"`
+	OnFailure []PlannedStep `json:"onFailure,omitempty"`
+	Always    []PlannedStep `json:"always,omitempty"`
+	// MissingImages 镜像存储中不存在的步骤镜像（去重）
+	MissingImages []string `json:"missingImages,omitempty"`
+}
+
+// PlannedStep 执行计划中的单个步骤。Command 与 Env 为容器最终的进程参数与环境变量（已结合镜像默认 entrypoint/cmd，敏感值已掩码）；
+// 镜像不存在时 Command 无法确定，为空。子流水线与审批步骤不启动容器，ImagePresent 为空。
+type PlannedStep struct {
+	Name         string   `json:"name"`
+	Image        string   `json:"image,omitempty"`
+	ImagePresent *bool    `json:"imagePresent,omitempty"`
+	Entrypoint   string   `json:"entrypoint,omitempty"`
+	Args         []string `json:"args,omitempty"`
+	Command      []string `json:"command,omitempty"`
+	Env          []string `json:"env,omitempty"`
+	Nodes        []string `json:"nodes,omitempty"`
+	When         string   `json:"when,omitempty"`
+	// Resources 生效的资源限制（步骤配置与全局默认值合并后），不限制时为空
+	Resources *StepResources `json:"resources,omitempty"`
+	// Deferred 为 true 表示命令引用了其他步骤的输出，相应部分在步骤启动前才渲染
+	Deferred      bool   `json:"deferred,omitempty"`
+	Pipeline      string `json:"pipeline,omitempty"`
+	Approval      bool   `json:"approval,omitempty"`
+	ForEachParent string `json:"forEachParent,omitempty"`
+	ForEachNode   string `json:"forEachNode,omitempty"`
+}
+
+// Plan 渲染流水线模板并生成执行计划（dry-run）：与 Run 使用相同的模板渲染与 pipeline.json 构建逻辑，
+// 计算主流程的执行层级，并检查各步骤镜像是否存在于镜像存储中。不写入任何文件，也不创建容器。
+func (r *Runner) Plan(pipelineName string, nodes []RunNode, args map[string]interface{}) (*PipelinePlan, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("节点列表不能为空（请通过 -n 指定节点 JSON 文件）")
+	}
+	tpl, err := LoadAndRenderPipelineTemplate(r.pipelinesDir, pipelineName, nodes, args)
+	if err != nil {
+		return nil, err
+	}
+	runData := BuildRunData("", pipelineName, tpl.Steps, nodes)
+	levels, err := StepsToLevels(runData.Steps)
+	if err != nil {
+		return nil, err
+	}
+	images, err := ListImages(r.imagesStoreDir)
+	if err != nil {
+		return nil, err
+	}
+
+	planner := &stepPlanner{images: images, configs: make(map[string]*v1.Config), secrets: secretValueList(taskSecretValues(nodes, args)), resources: r.stepConfig.Resources}
+	plan := &PipelinePlan{
+		PipelineName:  pipelineName,
+		MaxParallel:   tpl.MaxParallel,
+		Timeout:       tpl.Timeout,
+		FailurePolicy: tpl.FailurePolicy,
+		Steps:         planner.planSteps(runData.Steps),
+		OnSuccess:     planner.planSteps(buildStepStates(tpl.OnSuccess, nodes)),
+		OnFailure:     planner.planSteps(buildStepStates(tpl.OnFailure, nodes)),
+		Always:        planner.planSteps(buildStepStates(tpl.Always, nodes)),
+		MissingImages: planner.missing,
+	}
+	for _, level := range levels {
+		names := stepNames(level)
+		// 同一层内的顺序不影响执行，排序保证输出稳定
+		sort.Strings(names)
+		plan.Levels = append(plan.Levels, names)
+	}
+	return plan, nil
+}
+
+// stepPlanner 生成步骤执行计划，缓存已读取的镜像配置并记录缺失的镜像。
+type stepPlanner struct {
+	images  []ImageEntry
+	configs map[string]*v1.Config
+	missing []string
+	secrets []string
+	// resources 全局默认资源限制
+	resources StepResources
+}
+
+func (p *stepPlanner) planSteps(steps []PipelineStepState) []PlannedStep {
+	if len(steps) == 0 {
+		return nil
+	}
+	planned := make([]PlannedStep, 0, len(steps))
+	for i := range steps {
+		planned = append(planned, p.planStep(&steps[i]))
+	}
+	return planned
+}
+
+func (p *stepPlanner) planStep(step *PipelineStepState) PlannedStep {
+	planned := PlannedStep{
+		Name:          step.Name,
+		Image:         step.Image,
+		Entrypoint:    maskSecrets(step.Entrypoint, p.secrets),
+		Args:          maskSecretList(step.Args, p.secrets),
+		Env:           maskEnv(step.Env, p.secrets),
+		Nodes:         step.Nodes,
+		When:          step.When,
+		Deferred:      step.Unrendered != nil,
+		Pipeline:      step.Pipeline,
+		Approval:      step.Approval != nil,
+		ForEachParent: step.ForEachParent,
+		ForEachNode:   step.ForEachNode,
+	}
+	if step.Pipeline != "" || step.Approval != nil {
+		return planned
+	}
+	planned.Resources = effectiveStepResources(step.Resources, p.resources)
+	cfg, ok := p.imageConfig(step.Image)
+	planned.ImagePresent = &ok
+	if !ok {
+		return planned
+	}
+	if args, env, _, err := stepProcess(cfg, step); err == nil {
+		planned.Command = maskSecretList(args, p.secrets)
+		planned.Env = maskEnv(env, p.secrets)
+	}
+	return planned
+}
+
+// imageConfig 返回镜像的配置，镜像不存在或无法读取时返回 false 并记录为缺失。
+func (p *stepPlanner) imageConfig(image string) (*v1.Config, bool) {
+	if cfg, ok := p.configs[image]; ok {
+		return cfg, cfg != nil
+	}
+	var cfg *v1.Config
+	if entry, ok := findImageEntry(p.images, image); ok {
+		if img, err := openImageLayout(entry.Path); err == nil {
+			if file, err := img.ConfigFile(); err == nil {
+				cfg = &file.Config
+			}
+		}
+	}
+	p.configs[image] = cfg
+	if cfg == nil {
+		p.missing = append(p.missing, image)
+	}
+	return cfg, cfg != nil
+}
+
+// stepProcess 根据镜像配置与步骤参数确定容器进程的参数、环境变量与工作目录：
+// 步骤未设置 entrypoint 时使用镜像的 Entrypoint，entrypoint 与 args 均为空时使用镜像的 Cmd；环境变量缺少 PATH 时补充默认值；
+// 步骤的 workingDir 优先于镜像的 WorkingDir。
+func stepProcess(cfg *v1.Config, step *PipelineStepState) (args, env []string, cwd string, err error) {
+	if strings.TrimSpace(step.Entrypoint) != "" {
+		args = append(args, step.Entrypoint)
+	} else {
+		args = append(args, cfg.Entrypoint...)
+	}
+	args = append(args, step.Args...)
+	if len(args) == 0 {
+		args = append(args, cfg.Cmd...)
+	}
+	if len(args) == 0 {
+		return nil, nil, "", fmt.Errorf("步骤 %s 缺少 entrypoint/args，且镜像无默认 cmd", step.Name)
+	}
+
+	env = append([]string{}, step.Env...)
+	if !containsEnvKey(env, "PATH") {
+		env = append(env, "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin")
+	}
+
+	cwd = step.WorkingDir
+	if strings.TrimSpace(cwd) == "" {
+		cwd = cfg.WorkingDir
+	}
+	if strings.TrimSpace(cwd) == "" {
+		cwd = "/"
+	}
+	return args, env, cwd, nil
+}
+
+// maskSecrets 将 s 中出现的敏感值替换为 ******。
+func maskSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, secretMask)
+		}
+	}
+	return s
+}
+
+func maskSecretList(list []string, secrets []string) []string {
+	if list == nil {
+		return nil
+	}
+	masked := make([]string, len(list))
+	for i, s := range list {
+		masked[i] = maskSecrets(s, secrets)
+	}
+	return masked
+}
+
+// maskEnv 掩码环境变量：变量名含敏感关键字时整体替换其值，否则替换值中出现的敏感值。
+func maskEnv(env []string, secrets []string) []string {
+	if env == nil {
+		return nil
+	}
+	masked := make([]string, len(env))
+	for i, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if isSensitiveEnvKey(key) {
+			value = secretMask
+		} else {
+			value = maskSecrets(value, secrets)
+		}
+		masked[i] = key + "=" + value
+	}
+	return masked
+}
+
+func isSensitiveEnvKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, kw := range sensitiveEnvKeywords {
+		if strings.Contains(upper, kw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunnerPlan_RendersStepsAndMasksSecrets(t *testing.T) {
+	dir := t.TempDir()
+	tpl := `{"maxParallel": 2, "steps": [
+  {"name": "start", "image": "img", "nodes": ["install", "check"]},
+  {"name": "install", "image": "img", "entrypoint": "/bin/sh", "args": ["-c", "sshpass -p {{(index .nodes 0).Password}} ssh root@{{(index .nodes 0).IP}}"], "env": ["DB_PASSWORD=abc", "MODE=prod"], "nodes": ["confirm"]},
+  {"name": "check", "image": "img", "nodes": ["confirm"]},
+  {"name": "confirm", "approval": {"message": "ok?"}}
+]}`
+	if err := os.WriteFile(filepath.Join(dir, "k8s.template.json"), []byte(tpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	arRoot := t.TempDir()
+	runner := NewRunner(arRoot, dir, t.TempDir(), "", 0, StepContainerConfig{})
+	nodes := []RunNode{{IP: "10.0.0.1", Username: "root", Password: "s3cret"}}
+	plan, err := runner.Plan("k8s", nodes, nil)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	wantLevels := [][]string{{"start"}, {"check", "install"}, {"confirm"}}
+	if !reflect.DeepEqual(plan.Levels, wantLevels) {
+		t.Fatalf("unexpected levels %v", plan.Levels)
+	}
+	if plan.MaxParallel != 2 || !reflect.DeepEqual(plan.MissingImages, []string{"img"}) {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	install := plan.Steps[1]
+	if install.Args[1] != "sshpass -p ****** ssh root@10.0.0.1" {
+		t.Fatalf("node password should be masked, got %q", install.Args[1])
+	}
+	if !reflect.DeepEqual(install.Env, []string{"DB_PASSWORD=******", "MODE=prod"}) {
+		t.Fatalf("unexpected env %v", install.Env)
+	}
+	if install.ImagePresent == nil || *install.ImagePresent {
+		t.Fatalf("image should be reported as missing")
+	}
+	if confirm := plan.Steps[3]; !confirm.Approval || confirm.ImagePresent != nil {
+		t.Fatalf("unexpected approval step %+v", confirm)
+	}
+	// dry-run 不应创建任务目录
+	if _, err := os.Stat(filepath.Join(arRoot, "tasks")); !os.IsNotExist(err) {
+		t.Fatalf("plan should not create task directories")
+	}
+}
@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	RetryBackoffFixed       = "fixed"
+	RetryBackoffExponential = "exponential"
+)
+
+// 流水线级失败策略
+const (
+	FailurePolicyFailFast                  = "failFast"                  // 任一步骤失败后不再启动新步骤（默认）
+	FailurePolicyFinishIndependentBranches = "finishIndependentBranches" // 仅阻断失败步骤的下游，不依赖它的分支继续执行至完成
+)
+
+// validateFailurePolicy 校验模板中的 failurePolicy 字段，空字符串表示默认的 failFast。
+func validateFailurePolicy(policy string) error {
+	switch policy {
+	case "", FailurePolicyFailFast, FailurePolicyFinishIndependentBranches:
+		return nil
+	default:
+		return fmt.Errorf("failurePolicy 无效: %s（可选 %s | %s）", policy, FailurePolicyFailFast, FailurePolicyFinishIndependentBranches)
+	}
+}
+
+// validateStepOptions 校验步骤执行策略字段，在模板解析后、生成 pipeline.json 前调用，尽早暴露模板错误。
+func validateStepOptions(step TemplateStep) error {
+	stepName, opts := step.Name, step.StepOptions
+	if opts.MaxParallel < 0 {
+		return fmt.Errorf("步骤 %s 的 maxParallel 不能为负数: %d", stepName, opts.MaxParallel)
+	}
+	// 单个步骤自成一组时 maxParallel 不起作用；forEach 步骤的各实例以父步骤名为分组
+	if opts.MaxParallel > 0 && opts.ParallelGroup == "" && step.ForEach == "" {
+		return fmt.Errorf("步骤 %s 设置了 maxParallel 但未设置 parallelGroup（仅 forEach 步骤可省略，其实例共享父步骤的并发上限）", stepName)
+	}
+	if opts.Retries < 0 {
+		return fmt.Errorf("步骤 %s 的 retries 不能为负数: %d", stepName, opts.Retries)
+	}
+	if _, err := parseOptionalDuration(opts.RetryDelay); err != nil {
+		return fmt.Errorf("步骤 %s 的 retryDelay 无效: %w", stepName, err)
+	}
+	if _, err := parseOptionalDuration(opts.MaxRetryDelay); err != nil {
+		return fmt.Errorf("步骤 %s 的 maxRetryDelay 无效: %w", stepName, err)
+	}
+	if _, err := parseOptionalDuration(opts.Timeout); err != nil {
+		return fmt.Errorf("步骤 %s 的 timeout 无效: %w", stepName, err)
+	}
+	if strings.TrimSpace(opts.When) != "" {
+		if _, err := parseWhen(opts.When); err != nil {
+			return fmt.Errorf("步骤 %s 的 when 表达式无效: %w", stepName, err)
+		}
+	}
+	for _, code := range opts.SuccessExitCodes {
+		if code < 0 || code > 255 {
+			return fmt.Errorf("步骤 %s 的 successExitCodes 无效: %d（取值范围 0-255）", stepName, code)
+		}
+	}
+	if err := validateStepResources(opts.Resources); err != nil {
+		return fmt.Errorf("步骤 %s 的 resources %w", stepName, err)
+	}
+	switch strings.ToLower(strings.TrimSpace(opts.RetryBackoff)) {
+	case "", RetryBackoffFixed, RetryBackoffExponential:
+	default:
+		return fmt.Errorf("步骤 %s 的 retryBackoff 无效: %s（可选 fixed | exponential）", stepName, opts.RetryBackoff)
+	}
+	return nil
+}
+
+// parseOptionalDuration 解析 Go duration 字符串，空字符串返回 0。
+func parseOptionalDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("不能为负数: %s", s)
+	}
+	return d, nil
+}
+
+// retryDelayFor 返回第 attempt 次尝试失败后、下一次尝试前的等待时间（attempt 从 1 开始）。
+// fixed 每次等待 retryDelay；exponential 等待 retryDelay * 2^(attempt-1)，并受 maxRetryDelay 限制。
+func retryDelayFor(opts StepOptions, attempt int) time.Duration {
+	base, _ := parseOptionalDuration(opts.RetryDelay)
+	if base <= 0 || attempt < 1 {
+		return base
+	}
+	if !strings.EqualFold(strings.TrimSpace(opts.RetryBackoff), RetryBackoffExponential) {
+		return base
+	}
+	maxDelay, _ := parseOptionalDuration(opts.MaxRetryDelay)
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if maxDelay > 0 && delay >= maxDelay {
+			return maxDelay
+		}
+		// 防止溢出：超过一天已无实际意义
+		if delay > 24*time.Hour {
+			delay = 24 * time.Hour
+			break
+		}
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// isSuccessExitCode 判断退出码是否视为步骤成功：未配置 successExitCodes 时仅 0 为成功。
+func isSuccessExitCode(opts StepOptions, exitCode int) bool {
+	if len(opts.SuccessExitCodes) == 0 {
+		return exitCode == 0
+	}
+	for _, code := range opts.SuccessExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// failureStatus 根据上下文错误判断步骤失败时应记录的状态：超时（步骤或流水线时限到期）记为 timeout，其余记为 failed。
+func failureStatus(errs ...error) string {
+	for _, err := range errs {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return StatusTimeout
+		}
+	}
+	return StatusFailed
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayFor(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    StepOptions
+		attempt int
+		want    time.Duration
+	}{
+		{"no delay", StepOptions{Retries: 3}, 1, 0},
+		{"fixed", StepOptions{RetryDelay: "5s"}, 3, 5 * time.Second},
+		{"exponential first", StepOptions{RetryDelay: "2s", RetryBackoff: "exponential"}, 1, 2 * time.Second},
+		{"exponential third", StepOptions{RetryDelay: "2s", RetryBackoff: "exponential"}, 3, 8 * time.Second},
+		{"exponential capped", StepOptions{RetryDelay: "2s", RetryBackoff: "exponential", MaxRetryDelay: "5s"}, 3, 5 * time.Second},
+	}
+	for _, c := range cases {
+		if got := retryDelayFor(c.opts, c.attempt); got != c.want {
+			t.Errorf("%s: retryDelayFor(attempt=%d) = %s, want %s", c.name, c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestValidateStepOptions(t *testing.T) {
+	if err := validateStepOptions(TemplateStep{Name: "ok", StepOptions: StepOptions{Retries: 2, RetryDelay: "1s", RetryBackoff: "exponential", MaxRetryDelay: "1m"}}); err != nil {
+		t.Fatalf("expected valid options, got %v", err)
+	}
+	if err := validateStepOptions(TemplateStep{Name: "copy", ForEach: "*", StepOptions: StepOptions{MaxParallel: 1}}); err != nil {
+		t.Fatalf("forEach step may omit parallelGroup, got %v", err)
+	}
+	invalid := []StepOptions{
+		{Retries: -1},
+		{RetryDelay: "abc"},
+		{RetryBackoff: "linear"},
+		{MaxParallel: -2},
+		{MaxParallel: 2},
+		{Timeout: "-5s"},
+		{SuccessExitCodes: []int{256}},
+		{Resources: &StepResources{Memory: "lots"}},
+		{Resources: &StepResources{CPU: "0"}},
+	}
+	for _, opts := range invalid {
+		if err := validateStepOptions(TemplateStep{Name: "bad", StepOptions: opts}); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+}
+
+func TestFailureStatus(t *testing.T) {
+	if got := failureStatus(nil, context.DeadlineExceeded); got != StatusTimeout {
+		t.Fatalf("expected %s, got %s", StatusTimeout, got)
+	}
+	if got := failureStatus(context.Canceled, errors.New("exit 1")); got != StatusFailed {
+		t.Fatalf("expected %s, got %s", StatusFailed, got)
+	}
+}
+
+func TestIsSuccessExitCode(t *testing.T) {
+	if !isSuccessExitCode(StepOptions{}, 0) || isSuccessExitCode(StepOptions{}, 2) {
+		t.Fatalf("default options should only accept exit code 0")
+	}
+	opts := StepOptions{SuccessExitCodes: []int{0, 2}}
+	if !isSuccessExitCode(opts, 2) {
+		t.Fatalf("exit code 2 should be accepted by %v", opts.SuccessExitCodes)
+	}
+	if isSuccessExitCode(opts, 1) {
+		t.Fatalf("exit code 1 should be rejected by %v", opts.SuccessExitCodes)
+	}
+}
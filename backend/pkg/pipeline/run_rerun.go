@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ResumeOptions 恢复执行的范围。From 与 Only 互斥，均为空时跳过已 success / skipped 的步骤，其余步骤按依赖重新调度。
+// 步骤名可以是 forEach 步骤在模板中的父步骤名，此时作用于它的全部节点实例。
+type ResumeOptions struct {
+	// From 从该步骤重新执行：该步骤及其全部后继重置为 pending，其余未完成的步骤照常恢复
+	From string
+	// Only 仅重新执行该步骤：其余步骤保持原状态且不执行（后继不会因此重新执行，需要时使用 From）
+	Only string
+}
+
+// findMainSteps 返回主流程中名称为 name（或 forEach 父步骤名为 name）的步骤下标。
+func findMainSteps(runData *PipelineRunData, name string) ([]int, error) {
+	var indexes []int
+	for i, s := range runData.Steps {
+		if s.Name == name || s.ForEachParent == name {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("流水线主流程中不存在步骤: %s", name)
+	}
+	return indexes, nil
+}
+
+// descendantSteps 返回 roots 及其在 DAG 中的全部后继步骤下标（按 nodes 边遍历，含 roots 本身）。
+func descendantSteps(steps []PipelineStepState, roots []int) []int {
+	index := make(map[string]int, len(steps))
+	for i, s := range steps {
+		index[s.Name] = i
+	}
+	visited := make(map[int]bool, len(steps))
+	queue := append([]int{}, roots...)
+	var result []int
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		result = append(result, i)
+		for _, next := range steps[i].Nodes {
+			if j, ok := index[next]; ok && !visited[j] {
+				queue = append(queue, j)
+			}
+		}
+	}
+	return result
+}
+
+// resetStepForRerun 将主流程中下标为 i 的步骤重置为 pending 并清空上次执行的结果，保留历次尝试记录以便容器 ID 继续编号。
+// 子流水线步骤清空 childTaskId 以启动新的子任务；审批步骤清除已有的审批决定以重新发起审批。
+func resetStepForRerun(runDir string, runData *PipelineRunData, i int) error {
+	state := &runData.Steps[i]
+	state.Status = StatusPending
+	state.StartedAt = nil
+	state.FinishedAt = nil
+	state.DurationMs = 0
+	state.ExitCode = nil
+	state.Error = ""
+	state.OOMKilled = false
+	state.Outputs = nil
+	state.ChildTaskID = ""
+	state.ApprovalResult = nil
+	if err := os.Remove(filepath.Join(NodeDir(runDir, i), stepApprovalFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("清理步骤 %s 的审批决定失败: %w", state.Name, err)
+	}
+	return nil
+}
+
+// prepareResume 按 opts 调整主流程步骤状态，并返回本次恢复视为已完成（不再执行）的步骤集合。
+func prepareResume(runDir string, runData *PipelineRunData, opts ResumeOptions) (map[string]bool, error) {
+	if opts.From != "" && opts.Only != "" {
+		return nil, fmt.Errorf("--from 与 --only 不能同时指定")
+	}
+	var reset []int
+	switch {
+	case opts.From != "":
+		roots, err := findMainSteps(runData, opts.From)
+		if err != nil {
+			return nil, err
+		}
+		reset = descendantSteps(runData.Steps, roots)
+	case opts.Only != "":
+		targets, err := findMainSteps(runData, opts.Only)
+		if err != nil {
+			return nil, err
+		}
+		reset = targets
+	}
+	for _, i := range reset {
+		if err := resetStepForRerun(runDir, runData, i); err != nil {
+			return nil, err
+		}
+	}
+
+	completed := make(map[string]bool)
+	for _, s := range runData.Steps {
+		if opts.Only != "" {
+			// 仅重新执行指定步骤：其余步骤无论状态如何都不再调度
+			if s.Status != StatusPending || (s.Name != opts.Only && s.ForEachParent != opts.Only) {
+				completed[s.Name] = true
+			}
+			continue
+		}
+		// 配置了 allowFailure 的失败步骤同样视为完成，需要重新执行时使用 --from / --only
+		if stepCompleted(s) {
+			completed[s.Name] = true
+		}
+	}
+	return completed, nil
+}
+
+// SkipStep 将主流程中的步骤（forEach 父步骤名作用于全部实例）标记为 skipped，恢复执行时其后继视为依赖已满足。
+// 正在运行或等待审批的步骤不能跳过，需先停止任务。仅修改 pipeline.json，不启动执行；
+// 任务仍由其他存活进程执行时拒绝，否则该进程下次写回 pipeline.json 会覆盖跳过标记。
+func SkipStep(arRoot, taskID, stepName string) (*PipelineRunData, error) {
+	runDir, err := FindRunDirByTaskID(arRoot, taskID)
+	if err != nil {
+		return nil, err
+	}
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取 pipeline.json 失败: %w", err)
+	}
+	if taskRunningElsewhere(runData) {
+		return nil, fmt.Errorf("%w: 任务 %s 正由进程 %d 执行（状态 %s），请先停止任务再跳过步骤", errTaskRunningElsewhere, taskID, runData.RunnerPID, runData.Status)
+	}
+	indexes, err := findMainSteps(runData, stepName)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range indexes {
+		if s := runData.Steps[i]; s.Status == StatusRunning || s.Status == StatusWaiting || s.Status == StatusQueued {
+			return nil, fmt.Errorf("步骤 %s 当前状态为 %s，请先停止任务再跳过", s.Name, s.Status)
+		}
+	}
+	for _, i := range indexes {
+		runData.Steps[i].Status = StatusSkipped
+		runData.Steps[i].Error = ""
+	}
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		return nil, err
+	}
+	return runData, nil
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func rerunTestData() *PipelineRunData {
+	return &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "k8s",
+		Steps: []PipelineStepState{
+			{Name: "start", Status: StatusSuccess, Nodes: []string{"copy@10.0.0.1", "copy@10.0.0.2"}},
+			{Name: "copy@10.0.0.1", Status: StatusSuccess, ForEachParent: "copy", Nodes: []string{"install"}},
+			{Name: "copy@10.0.0.2", Status: StatusSuccess, ForEachParent: "copy", Nodes: []string{"install"}},
+			{Name: "install", Status: StatusSuccess, Nodes: []string{"verify"}, ChildTaskID: "child"},
+			{Name: "verify", Status: StatusFailed, Error: "boom"},
+			{Name: "other", Status: StatusSuccess},
+		},
+	}
+}
+
+func TestPrepareResume_FromResetsDescendants(t *testing.T) {
+	runData := rerunTestData()
+	completed, err := prepareResume(t.TempDir(), runData, ResumeOptions{From: "copy"})
+	if err != nil {
+		t.Fatalf("prepareResume returned error: %v", err)
+	}
+	want := map[string]string{"start": StatusSuccess, "copy@10.0.0.1": StatusPending, "copy@10.0.0.2": StatusPending, "install": StatusPending, "verify": StatusPending, "other": StatusSuccess}
+	for _, s := range runData.Steps {
+		if s.Status != want[s.Name] {
+			t.Errorf("step %s: status %s, want %s", s.Name, s.Status, want[s.Name])
+		}
+	}
+	if runData.Steps[3].ChildTaskID != "" || runData.Steps[4].Error != "" {
+		t.Fatalf("rerun steps should drop previous results: %+v", runData.Steps)
+	}
+	if len(completed) != 2 || !completed["start"] || !completed["other"] {
+		t.Fatalf("unexpected completed set %v", completed)
+	}
+}
+
+func TestPrepareResume_KeepsAllowedFailures(t *testing.T) {
+	runData := rerunTestData()
+	runData.Steps[4].AllowFailure = true
+	runData.Steps[5].Status = StatusFailed
+	completed, err := prepareResume(t.TempDir(), runData, ResumeOptions{})
+	if err != nil {
+		t.Fatalf("prepareResume returned error: %v", err)
+	}
+	if !completed["verify"] || completed["other"] || len(completed) != 5 {
+		t.Fatalf("allowFailure steps should count as completed, got %v", completed)
+	}
+	if pending := unfinishedSteps(runData.Steps); len(pending) != 1 || pending[0] != "other" {
+		t.Fatalf("resume and the final status check should agree, got %v", pending)
+	}
+}
+
+func TestPrepareResume_OnlyRunsSingleStep(t *testing.T) {
+	runData := rerunTestData()
+	completed, err := prepareResume(t.TempDir(), runData, ResumeOptions{Only: "install"})
+	if err != nil {
+		t.Fatalf("prepareResume returned error: %v", err)
+	}
+	if runData.Steps[3].Status != StatusPending || runData.Steps[4].Status != StatusFailed {
+		t.Fatalf("only the selected step should be reset: %+v", runData.Steps)
+	}
+	if len(completed) != len(runData.Steps)-1 || completed["install"] {
+		t.Fatalf("unexpected completed set %v", completed)
+	}
+	if _, err := prepareResume(t.TempDir(), rerunTestData(), ResumeOptions{From: "a", Only: "b"}); err == nil {
+		t.Fatalf("expected error when both from and only are set")
+	}
+	if _, err := prepareResume(t.TempDir(), rerunTestData(), ResumeOptions{From: "missing"}); err == nil {
+		t.Fatalf("expected error for unknown step")
+	}
+}
+
+func TestSkipStep(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "k8s", "task")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runData := rerunTestData()
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SkipStep(arRoot, "task", "verify"); err != nil {
+		t.Fatalf("SkipStep returned error: %v", err)
+	}
+	saved, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Steps[4].Status != StatusSkipped || saved.Steps[4].Error != "" {
+		t.Fatalf("expected verify skipped, got %+v", saved.Steps[4])
+	}
+
+	runData.Steps[5].Status = StatusRunning
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SkipStep(arRoot, "task", "other"); err == nil {
+		t.Fatalf("expected error when skipping a running step")
+	}
+
+	// 任务仍由其他存活进程（此处借用父进程）执行时，即使步骤未运行也不能改写 pipeline.json
+	runData.Steps[5].Status = StatusPending
+	runData.Status = StatusRunning
+	runData.RunnerPID = os.Getppid()
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SkipStep(arRoot, "task", "other"); !errors.Is(err, errTaskRunningElsewhere) {
+		t.Fatalf("expected error when the task is executed by another process, got %v", err)
+	}
+
+	// task stop 已将任务记为 cancelled，但原执行进程仍未退出
+	runData.Status = StatusCancelled
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SkipStep(arRoot, "task", "other"); !errors.Is(err, errTaskRunningElsewhere) {
+		t.Fatalf("expected error while the stopped task's runner is still alive, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// cpuPeriod CPU 限制使用的 CFS 调度周期（微秒），quota = cpu * cpuPeriod。
+const cpuPeriod uint64 = 100000
+
+// StepResources 步骤容器的 cgroup 资源限制，映射到 OCI spec 的 linux.resources。各字段为空 / 0 表示不限制。
+type StepResources struct {
+	// CPU 可使用的 CPU 核数，如 "0.5"、"2"，也可写作 "500m"（千分之一核）
+	CPU string `json:"cpu,omitempty"`
+	// Memory 内存上限（同时禁止使用 swap），如 "512Mi"、"2G"；单位 K/M/G/T 按 1024 进制，可写作 Mi、MB 等形式，无单位时为字节
+	Memory string `json:"memory,omitempty"`
+	// Pids 容器内可同时存在的最大进程（线程）数
+	Pids int64 `json:"pids,omitempty"`
+}
+
+// validateStepResources 校验资源限制的格式，res 为 nil 时不做检查。
+func validateStepResources(res *StepResources) error {
+	if res == nil {
+		return nil
+	}
+	if _, err := parseCPU(res.CPU); err != nil {
+		return fmt.Errorf("cpu 无效: %w", err)
+	}
+	if _, err := parseMemory(res.Memory); err != nil {
+		return fmt.Errorf("memory 无效: %w", err)
+	}
+	if res.Pids < 0 {
+		return fmt.Errorf("pids 不能为负数: %d", res.Pids)
+	}
+	return nil
+}
+
+// effectiveStepResources 以步骤的 resources 为准，未设置的字段使用全局默认值；两者均未设置任何限制时返回 nil。
+func effectiveStepResources(step *StepResources, defaults StepResources) *StepResources {
+	res := defaults
+	if step != nil {
+		if strings.TrimSpace(step.CPU) != "" {
+			res.CPU = step.CPU
+		}
+		if strings.TrimSpace(step.Memory) != "" {
+			res.Memory = step.Memory
+		}
+		if step.Pids > 0 {
+			res.Pids = step.Pids
+		}
+	}
+	if strings.TrimSpace(res.CPU) == "" && strings.TrimSpace(res.Memory) == "" && res.Pids <= 0 {
+		return nil
+	}
+	return &res
+}
+
+// linuxResources 将资源限制转换为 OCI spec 的 linux.resources，res 为 nil 时返回 nil（不限制）。
+func linuxResources(res *StepResources) (*specs.LinuxResources, error) {
+	if res == nil {
+		return nil, nil
+	}
+	out := &specs.LinuxResources{}
+	cpu, err := parseCPU(res.CPU)
+	if err != nil {
+		return nil, fmt.Errorf("cpu 无效: %w", err)
+	}
+	if cpu > 0 {
+		quota := int64(math.Ceil(cpu * float64(cpuPeriod)))
+		period := cpuPeriod
+		out.CPU = &specs.LinuxCPU{Quota: &quota, Period: &period}
+	}
+	memory, err := parseMemory(res.Memory)
+	if err != nil {
+		return nil, fmt.Errorf("memory 无效: %w", err)
+	}
+	if memory > 0 {
+		// swap 上限（内存 + swap）与内存上限相同，即不允许使用 swap，超出时直接触发 OOM
+		swap := memory
+		out.Memory = &specs.LinuxMemory{Limit: &memory, Swap: &swap}
+	}
+	if res.Pids > 0 {
+		pids := res.Pids
+		out.Pids = &specs.LinuxPids{Limit: &pids}
+	}
+	return out, nil
+}
+
+// oomKilledError 返回步骤进程被 OOM killer 终止时的失败原因，与普通的非 0 退出码区分。
+func oomKilledError(stepName string, res *StepResources, exitCode int) error {
+	if res == nil || strings.TrimSpace(res.Memory) == "" {
+		return fmt.Errorf("步骤 %s 的进程被 OOM 终止（退出码 %d），宿主机内存不足", stepName, exitCode)
+	}
+	return fmt.Errorf("步骤 %s 超出内存限制（memory=%s），进程被 OOM 终止（退出码 %d）", stepName, res.Memory, exitCode)
+}
+
+// parseCPU 解析 CPU 核数，支持小数（"0.5"）与千分之一核（"500m"），空字符串返回 0。
+func parseCPU(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	num, scale := s, 1.0
+	if strings.HasSuffix(s, "m") {
+		num, scale = strings.TrimSuffix(s, "m"), 1000
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("无法解析: %s", s)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("必须大于 0: %s", s)
+	}
+	cpu := v / scale
+	// 低于 1ms/100ms 的 quota 会被内核拒绝
+	if cpu*float64(cpuPeriod) < 1000 {
+		return 0, fmt.Errorf("不能小于 0.01 核: %s", s)
+	}
+	return cpu, nil
+}
+
+// memoryUnits 内存单位（不区分大小写，去掉可选的 i / b 后缀）到字节数的倍数。
+var memoryUnits = map[string]int64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// parseMemory 解析内存大小（如 "512Mi"、"2G"、"1073741824"），空字符串返回 0。
+func parseMemory(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+	num, unit := s[:i], strings.ToLower(s[i:])
+	unit = strings.TrimSuffix(unit, "b")
+	unit = strings.TrimSuffix(unit, "i")
+	mult, ok := memoryUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("无法解析: %s（示例: 512Mi、2G）", s)
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("无法解析: %s（示例: 512Mi、2G）", s)
+	}
+	bytes := v * float64(mult)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("超出范围: %s", s)
+	}
+	// 过小的上限会使容器进程在启动阶段即被 OOM 终止
+	if bytes < 1<<20 {
+		return 0, fmt.Errorf("不能小于 1Mi: %s", s)
+	}
+	return int64(bytes), nil
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import "testing"
+
+func TestLinuxResources_MergesDefaultsIntoSpec(t *testing.T) {
+	res := effectiveStepResources(&StepResources{Memory: "512Mi"}, StepResources{CPU: "500m", Memory: "1G", Pids: 256})
+	if res == nil || res.CPU != "500m" || res.Memory != "512Mi" || res.Pids != 256 {
+		t.Fatalf("step resources should override defaults field by field, got %+v", res)
+	}
+	out, err := linuxResources(res)
+	if err != nil {
+		t.Fatalf("linuxResources returned error: %v", err)
+	}
+	if *out.CPU.Quota != 50000 || *out.CPU.Period != 100000 {
+		t.Fatalf("unexpected cpu quota %d/%d", *out.CPU.Quota, *out.CPU.Period)
+	}
+	if *out.Memory.Limit != 512<<20 || *out.Memory.Swap != 512<<20 {
+		t.Fatalf("unexpected memory limit %d swap %d", *out.Memory.Limit, *out.Memory.Swap)
+	}
+	if *out.Pids.Limit != 256 {
+		t.Fatalf("unexpected pids limit %d", *out.Pids.Limit)
+	}
+	if effectiveStepResources(nil, StepResources{}) != nil {
+		t.Fatalf("no limits should leave linux.resources unset")
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	cases := map[string]int64{"": 0, "2G": 2 << 30, "2g": 2 << 30, "256MiB": 256 << 20, "1.5Gi": 3 << 29, "1048576": 1 << 20}
+	for in, want := range cases {
+		if got, err := parseMemory(in); err != nil || got != want {
+			t.Errorf("parseMemory(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"abc", "10X", "-1G", "512"} {
+		if _, err := parseMemory(in); err == nil {
+			t.Errorf("parseMemory(%q) should fail", in)
+		}
+	}
+}
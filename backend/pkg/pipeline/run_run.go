@@ -8,46 +8,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/moby/sys/user"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 )
 
-// writeRuntimeSpecForRun 为流水线单步生成 OCI spec：挂载 /tasks、/current-task 与 /ar-data，进程参数来自 step。
-func writeRuntimeSpecForRun(bundleDir string, image v1.Image, tasksDir, currentTaskDir, hostDataDir string, step *PipelineStepState) error {
+// writeRuntimeSpecForRun 为流水线单步生成 OCI spec：挂载 /tasks、/current-task 与 /ar-data 以及步骤的额外挂载 extraMounts，
+// 进程参数、工作目录、用户与主机名来自 step。secrets 非空时写入 secretsDir 并只读挂载到 /run/secrets；
+// 写入 config.json 的进程参数与环境变量中的敏感值被掩码，返回含真实值的进程配置，由调用方在启动容器时使用。
+func writeRuntimeSpecForRun(bundleDir string, image v1.Image, tasksDir, currentTaskDir, hostDataDir string, step *PipelineStepState, extraMounts []specs.Mount, secrets map[string]string, secretsDir string) (*specs.Process, error) {
 	if err := os.MkdirAll(bundleDir, 0755); err != nil {
-		return fmt.Errorf("创建 bundle 目录失败: %w", err)
+		return nil, fmt.Errorf("创建 bundle 目录失败: %w", err)
 	}
 
 	cfg, err := image.ConfigFile()
 	if err != nil {
-		return fmt.Errorf("读取镜像配置失败: %w", err)
+		return nil, fmt.Errorf("读取镜像配置失败: %w", err)
 	}
 
-	args := make([]string, 0)
-	if strings.TrimSpace(step.Entrypoint) != "" {
-		args = append(args, step.Entrypoint)
-	} else {
-		args = append(args, cfg.Config.Entrypoint...)
+	args, env, cwd, err := stepProcess(&cfg.Config, step)
+	if err != nil {
+		return nil, err
 	}
-	args = append(args, step.Args...)
-	if len(args) == 0 {
-		args = append(args, cfg.Config.Cmd...)
+
+	rootfs := filepath.Join(bundleDir, "rootfs")
+	execUser, err := resolveStepUser(rootfs, step.User)
+	if err != nil {
+		return nil, fmt.Errorf("步骤 %s 的 user 无效: %w", step.Name, err)
 	}
-	if len(args) == 0 {
-		return fmt.Errorf("步骤 %s 缺少 entrypoint/args，且镜像无默认 cmd", step.Name)
+	if !containsEnvKey(env, "HOME") {
+		env = append(env, "HOME="+execUser.Home)
 	}
-
-	env := append([]string{}, step.Env...)
-	if !containsEnvKey(env, "PATH") {
-		env = append(env, "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin")
+	hostname := step.Hostname
+	if hostname == "" {
+		hostname = defaultStepHostname
 	}
 
-	cwd := cfg.Config.WorkingDir
-	if strings.TrimSpace(cwd) == "" {
-		cwd = "/"
+	resources, err := linuxResources(step.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("步骤 %s 的 resources %w", step.Name, err)
 	}
 
 	spec := specs.Spec{
@@ -57,13 +58,14 @@ func writeRuntimeSpecForRun(bundleDir string, image v1.Image, tasksDir, currentT
 			Args:            args,
 			Env:             env,
 			Cwd:             cwd,
+			User:            specs.User{UID: uint32(execUser.Uid), GID: uint32(execUser.Gid), AdditionalGids: additionalGids(execUser.Sgids)},
 			NoNewPrivileges: true,
 		},
 		Root: &specs.Root{
-			Path:     filepath.Join(bundleDir, "rootfs"),
+			Path:     rootfs,
 			Readonly: false,
 		},
-		Hostname: "ar-run",
+		Hostname: hostname,
 		Mounts: []specs.Mount{
 			{Destination: "/proc", Type: "proc", Source: "proc"},
 			{Destination: "/dev", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "strictatime", "mode=755", "size=65536k"}},
@@ -88,42 +90,95 @@ func writeRuntimeSpecForRun(bundleDir string, image v1.Image, tasksDir, currentT
 				"/proc/timer_list", "/proc/timer_stats", "/proc/sched_debug", "/sys/firmware", "/proc/scsi",
 			},
 			ReadonlyPaths: []string{"/proc/bus", "/proc/fs", "/proc/irq", "/proc/sys", "/proc/sysrq-trigger"},
+			// 资源限制由 specconv 转换为 libcontainer 的 cgroup 配置，防止失控的步骤耗尽控制主机的资源
+			Resources: resources,
 		},
 	}
 
+	spec.Mounts = append(spec.Mounts, extraMounts...)
+	if len(secrets) > 0 {
+		if err := writeStepSecretFiles(secretsDir, secrets, execUser.Uid, execUser.Gid); err != nil {
+			return nil, err
+		}
+		spec.Mounts = append(spec.Mounts, specs.Mount{Destination: stepSecretsTarget, Type: "bind", Source: secretsDir, Options: []string{"rbind", "ro"}})
+	}
+
+	// bundle 位于 /tasks 下，对其他步骤可见，config.json 中只保留掩码后的进程参数
+	process := *spec.Process
+	masked := secretValueList(secrets)
+	spec.Process.Args = maskSecretList(args, masked)
+	spec.Process.Env = maskEnv(env, masked)
+
 	specBytes, err := json.MarshalIndent(spec, "", "  ")
 	if err != nil {
-		return fmt.Errorf("序列化 OCI spec 失败: %w", err)
+		return nil, fmt.Errorf("序列化 OCI spec 失败: %w", err)
 	}
 	if err := os.WriteFile(filepath.Join(bundleDir, "config.json"), specBytes, 0644); err != nil {
-		return fmt.Errorf("写入 config.json 失败: %w", err)
+		return nil, fmt.Errorf("写入 config.json 失败: %w", err)
 	}
-	return nil
+	return &process, nil
+}
+
+// resolveStepUser 解析步骤的 user（uid[:gid] 或用户名[:组名]），用户名与组名从镜像 rootfs 的 /etc/passwd、/etc/group 查找；
+// user 为空时返回 root。
+func resolveStepUser(rootfs, userSpec string) (*user.ExecUser, error) {
+	defaults := &user.ExecUser{Uid: 0, Gid: 0, Home: "/root"}
+	if userSpec == "" {
+		return defaults, nil
+	}
+	return user.GetExecUserPath(userSpec, defaults, filepath.Join(rootfs, "etc", "passwd"), filepath.Join(rootfs, "etc", "group"))
+}
+
+func additionalGids(gids []int) []uint32 {
+	if len(gids) == 0 {
+		return nil
+	}
+	out := make([]uint32, 0, len(gids))
+	for _, g := range gids {
+		out = append(out, uint32(g))
+	}
+	return out
 }
 
 // RunStepResult 单步执行结果，供 RunPipeline 更新状态。
 type RunStepResult struct {
-	ExitCode int
-	Err      error
+	ExitCode  int   // 容器进程的真实退出码；容器未能运行时为 -1，被信号终止时为 128+信号值
+	OOMKilled bool  // 进程因超出内存限制被 OOM killer 终止
+	Err       error // 容器创建/运行失败或被取消；进程以非 0 退出码正常退出时为 nil
 }
 
-// RunStep 运行流水线中的单步：从 store 取镜像、解包、写 spec（/tasks、/current-task、/ar-data）、执行容器。
-func RunStep(ctx context.Context, runtimeRoot, imagesStoreDir, runDir, nodeDir, hostDataDir, containerID string, step *PipelineStepState) RunStepResult {
+// RunStep 运行流水线中的单步：从 store 取镜像、准备 rootfs（rootfsCacheDir 非空时使用按 digest 缓存的只读 rootfs + overlayfs）、
+// 写 spec（/tasks、/current-task、/ar-data 与步骤的额外挂载 mounts）、执行容器。
+// secrets 为任务的敏感值：以文件形式挂载到 /run/secrets，并在 config.json 与步骤日志中掩码。
+func RunStep(ctx context.Context, runtimeRoot, imagesStoreDir, rootfsCacheDir, runDir, nodeDir, hostDataDir, containerID string, step *PipelineStepState, mounts []specs.Mount, secrets map[string]string) RunStepResult {
 	img, err := OpenImageFromStore(imagesStoreDir, step.Image)
 	if err != nil {
 		return RunStepResult{ExitCode: -1, Err: err}
 	}
 
 	bundleDir := filepath.Join(runDir, "bundles", step.Name)
-	rootfsDir := filepath.Join(bundleDir, "rootfs")
-	if err := os.MkdirAll(rootfsDir, 0755); err != nil {
-		return RunStepResult{ExitCode: -1, Err: fmt.Errorf("创建 rootfs 目录失败: %w", err)}
+	// 重试时先清理上一次尝试残留的 bundle（debug 模式下不会自动删除），保证 rootfs 干净；残留的 overlayfs 先卸载，避免删除穿透到挂载内
+	if err := unmountRootfs(filepath.Join(bundleDir, "rootfs")); err != nil {
+		return RunStepResult{ExitCode: -1, Err: fmt.Errorf("卸载旧 rootfs 失败: %w", err)}
 	}
-
-	logrus.Infof("解包步骤镜像: %s -> %s", step.Name, rootfsDir)
-	if err := extractRootfsFromImage(img, rootfsDir); err != nil {
-		return RunStepResult{ExitCode: -1, Err: fmt.Errorf("解包步骤镜像失败: %w", err)}
+	if err := os.RemoveAll(bundleDir); err != nil {
+		return RunStepResult{ExitCode: -1, Err: fmt.Errorf("清理旧 bundle 目录失败: %w", err)}
 	}
+	rootfs, err := prepareStepRootfs(rootfsCacheDir, img, bundleDir, containerID)
+	if err != nil {
+		return RunStepResult{ExitCode: -1, Err: err}
+	}
+	defer func() {
+		rootfs.release()
+		// 非 debug 模式下，步骤完成后及时删除 bundle 目录（含 overlay upper 层）以释放磁盘空间
+		if !logrus.IsLevelEnabled(logrus.DebugLevel) {
+			if removeErr := os.RemoveAll(bundleDir); removeErr != nil {
+				logrus.Warnf("清理 bundle 目录失败 %s: %v", bundleDir, removeErr)
+			} else {
+				logrus.Infof("已清理步骤 bundle 目录: %s", bundleDir)
+			}
+		}
+	}()
 	// 使用绝对路径作为 bind mount 源，避免 runc 相对 bundle 解析导致挂载到错误目录
 	tasksDirAbs, err := filepath.Abs(runDir)
 	if err != nil {
@@ -140,7 +195,9 @@ func RunStep(ctx context.Context, runtimeRoot, imagesStoreDir, runDir, nodeDir,
 	if err != nil {
 		return RunStepResult{ExitCode: -1, Err: fmt.Errorf("解析宿主机数据目录绝对路径失败: %w", err)}
 	}
-	if err := writeRuntimeSpecForRun(bundleDir, img, tasksDirAbs, nodeDirAbs, hostDataDirAbs, step); err != nil {
+	defer removeStepSecretFiles(containerID)
+	process, err := writeRuntimeSpecForRun(bundleDir, img, tasksDirAbs, nodeDirAbs, hostDataDirAbs, step, mounts, secrets, stepSecretsDir(containerID))
+	if err != nil {
 		return RunStepResult{ExitCode: -1, Err: err}
 	}
 
@@ -164,18 +221,13 @@ func RunStep(ctx context.Context, runtimeRoot, imagesStoreDir, runDir, nodeDir,
 	}
 	defer stderrFile.Close()
 
-	err = runOneShotContainer(ctx, runtimeRoot, bundleDir, containerID, stdoutFile, stderrFile)
-	// 非 debug 模式下，步骤完成后及时删除 bundle 目录以释放磁盘空间
-	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
-		if removeErr := os.RemoveAll(bundleDir); removeErr != nil {
-			logrus.Warnf("清理 bundle 目录失败 %s: %v", bundleDir, removeErr)
-		} else {
-			logrus.Infof("已清理步骤 bundle 目录: %s", bundleDir)
-		}
-	}
-	if err != nil {
-		// runOneShotContainer 在非 0 退出时返回 error，可解析或约定 ExitCode
-		return RunStepResult{ExitCode: 1, Err: err}
-	}
-	return RunStepResult{ExitCode: 0}
+	// 步骤输出中出现的敏感值在写入日志前掩码
+	stdout := newSecretMaskWriter(stdoutFile, secretValueList(secrets))
+	defer stdout.Flush()
+	stderr := newSecretMaskWriter(stderrFile, secretValueList(secrets))
+	defer stderr.Flush()
+
+	exitCode, oomKilled, err := runOneShotContainer(ctx, runtimeRoot, bundleDir, containerID, process, stdout, stderr)
+	// 退出码原样返回，是否视为成功由调用方按步骤的 successExitCodes 判断
+	return RunStepResult{ExitCode: exitCode, OOMKilled: oomKilled, Err: err}
 }
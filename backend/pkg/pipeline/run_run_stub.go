@@ -5,17 +5,22 @@ package pipeline
 import (
 	"context"
 	"errors"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // RunStep 在非 Linux 上为未实现，保证编译通过。
-func RunStep(ctx context.Context, runtimeRoot, imagesStoreDir, runDir, nodeDir, hostDataDir, containerID string, step *PipelineStepState) RunStepResult {
+func RunStep(ctx context.Context, runtimeRoot, imagesStoreDir, rootfsCacheDir, runDir, nodeDir, hostDataDir, containerID string, step *PipelineStepState, mounts []specs.Mount, secrets map[string]string) RunStepResult {
 	_ = ctx
 	_ = runtimeRoot
 	_ = imagesStoreDir
+	_ = rootfsCacheDir
 	_ = runDir
 	_ = nodeDir
 	_ = hostDataDir
 	_ = containerID
 	_ = step
+	_ = mounts
+	_ = secrets
 	return RunStepResult{ExitCode: -1, Err: errors.New("pipeline run 仅在 Linux 上支持")}
 }
@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stepExecFunc 执行单个步骤，返回非 nil 表示该步骤失败。
+type stepExecFunc func(ctx context.Context, step PipelineStepState) error
+
+// dagScheduler 按 pipeline.json 中 nodes 边构成的 DAG 调度步骤：
+// 某步骤的全部前驱成功后立即启动，不再按层级等待同层其他步骤完成。
+// 同时运行的步骤数受 slots（全局/流水线级上限）与 groupSlots（步骤分组上限）约束，超出时步骤进入 queued 等待槽位。
+type dagScheduler struct {
+	steps       []PipelineStepState
+	nameToIndex map[string]int
+	slots       chan struct{}            // nil 表示不限制
+	groupSlots  map[string]chan struct{} // 分组名 -> 槽位
+	// setStatus 在步骤进入 queued（依赖已满足但暂无可用槽位）或因流水线中止退回 pending 时调用，用于持久化状态
+	setStatus func(step PipelineStepState, status string)
+	// finishIndependentBranches 为 true 时步骤失败只阻断其下游（下游的前驱计数不会归零），其余分支继续调度；
+	// 为 false（failFast）时任一步骤失败即不再启动新步骤
+	finishIndependentBranches bool
+	// skip 在步骤依赖满足后、获取槽位前调用，返回 true 表示跳过该步骤（视为已满足，后继照常调度）；
+	// 返回错误时按步骤失败处理。为 nil 时不跳过任何步骤
+	skip func(step PipelineStepState) (bool, error)
+	// gate 在步骤获取槽位后、启动前调用，阻塞直到允许启动（任务暂停期间不启动新步骤）；返回错误（ctx 结束）时步骤放弃执行。
+	// 为 nil 时不等待
+	gate func(ctx context.Context) error
+}
+
+// errStepAbandoned 表示步骤在等待槽位期间流水线已中止，步骤未被执行。
+var errStepAbandoned = errors.New("步骤未执行：流水线已中止")
+
+// newDAGScheduler 校验 DAG（无环、无未知节点引用）并构造调度器。maxParallel 为 0 表示不限制全局并发。
+func newDAGScheduler(steps []PipelineStepState, maxParallel int) (*dagScheduler, error) {
+	if _, err := StepsToLevels(steps); err != nil {
+		return nil, err
+	}
+	nameToIndex := make(map[string]int, len(steps))
+	for i := range steps {
+		nameToIndex[steps[i].Name] = i
+	}
+	s := &dagScheduler{steps: steps, nameToIndex: nameToIndex, groupSlots: make(map[string]chan struct{})}
+	if maxParallel > 0 {
+		s.slots = make(chan struct{}, maxParallel)
+	}
+	// 同组步骤取最小的非 0 maxParallel 作为分组上限
+	groupLimits := make(map[string]int)
+	for _, step := range steps {
+		if step.MaxParallel <= 0 {
+			continue
+		}
+		group := parallelGroupOf(step)
+		if limit, ok := groupLimits[group]; !ok || step.MaxParallel < limit {
+			groupLimits[group] = step.MaxParallel
+		}
+	}
+	for group, limit := range groupLimits {
+		s.groupSlots[group] = make(chan struct{}, limit)
+	}
+	return s, nil
+}
+
+// parallelGroupOf 返回步骤所属的并发分组，未设置时使用步骤名。
+func parallelGroupOf(step PipelineStepState) string {
+	if step.ParallelGroup != "" {
+		return step.ParallelGroup
+	}
+	return step.Name
+}
+
+// effectiveMaxParallel 合并全局与流水线级并发上限：取两者中较小的非 0 值，均为 0 时返回 0（不限制）。
+func effectiveMaxParallel(global, pipeline int) int {
+	if global <= 0 {
+		return pipeline
+	}
+	if pipeline <= 0 || global < pipeline {
+		return global
+	}
+	return pipeline
+}
+
+// acquire 为步骤获取全局与分组槽位；无法立即获取时先将状态置为 queued 再阻塞等待。
+// ctx 取消时返回错误，已获取的槽位会被释放。
+func (s *dagScheduler) acquire(ctx context.Context, step PipelineStepState) error {
+	sems := make([]chan struct{}, 0, 2)
+	if sem, ok := s.groupSlots[parallelGroupOf(step)]; ok {
+		sems = append(sems, sem)
+	}
+	if s.slots != nil {
+		sems = append(sems, s.slots)
+	}
+	queued := false
+	for i, sem := range sems {
+		select {
+		case sem <- struct{}{}:
+			continue
+		default:
+		}
+		if !queued {
+			queued = true
+			if s.setStatus != nil {
+				s.setStatus(step, StatusQueued)
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for _, held := range sems[:i] {
+				<-held
+			}
+			if queued && s.setStatus != nil {
+				s.setStatus(step, StatusPending)
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// release 释放步骤占用的全局与分组槽位。
+func (s *dagScheduler) release(step PipelineStepState) {
+	if sem, ok := s.groupSlots[parallelGroupOf(step)]; ok {
+		<-sem
+	}
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// run 调度执行所有未完成的步骤。completed 中为 true 的步骤视为已成功（用于恢复执行），不会再次运行。
+// 任一步骤失败（failFast）或 ctx 被取消后不再启动新步骤，等待已启动的步骤结束后汇总错误返回；
+// finishIndependentBranches 模式下失败步骤的下游不再启动，其余分支执行完毕后再汇总错误返回。
+func (s *dagScheduler) run(ctx context.Context, completed map[string]bool, exec stepExecFunc) error {
+	// 剩余未完成的前驱数量
+	remaining := make(map[string]int, len(s.steps))
+	for _, step := range s.steps {
+		if completed[step.Name] {
+			continue
+		}
+		for _, next := range step.Nodes {
+			remaining[next]++
+		}
+	}
+
+	type stepResult struct {
+		step PipelineStepState
+		err  error
+	}
+	results := make(chan stepResult)
+	// abortCtx 在首个步骤失败时取消，使仍在等待槽位的步骤放弃执行；已运行的步骤仍使用 ctx
+	abortCtx, abort := context.WithCancel(ctx)
+	defer abort()
+	started := make(map[string]bool, len(s.steps))
+	running := 0
+	finished := 0
+	toFinish := 0
+	for _, step := range s.steps {
+		if !completed[step.Name] {
+			toFinish++
+		}
+	}
+
+	launch := func(candidates []PipelineStepState) {
+		ready := make([]PipelineStepState, 0, len(candidates))
+		for _, step := range candidates {
+			if completed[step.Name] || started[step.Name] || remaining[step.Name] > 0 {
+				continue
+			}
+			ready = append(ready, step)
+		}
+		if len(ready) == 0 {
+			return
+		}
+		logrus.Infof("启动 %d 个就绪步骤: %v", len(ready), stepNames(ready))
+		for _, step := range ready {
+			started[step.Name] = true
+			running++
+			go func(st PipelineStepState) {
+				if s.skip != nil {
+					skipped, err := s.skip(st)
+					if err != nil || skipped {
+						results <- stepResult{step: st, err: err}
+						return
+					}
+				}
+				if err := s.acquire(abortCtx, st); err != nil {
+					results <- stepResult{step: st, err: errStepAbandoned}
+					return
+				}
+				if s.gate != nil {
+					if err := s.gate(abortCtx); err != nil {
+						s.release(st)
+						results <- stepResult{step: st, err: errStepAbandoned}
+						return
+					}
+				}
+				err := exec(ctx, st)
+				s.release(st)
+				results <- stepResult{step: st, err: err}
+			}(step)
+		}
+	}
+
+	var errs []error
+	if ctx.Err() == nil {
+		launch(s.steps)
+	}
+	for running > 0 {
+		res := <-results
+		running--
+		if errors.Is(res.err, errStepAbandoned) {
+			continue
+		}
+		if res.err != nil {
+			errs = append(errs, res.err)
+			if !s.finishIndependentBranches {
+				abort()
+			}
+			continue
+		}
+		finished++
+		successors := make([]PipelineStepState, 0, len(res.step.Nodes))
+		for _, next := range res.step.Nodes {
+			remaining[next]--
+			successors = append(successors, s.steps[s.nameToIndex[next]])
+		}
+		if (len(errs) == 0 || s.finishIndependentBranches) && ctx.Err() == nil {
+			launch(successors)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if finished < toFinish {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("流水线仍有 %d 个步骤未能调度执行", toFinish-finished)
+	}
+	return nil
+}
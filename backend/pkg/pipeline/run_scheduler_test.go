@@ -0,0 +1,268 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDAGScheduler_StartsStepWhenOwnPredecessorsSucceed(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "start", Nodes: []string{"copy-0", "copy-1"}},
+		{Name: "copy-0", Nodes: []string{"firewall-0"}},
+		{Name: "copy-1", Nodes: []string{"firewall-1"}},
+		{Name: "firewall-0"},
+		{Name: "firewall-1"},
+	}
+	scheduler, err := newDAGScheduler(steps, 0)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+
+	releaseSlow := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	err = scheduler.run(context.Background(), nil, func(ctx context.Context, step PipelineStepState) error {
+		switch step.Name {
+		case "copy-1":
+			<-releaseSlow
+		case "firewall-0":
+			// firewall-0 只依赖 copy-0，应在慢步骤 copy-1 结束前启动
+			close(releaseSlow)
+		}
+		mu.Lock()
+		order = append(order, step.Name)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if len(order) != len(steps) {
+		t.Fatalf("expected %d steps executed, got %v", len(steps), order)
+	}
+}
+
+func TestDAGScheduler_FailureStopsSuccessors(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "a", Nodes: []string{"b"}},
+		{Name: "b", Nodes: []string{"c"}},
+		{Name: "c"},
+		{Name: "other"},
+	}
+	scheduler, err := newDAGScheduler(steps, 0)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+
+	var mu sync.Mutex
+	executed := map[string]bool{}
+	err = scheduler.run(context.Background(), nil, func(ctx context.Context, step PipelineStepState) error {
+		mu.Lock()
+		executed[step.Name] = true
+		mu.Unlock()
+		if step.Name == "b" {
+			return errors.New("boom")
+		}
+		if step.Name == "other" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("expected error from failed step")
+	}
+	if executed["c"] {
+		t.Fatalf("successor of failed step should not run")
+	}
+	if !executed["other"] {
+		t.Fatalf("independent step already started should run to completion")
+	}
+}
+
+func TestDAGScheduler_SkipsCompletedSteps(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "a", Nodes: []string{"b"}},
+		{Name: "b", Nodes: []string{"c"}},
+		{Name: "c"},
+	}
+	scheduler, err := newDAGScheduler(steps, 0)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+
+	var executed []string
+	err = scheduler.run(context.Background(), map[string]bool{"a": true}, func(ctx context.Context, step PipelineStepState) error {
+		executed = append(executed, step.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if len(executed) != 2 || executed[0] != "b" || executed[1] != "c" {
+		t.Fatalf("expected [b c], got %v", executed)
+	}
+}
+
+func TestNewDAGScheduler_RejectsCycle(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "a", Nodes: []string{"b"}},
+		{Name: "b", Nodes: []string{"a"}},
+	}
+	if _, err := newDAGScheduler(steps, 0); err == nil {
+		t.Fatalf("expected error for cyclic DAG")
+	}
+}
+
+func TestDAGScheduler_RespectsMaxParallelAndMarksQueued(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "n0"}, {Name: "n1"}, {Name: "n2"}, {Name: "n3"},
+		{Name: "g0", StepOptions: StepOptions{ParallelGroup: "g", MaxParallel: 1}},
+		{Name: "g1", StepOptions: StepOptions{ParallelGroup: "g", MaxParallel: 1}},
+	}
+	scheduler, err := newDAGScheduler(steps, 2)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+	var mu sync.Mutex
+	queued := map[string]bool{}
+	scheduler.setStatus = func(step PipelineStepState, status string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if status == StatusQueued {
+			queued[step.Name] = true
+		}
+	}
+
+	running, maxRunning, groupRunning := 0, 0, 0
+	err = scheduler.run(context.Background(), nil, func(ctx context.Context, step PipelineStepState) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		if step.ParallelGroup == "g" {
+			groupRunning++
+			if groupRunning > 1 {
+				mu.Unlock()
+				t.Errorf("parallel group g exceeded its limit")
+				return nil
+			}
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		if step.ParallelGroup == "g" {
+			groupRunning--
+		}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if maxRunning > 2 {
+		t.Fatalf("expected at most 2 steps running, got %d", maxRunning)
+	}
+	if len(queued) == 0 {
+		t.Fatalf("expected some steps to be marked queued")
+	}
+}
+
+func TestEffectiveMaxParallel(t *testing.T) {
+	cases := []struct{ global, pipeline, want int }{
+		{0, 0, 0},
+		{4, 0, 4},
+		{0, 3, 3},
+		{4, 3, 3},
+		{2, 3, 2},
+	}
+	for _, c := range cases {
+		if got := effectiveMaxParallel(c.global, c.pipeline); got != c.want {
+			t.Errorf("effectiveMaxParallel(%d, %d) = %d, want %d", c.global, c.pipeline, got, c.want)
+		}
+	}
+}
+
+func TestDAGScheduler_FinishIndependentBranches(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "start", Nodes: []string{"a-0", "b-0"}},
+		{Name: "a-0", Nodes: []string{"a-1"}},
+		{Name: "a-1"},
+		{Name: "b-0", Nodes: []string{"b-1"}},
+		{Name: "b-1"},
+	}
+	scheduler, err := newDAGScheduler(steps, 0)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+	scheduler.finishIndependentBranches = true
+
+	var mu sync.Mutex
+	executed := map[string]bool{}
+	err = scheduler.run(context.Background(), nil, func(ctx context.Context, step PipelineStepState) error {
+		mu.Lock()
+		executed[step.Name] = true
+		mu.Unlock()
+		if step.Name == "a-0" {
+			return errors.New("boom")
+		}
+		if step.Name == "b-0" {
+			// 确保 a-0 先失败，再验证 b 分支仍被继续调度
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("expected error from failed step")
+	}
+	if executed["a-1"] {
+		t.Fatalf("successor of failed step should not run")
+	}
+	if !executed["b-1"] {
+		t.Fatalf("independent branch should run to completion")
+	}
+}
+
+func TestDAGScheduler_ForEachInstancesShareMaxParallel(t *testing.T) {
+	nodes := []RunNode{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}, {IP: "10.0.0.3"}}
+	expanded, err := expandForEach([]TemplateStep{{Name: "copy", ForEach: "*", StepOptions: StepOptions{MaxParallel: 1}}}, nodes)
+	if err != nil {
+		t.Fatalf("expandForEach returned error: %v", err)
+	}
+	runData := BuildRunData("task", "k8s", expanded, nodes)
+	if len(runData.Steps) != 3 {
+		t.Fatalf("expected 3 instances, got %d", len(runData.Steps))
+	}
+	scheduler, err := newDAGScheduler(runData.Steps, 0)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	err = scheduler.run(context.Background(), nil, func(ctx context.Context, step PipelineStepState) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if maxRunning != 1 {
+		t.Fatalf("forEach instances should share maxParallel 1, got %d running at once", maxRunning)
+	}
+}
@@ -0,0 +1,459 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 敏感值：节点登录密码与以 {"secret": "..."} 形式传入的参数。渲染后的步骤在内存中保留真实值，写入 pipeline.json、
+// 步骤 bundle 的 config.json 与步骤日志时替换为 ******；被掩码字段的真实值以密钥存储的加密密钥加密后另存于
+// arRoot/task-secrets/<taskId>.json（0600，不挂载到步骤容器），恢复执行时据此还原，任务成功结束后删除。
+// 步骤启动时真实值只在内存中注入容器进程，并以文件形式挂载到 /run/secrets。
+
+// stepSecretsTarget 步骤容器内敏感值文件的挂载点，每个敏感值一个文件，文件名见 taskSecretValues。
+const stepSecretsTarget = "/run/secrets"
+
+// stepSecretsRoot 步骤敏感值文件在宿主机上的目录（tmpfs，不落盘），每个容器一个子目录，步骤结束后删除。
+var stepSecretsRoot = "/dev/shm/ar-secrets"
+
+var secretNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// secretArgValue 判断参数值是否为标记为敏感的参数（{"secret": "..."}），是则返回其真实值。
+func secretArgValue(v interface{}) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	s, ok := m["secret"].(string)
+	return s, ok
+}
+
+// unwrapSecretArgs 返回将敏感参数替换为真实值后的参数副本，供模板渲染与 when 求值使用；args 中的标记保持不变，
+// 以便子流水线继承参数时仍能识别敏感值。
+func unwrapSecretArgs(args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if s, ok := secretArgValue(v); ok {
+			v = s
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// taskSecretValues 返回任务的敏感值（名称 -> 值），名称即 /run/secrets 下的文件名：
+// 节点密码为 node-<ip>-password，敏感参数为 arg-<key>（不允许的字符替换为 _）。
+func taskSecretValues(nodes []RunNode, args map[string]interface{}) map[string]string {
+	secrets := make(map[string]string)
+	for _, n := range nodes {
+		// 以密钥引用给出的密码在步骤启动前从密钥存储解析，见 resolveStepSecrets
+		if n.Password != "" && n.Password != secretMask && !isSecretRef(n.Password) {
+			secrets[secretFileName("node-"+n.IP+"-password")] = n.Password
+		}
+	}
+	for k, v := range args {
+		if s, ok := secretArgValue(v); ok && s != "" && s != secretMask {
+			secrets[secretFileName("arg-"+k)] = s
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	return secrets
+}
+
+func secretFileName(name string) string {
+	return secretNameInvalidChars.ReplaceAllString(name, "_")
+}
+
+// secretValueList 返回用于掩码的敏感值列表，按长度从长到短排列，避免较短的值先替换后残留较长值的片段。
+func secretValueList(secrets map[string]string) []string {
+	if len(secrets) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(secrets))
+	for _, v := range secrets {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// maskCommand 掩码步骤命令中的敏感值，环境变量名含敏感关键字时整体掩码。
+func maskCommand(cmd StepCommand, secrets []string) StepCommand {
+	return StepCommand{
+		Entrypoint: maskSecrets(cmd.Entrypoint, secrets),
+		Args:       maskSecretList(cmd.Args, secrets),
+		Env:        maskEnv(cmd.Env, secrets),
+	}
+}
+
+func stepCommandOf(s PipelineStepState) StepCommand {
+	return StepCommand{Entrypoint: s.Entrypoint, Args: s.Args, Env: s.Env}
+}
+
+// maskedRunData 返回写入 pipeline.json 的副本：节点密码、敏感参数以及步骤命令与错误信息中出现的敏感值替换为 ******。
+// runData 本身保持不变。
+func maskedRunData(runData *PipelineRunData) *PipelineRunData {
+	secrets := secretValueList(runData.secrets)
+	masked := *runData
+	if runData.Args != nil {
+		masked.Args = make(map[string]interface{}, len(runData.Args))
+		for k, v := range runData.Args {
+			if _, ok := secretArgValue(v); ok {
+				v = map[string]interface{}{"secret": secretMask}
+			}
+			masked.Args[k] = v
+		}
+	}
+	if runData.RunNodes != nil {
+		masked.RunNodes = make([]RunNode, len(runData.RunNodes))
+		for i, n := range runData.RunNodes {
+			if n.Password != "" && !isSecretRef(n.Password) {
+				n.Password = secretMask
+			}
+			masked.RunNodes[i] = n
+		}
+	}
+	masked.Steps = maskStepStates(runData.Steps, secrets)
+	masked.OnSuccess = maskStepStates(runData.OnSuccess, secrets)
+	masked.OnFailure = maskStepStates(runData.OnFailure, secrets)
+	masked.Always = maskStepStates(runData.Always, secrets)
+	return &masked
+}
+
+func maskStepStates(steps []PipelineStepState, secrets []string) []PipelineStepState {
+	if steps == nil {
+		return nil
+	}
+	out := make([]PipelineStepState, len(steps))
+	for i, s := range steps {
+		cmd := maskCommand(stepCommandOf(s), secrets)
+		s.Entrypoint, s.Args, s.Env = cmd.Entrypoint, cmd.Args, cmd.Env
+		if s.Unrendered != nil {
+			unrendered := maskCommand(*s.Unrendered, secrets)
+			s.Unrendered = &unrendered
+		}
+		s.Error = maskSecrets(s.Error, secrets)
+		if len(s.Attempts) > 0 {
+			attempts := make([]StepAttempt, len(s.Attempts))
+			for j, a := range s.Attempts {
+				a.Error = maskSecrets(a.Error, secrets)
+				attempts[j] = a
+			}
+			s.Attempts = attempts
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// taskSecrets 任务中被掩码字段的真实值，保存在 arRoot/task-secrets/<taskId>.json。
+type taskSecrets struct {
+	Args     map[string]interface{} `json:"args,omitempty"`
+	RunNodes []RunNode              `json:"runNodes,omitempty"`
+	// Steps 命令含敏感值的步骤（键为 <分组>/<步骤名>，分组为 main 或钩子类型）渲染后的命令
+	Steps map[string]secretStepCommand `json:"steps,omitempty"`
+}
+
+type secretStepCommand struct {
+	Command    StepCommand  `json:"command"`
+	Unrendered *StepCommand `json:"unrendered,omitempty"`
+}
+
+// TaskSecretsPath 返回任务敏感值文件的路径。
+func TaskSecretsPath(arRoot, taskID string) string {
+	return filepath.Join(arRoot, "task-secrets", taskID+".json")
+}
+
+// taskSecretsAAD 任务敏感值文件加密时的附加数据，文件被改名（挪给其他任务）后无法解密。
+func taskSecretsAAD(taskID string) string {
+	return "task-secrets/" + taskID
+}
+
+// saveTaskSecrets 加密保存 runData 中写入 pipeline.json 时会被掩码的字段的真实值；没有任何字段被掩码时不写文件（并删除旧文件）。
+func saveTaskSecrets(arRoot string, store *SecretStore, runData *PipelineRunData) error {
+	secrets := secretValueList(runData.secrets)
+	plain := taskSecrets{Steps: make(map[string]secretStepCommand)}
+	for _, group := range runData.stepGroups() {
+		for _, s := range group.steps {
+			cmd := stepCommandOf(s)
+			changed := !jsonEqual(cmd, maskCommand(cmd, secrets))
+			entry := secretStepCommand{Command: cmd}
+			if s.Unrendered != nil && !jsonEqual(*s.Unrendered, maskCommand(*s.Unrendered, secrets)) {
+				unrendered := *s.Unrendered
+				entry.Unrendered = &unrendered
+				changed = true
+			}
+			if changed {
+				plain.Steps[group.kind+"/"+s.Name] = entry
+			}
+		}
+	}
+	path := TaskSecretsPath(arRoot, runData.TaskID)
+	if len(runData.secrets) == 0 && len(plain.Steps) == 0 {
+		return removeTaskSecrets(arRoot, runData.TaskID)
+	}
+	plain.Args = runData.Args
+	plain.RunNodes = runData.RunNodes
+	data, err := json.Marshal(plain)
+	if err != nil {
+		return fmt.Errorf("序列化任务敏感值失败: %w", err)
+	}
+	record, err := store.seal(taskSecretsAAD(runData.TaskID), data)
+	if err != nil {
+		return fmt.Errorf("加密任务敏感值失败: %w", err)
+	}
+	record.Name, record.UpdatedAt = runData.TaskID, time.Now()
+	if data, err = json.Marshal(record); err != nil {
+		return fmt.Errorf("序列化任务敏感值失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建任务敏感值目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("写入任务敏感值文件失败 %s: %w", path, err)
+	}
+	return nil
+}
+
+// removeTaskSecrets 删除任务敏感值文件，不存在时忽略。
+func removeTaskSecrets(arRoot, taskID string) error {
+	path := TaskSecretsPath(arRoot, taskID)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除任务敏感值文件失败 %s: %w", path, err)
+	}
+	return nil
+}
+
+// readTaskSecrets 读取并解密任务敏感值文件，文件不存在时返回 nil。兼容未加密的旧文件，下次保存时改为加密。
+func readTaskSecrets(arRoot string, store *SecretStore, taskID string) (*taskSecrets, error) {
+	path := TaskSecretsPath(arRoot, taskID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取任务敏感值文件失败 %s: %w", path, err)
+	}
+	var record secretRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("解析任务敏感值文件失败 %s: %w", path, err)
+	}
+	if len(record.Nonce) > 0 {
+		if data, err = store.open(taskSecretsAAD(taskID), record); err != nil {
+			return nil, fmt.Errorf("解密任务敏感值文件失败 %s: %w", path, err)
+		}
+	}
+	var plain taskSecrets
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, fmt.Errorf("解析任务敏感值文件失败 %s: %w", path, err)
+	}
+	return &plain, nil
+}
+
+// restoreTaskSecrets 用任务敏感值文件还原从 pipeline.json 读取的 runData（恢复执行时调用），文件不存在时
+// 沿用 pipeline.json 中的值（旧版本创建的任务），并补写敏感值文件，此后 pipeline.json 中的敏感值同样被掩码。
+// 文件已随任务成功结束删除而 pipeline.json 中只有掩码时返回错误，不以掩码后的值执行步骤。
+func restoreTaskSecrets(arRoot string, store *SecretStore, runData *PipelineRunData) error {
+	plain, err := readTaskSecrets(arRoot, store, runData.TaskID)
+	if err != nil {
+		return err
+	}
+	if plain == nil && hasMaskedSecrets(runData) {
+		return fmt.Errorf("任务 %s 的敏感值（节点密码、敏感参数）已在任务成功结束后清除，无法重新执行，请重新运行流水线", runData.TaskID)
+	}
+	if plain != nil {
+		if plain.Args != nil {
+			runData.Args = plain.Args
+		}
+		if plain.RunNodes != nil {
+			runData.RunNodes = plain.RunNodes
+		}
+		for _, group := range runData.stepGroups() {
+			for i := range group.steps {
+				entry, ok := plain.Steps[group.kind+"/"+group.steps[i].Name]
+				if !ok {
+					continue
+				}
+				s := &group.steps[i]
+				s.Entrypoint, s.Args, s.Env = entry.Command.Entrypoint, entry.Command.Args, entry.Command.Env
+				if entry.Unrendered != nil {
+					s.Unrendered = entry.Unrendered
+				}
+			}
+		}
+	}
+	return enableSecretMasking(arRoot, store, runData)
+}
+
+// hasMaskedSecrets 判断从 pipeline.json 读取的 runData 中节点密码或敏感参数是否为掩码。
+func hasMaskedSecrets(runData *PipelineRunData) bool {
+	for _, n := range runData.RunNodes {
+		if n.Password == secretMask {
+			return true
+		}
+	}
+	for _, v := range runData.Args {
+		if s, ok := secretArgValue(v); ok && s == secretMask {
+			return true
+		}
+	}
+	return false
+}
+
+// enableSecretMasking 根据 runData 的节点与参数计算任务敏感值，开启写入 pipeline.json 时的掩码，并加密保存敏感值文件。
+// 新建任务在首次写入 pipeline.json 前调用。
+func enableSecretMasking(arRoot string, store *SecretStore, runData *PipelineRunData) error {
+	runData.secrets = taskSecretValues(runData.RunNodes, runData.Args)
+	runData.maskOnWrite = true
+	return saveTaskSecrets(arRoot, store, runData)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+// stepSecretsDir 返回步骤容器的敏感值文件目录。
+func stepSecretsDir(containerID string) string {
+	return filepath.Join(stepSecretsRoot, containerID)
+}
+
+// writeStepSecretFiles 在 dir 下为每个敏感值写入一个文件，目录与文件属主为步骤进程的用户且仅其可读
+// （非 root 执行时无法修改属主，保持当前用户）。
+func writeStepSecretFiles(dir string, secrets map[string]string, uid, gid int) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("清理旧的敏感值目录失败 %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("创建敏感值目录失败 %s: %w", dir, err)
+	}
+	chown := func(path string) error {
+		if os.Geteuid() != 0 {
+			return nil
+		}
+		return os.Chown(path, uid, gid)
+	}
+	for name, value := range secrets {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(value), 0400); err != nil {
+			return fmt.Errorf("写入敏感值文件失败 %s: %w", path, err)
+		}
+		if err := chown(path); err != nil {
+			return fmt.Errorf("设置敏感值文件属主失败 %s: %w", path, err)
+		}
+	}
+	if err := chown(dir); err != nil {
+		return fmt.Errorf("设置敏感值目录属主失败 %s: %w", dir, err)
+	}
+	return nil
+}
+
+// removeStepSecretFiles 删除步骤容器的敏感值文件目录（步骤结束或对账清理时调用），不存在时忽略。
+func removeStepSecretFiles(containerID string) {
+	if containerID == "" {
+		return
+	}
+	dir := stepSecretsDir(containerID)
+	if err := os.RemoveAll(dir); err != nil {
+		logrus.Warnf("删除步骤敏感值目录失败 %s: %v", dir, err)
+	}
+}
+
+// secretMaskWriter 按行掩码写入的敏感值后再写入 w，用于步骤日志；不足一行的内容缓存到换行或 Flush 时写出。
+type secretMaskWriter struct {
+	w       io.Writer
+	secrets []string
+	buf     []byte
+}
+
+// maxMaskLineSize 单行超过该长度仍未换行时直接掩码写出，避免无换行的输出长时间滞留在内存中。
+const maxMaskLineSize = 64 << 10
+
+func newSecretMaskWriter(w io.Writer, secrets []string) *secretMaskWriter {
+	return &secretMaskWriter{w: w, secrets: secrets}
+}
+
+func (m *secretMaskWriter) Write(p []byte) (int, error) {
+	m.buf = append(m.buf, p...)
+	end := bytes.LastIndexByte(m.buf, '\n') + 1
+	if end == 0 && len(m.buf) >= maxMaskLineSize {
+		end = len(m.buf)
+	}
+	if end > 0 {
+		if _, err := io.WriteString(m.w, maskSecrets(string(m.buf[:end]), m.secrets)); err != nil {
+			return 0, err
+		}
+		m.buf = append(m.buf[:0], m.buf[end:]...)
+	}
+	return len(p), nil
+}
+
+// Flush 写出缓存中不足一行的剩余内容。
+func (m *secretMaskWriter) Flush() error {
+	if len(m.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(m.w, maskSecrets(string(m.buf), m.secrets))
+	m.buf = m.buf[:0]
+	return err
+}
+
+// resolveStepSecrets 将步骤命令中的密钥引用（模板函数 secret 或以引用给出的节点密码）从密钥存储解析为真实值，
+// 返回解析后的命令，以及任务敏感值与本步骤用到的密钥（文件名为 secret-<name>）合并后的敏感值。
+func resolveStepSecrets(cmd StepCommand, store *SecretStore, taskSecrets map[string]string) (StepCommand, map[string]string, error) {
+	used := make(map[string]string)
+	resolve := func(s string) (string, error) { return ResolveSecretRefs(s, store, used) }
+	resolveList := func(list []string) ([]string, error) {
+		if list == nil {
+			return nil, nil
+		}
+		out := make([]string, 0, len(list))
+		for _, s := range list {
+			resolved, err := resolve(s)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, resolved)
+		}
+		return out, nil
+	}
+	var out StepCommand
+	var err error
+	if out.Entrypoint, err = resolve(cmd.Entrypoint); err != nil {
+		return cmd, nil, err
+	}
+	if out.Args, err = resolveList(cmd.Args); err != nil {
+		return cmd, nil, err
+	}
+	if out.Env, err = resolveList(cmd.Env); err != nil {
+		return cmd, nil, err
+	}
+	if len(used) == 0 {
+		return out, taskSecrets, nil
+	}
+	secrets := make(map[string]string, len(taskSecrets)+len(used))
+	for k, v := range taskSecrets {
+		secrets[k] = v
+	}
+	for name, v := range used {
+		secrets["secret-"+name] = v
+	}
+	return out, secrets, nil
+}
@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func secretTestData() *PipelineRunData {
+	return &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "k8s",
+		RunNodes:     []RunNode{{IP: "10.0.0.1", Username: "root", Password: "n0de-pass"}},
+		Args:         map[string]interface{}{"version": "1.35", "registry_password": map[string]interface{}{"secret": "Harbor12345"}},
+		Steps: []PipelineStepState{
+			{Name: "install", Status: StatusPending, Args: []string{"sshpass -p n0de-pass ssh root@10.0.0.1"}, Env: []string{"SSHPASS=n0de-pass", "REGISTRY=harbor:Harbor12345", "VERSION=1.35"}},
+			{Name: "verify", Status: StatusPending, Args: []string{"kubectl get nodes"}},
+		},
+		Always: []PipelineStepState{{Name: "cleanup", Status: StatusPending, Env: []string{"API_TOKEN=abc"}}},
+	}
+}
+
+func TestBuildRenderContext_UnwrapsSecretArgs(t *testing.T) {
+	runData := secretTestData()
+	ctx := buildRenderContext(runData.RunNodes, runData.Args)
+	if got := renderString(`{{.args.registry_password}}/{{.args.version}}`, ctx); got != "Harbor12345/1.35" {
+		t.Fatalf("unexpected render result %q", got)
+	}
+	if _, ok := secretArgValue(runData.Args["registry_password"]); !ok {
+		t.Fatalf("args passed to the render context must keep the secret marker")
+	}
+	want := map[string]string{"node-10.0.0.1-password": "n0de-pass", "arg-registry_password": "Harbor12345"}
+	if got := taskSecretValues(runData.RunNodes, runData.Args); !reflect.DeepEqual(got, want) {
+		t.Fatalf("taskSecretValues = %v, want %v", got, want)
+	}
+}
+
+func TestWritePipelineJSON_MasksSecretsAndResumeRestores(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := t.TempDir()
+	runData := secretTestData()
+	store := NewSecretStore(filepath.Join(arRoot, "secrets"), filepath.Join(arRoot, "secret.key"))
+	if err := enableSecretMasking(arRoot, store, runData); err != nil {
+		t.Fatalf("enableSecretMasking returned error: %v", err)
+	}
+	runData.Steps[0].Error = "exit 1: n0de-pass"
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatalf("WritePipelineJSON returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(runDir, "pipeline.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"n0de-pass", "Harbor12345", "abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("pipeline.json leaks %q:\n%s", secret, data)
+		}
+	}
+	if runData.Steps[0].Env[0] != "SSHPASS=n0de-pass" {
+		t.Fatalf("in-memory runData must keep real values: %v", runData.Steps[0].Env)
+	}
+	if info, err := os.Stat(TaskSecretsPath(arRoot, "task")); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("task secrets file should exist with mode 0600: %v %v", info, err)
+	}
+	// 敏感值文件以密钥存储的加密密钥加密，不含明文
+	data, err = os.ReadFile(TaskSecretsPath(arRoot, "task"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"n0de-pass", "Harbor12345", "abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("task secrets file leaks %q:\n%s", secret, data)
+		}
+	}
+
+	restored, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Steps[0].Env[0] != "SSHPASS="+secretMask || restored.RunNodes[0].Password != secretMask {
+		t.Fatalf("pipeline.json should contain masked values: %+v", restored.Steps[0])
+	}
+	if err := restoreTaskSecrets(arRoot, store, restored); err != nil {
+		t.Fatalf("restoreTaskSecrets returned error: %v", err)
+	}
+	fresh := secretTestData()
+	if !reflect.DeepEqual(restored.Steps[0].Env, fresh.Steps[0].Env) || !reflect.DeepEqual(restored.Steps[0].Args, fresh.Steps[0].Args) {
+		t.Fatalf("step command not restored: %+v", restored.Steps[0])
+	}
+	if restored.Always[0].Env[0] != "API_TOKEN=abc" || restored.RunNodes[0].Password != "n0de-pass" {
+		t.Fatalf("hooks and nodes not restored: %+v %+v", restored.Always[0], restored.RunNodes)
+	}
+	if s, _ := secretArgValue(restored.Args["registry_password"]); s != "Harbor12345" {
+		t.Fatalf("secret arg not restored: %v", restored.Args)
+	}
+
+	// 任务成功结束后敏感值文件被删除，此后不能以掩码后的值重新执行
+	if err := removeTaskSecrets(arRoot, "task"); err != nil {
+		t.Fatal(err)
+	}
+	masked, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreTaskSecrets(arRoot, store, masked); err == nil {
+		t.Fatalf("expected error when the task secrets were removed")
+	}
+}
+
+func TestWriteStepSecretFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cid")
+	secrets := map[string]string{"node-10.0.0.1-password": "n0de-pass"}
+	if err := writeStepSecretFiles(dir, secrets, os.Getuid(), os.Getgid()); err != nil {
+		t.Fatalf("writeStepSecretFiles returned error: %v", err)
+	}
+	path := filepath.Join(dir, "node-10.0.0.1-password")
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "n0de-pass" {
+		t.Fatalf("unexpected secret file content %q: %v", data, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0400 {
+		t.Fatalf("secret file mode = %v, want 0400", info.Mode().Perm())
+	}
+}
+
+func TestSecretMaskWriter_MasksAcrossWrites(t *testing.T) {
+	var out bytes.Buffer
+	w := newSecretMaskWriter(&out, []string{"n0de-pass"})
+	for _, chunk := range []string{"login with n0de", "-pass ok\npartial n0de-", "pass"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.String() != "login with ****** ok\n" {
+		t.Fatalf("complete lines should be masked and written, got %q", out.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "login with ****** ok\npartial ******" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
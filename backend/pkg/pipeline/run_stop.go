@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// taskStopFile 停止标记文件，位于任务运行目录。task stop 写入，执行任务的进程（CLI 前台执行或 server 托管执行）轮询到后删除标记并取消任务：
+// 主流程不再启动新步骤与重试，等待审批的步骤结束等待，钩子只在宽限期内运行；钩子运行期间收到停止请求则立即取消钩子。
+// 与暂停标记一样，停止方不直接改写执行进程持有的任务状态，执行进程结束前将任务记为 cancelled。
+const taskStopFile = "stop.json"
+
+// errTaskStopped 任务被 task stop 停止。
+var errTaskStopped = errors.New("任务已被停止")
+
+// taskStopWatcher 执行进程内对停止标记的响应。字段由 mu 保护；nil 表示不响应停止标记（如未经 execute 执行的步骤）。
+type taskStopWatcher struct {
+	runDir      string
+	cancel      context.CancelFunc // 取消任务上下文（主流程步骤、调度与子流水线）
+	mu          sync.Mutex
+	stopped     bool
+	cancelHooks context.CancelFunc // 钩子运行期间有效
+}
+
+func newTaskStopWatcher(runDir string, cancel context.CancelFunc) *taskStopWatcher {
+	return &taskStopWatcher{runDir: runDir, cancel: cancel}
+}
+
+// check 检查停止标记：出现时删除标记并取消任务，钩子运行期间同时立即取消钩子。返回任务是否已被停止。
+// 调度新步骤与重试前同步调用，避免在轮询间隔内为已停止的任务启动新容器。
+func (w *taskStopWatcher) check() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := os.Stat(filepath.Join(w.runDir, taskStopFile)); err != nil {
+		return w.stopped
+	}
+	if err := clearTaskStop(w.runDir); err != nil {
+		logrus.Warn(err)
+	}
+	if !w.stopped {
+		logrus.Infof("收到停止请求，取消任务: %s", w.runDir)
+		w.stopped = true
+		w.cancel()
+	}
+	if w.cancelHooks != nil {
+		logrus.Infof("收到停止请求，取消正在运行的钩子: %s", w.runDir)
+		w.cancelHooks()
+	}
+	return w.stopped
+}
+
+// isStopped 判断任务是否已被停止。
+func (w *taskStopWatcher) isStopped() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stopped
+}
+
+// setHooksCancel 登记（cancel 为 nil 时注销）正在运行的钩子的取消函数。
+func (w *taskStopWatcher) setHooksCancel(cancel context.CancelFunc) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cancelHooks = cancel
+}
+
+// watch 每 pausePollInterval 检查一次停止标记，直到 ctx 结束。ctx 需覆盖钩子执行阶段，不能随任务上下文一起取消。
+func (w *taskStopWatcher) watch(ctx context.Context) {
+	ticker := time.NewTicker(pausePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		w.check()
+	}
+}
+
+// requestTaskStop 写入停止标记，通知执行任务的进程取消任务。
+func requestTaskStop(runDir string) error {
+	if err := os.WriteFile(filepath.Join(runDir, taskStopFile), []byte(time.Now().Format(time.RFC3339)), 0644); err != nil {
+		return fmt.Errorf("写入停止标记失败: %w", err)
+	}
+	return nil
+}
+
+// clearTaskStop 删除任务运行目录中的停止标记，不存在时忽略。
+func clearTaskStop(runDir string) error {
+	if err := os.Remove(filepath.Join(runDir, taskStopFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除停止标记失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// subPipelineCallKey context 中记录子流水线调用信息的键。
+type subPipelineCallKey struct{}
+
+// subPipelineCall 子流水线的调用信息：父任务 ID 与自顶向下的流水线调用链（用于检测循环引用）。
+type subPipelineCall struct {
+	parentTaskID string
+	chain        []string
+}
+
+// subPipelineCallFrom 返回 ctx 中的子流水线调用信息，顶层任务返回零值。
+func subPipelineCallFrom(ctx context.Context) subPipelineCall {
+	call, _ := ctx.Value(subPipelineCallKey{}).(subPipelineCall)
+	return call
+}
+
+// validateSubPipelineStep 校验子流水线步骤：不能同时指定容器相关字段，流水线名必须合法。
+func validateSubPipelineStep(step TemplateStep) error {
+	if step.Pipeline == "" {
+		if len(step.PipelineArgs) > 0 {
+			return fmt.Errorf("步骤 %s 设置了 pipelineArgs 但未指定 pipeline", step.Name)
+		}
+		return nil
+	}
+	if sanitizePipelineName(step.Pipeline) == "" {
+		return fmt.Errorf("步骤 %s 的 pipeline 名称无效: %s", step.Name, step.Pipeline)
+	}
+	if step.Image != "" || step.Entrypoint != "" || len(step.Args) > 0 || len(step.Env) > 0 || hasContainerOptions(step.ContainerOptions) {
+		return fmt.Errorf("步骤 %s 为子流水线步骤，不能同时设置 image/entrypoint/args/env/mounts/workingDir/user/hostname", step.Name)
+	}
+	return nil
+}
+
+// subPipelineArgs 按 pipelineArgs（子流水线参数名 -> 父任务参数名）从父任务参数中挑选传给子流水线的参数，
+// 父任务中不存在的参数忽略。
+func subPipelineArgs(mapping map[string]string, parentArgs map[string]interface{}) map[string]interface{} {
+	args := make(map[string]interface{}, len(mapping))
+	for childKey, parentKey := range mapping {
+		if v, ok := parentArgs[parentKey]; ok {
+			args[childKey] = v
+		}
+	}
+	return args
+}
+
+// attemptContainerID 返回步骤某次尝试的容器 ID；子流水线步骤与审批步骤不启动容器，返回空字符串。
+func attemptContainerID(pipelineName string, step PipelineStepState, index, attempt int) string {
+	if step.Pipeline != "" || step.Approval != nil {
+		return ""
+	}
+	return stepContainerID(pipelineName, step.Name, index, attempt)
+}
+
+// runSubPipeline 以嵌套任务的方式执行子流水线步骤：使用父任务的节点与映射后的参数运行 step.Pipeline。
+// 子任务 ID 在首次执行前生成并写入 state.ChildTaskID；重试或恢复父任务时若子任务已存在，则恢复该子任务（跳过已完成的步骤）。
+// 子任务继承 ctx，父任务被取消或超时时子任务随之停止。调用方不得持有 mu。
+func (r *Runner) runSubPipeline(ctx context.Context, runDir string, runData *PipelineRunData, mu *sync.Mutex, state *PipelineStepState, step *PipelineStepState) RunStepResult {
+	call := subPipelineCallFrom(ctx)
+	chain := append(append([]string{}, call.chain...), sanitizePipelineName(runData.PipelineName))
+	for _, name := range chain {
+		if name == sanitizePipelineName(step.Pipeline) {
+			return RunStepResult{ExitCode: -1, Err: fmt.Errorf("子流水线循环引用: %v -> %s", chain, step.Pipeline)}
+		}
+	}
+
+	mu.Lock()
+	if state.ChildTaskID == "" {
+		state.ChildTaskID = GenerateTaskID()
+	}
+	childTaskID := state.ChildTaskID
+	writeErr := WritePipelineJSON(runDir, runData)
+	mu.Unlock()
+	if writeErr != nil {
+		return RunStepResult{ExitCode: -1, Err: writeErr}
+	}
+
+	childCtx := context.WithValue(ctx, subPipelineCallKey{}, subPipelineCall{parentTaskID: runData.TaskID, chain: chain})
+	var err error
+	if _, findErr := FindRunDirByTaskID(r.arRoot, childTaskID); findErr == nil {
+		logrus.Infof("步骤 %s 恢复子流水线任务: pipeline=%s taskId=%s", step.Name, step.Pipeline, childTaskID)
+		err = r.Resume(childCtx, childTaskID)
+	} else {
+		logrus.Infof("步骤 %s 启动子流水线任务: pipeline=%s taskId=%s", step.Name, step.Pipeline, childTaskID)
+		_, err = r.Run(childCtx, step.Pipeline, runData.RunNodes, subPipelineArgs(step.PipelineArgs, runData.Args), childTaskID)
+	}
+	if err != nil {
+		return RunStepResult{ExitCode: -1, Err: fmt.Errorf("子流水线 %s（taskId=%s）执行失败: %w", step.Pipeline, childTaskID, err)}
+	}
+	return RunStepResult{ExitCode: 0}
+}
+
+// Stop 按 taskId 停止流水线任务：停止正在运行的容器，未结束的步骤标记为 cancelled；
+// 正在运行的子流水线步骤会级联停止其子任务。
+func (r *Runner) Stop(taskID string) error {
+	return stopPipelineTask(r.arRoot, r.runtimeRoot, taskID)
+}
@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSubPipelineArgs_MapsSubsetOfParentArgs(t *testing.T) {
+	parent := map[string]interface{}{"vip": "10.0.0.100", "replicas": 3, "unused": true}
+	got := subPipelineArgs(map[string]string{"lvs_care_vip": "vip", "replicas": "replicas", "missing": "nope"}, parent)
+	want := map[string]interface{}{"lvs_care_vip": "10.0.0.100", "replicas": 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("subPipelineArgs = %v, want %v", got, want)
+	}
+}
+
+func TestValidateSubPipelineStep(t *testing.T) {
+	if err := validateSubPipelineStep(TemplateStep{Name: "cni", Pipeline: "network-cilium"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateSubPipelineStep(TemplateStep{Name: "cni", Pipeline: "network-cilium", Image: "img"}); err == nil {
+		t.Fatalf("expected error when sub-pipeline step also sets image")
+	}
+	if err := validateSubPipelineStep(TemplateStep{Name: "cni", PipelineArgs: map[string]string{"a": "b"}}); err == nil {
+		t.Fatalf("expected error for pipelineArgs without pipeline")
+	}
+}
+
+func TestRunSubPipeline_RejectsCycle(t *testing.T) {
+	r := NewRunner(t.TempDir(), t.TempDir(), "", "", 0, StepContainerConfig{})
+	runData := &PipelineRunData{TaskID: "child", PipelineName: "network-cilium"}
+	ctx := context.WithValue(context.Background(), subPipelineCallKey{}, subPipelineCall{parentTaskID: "parent", chain: []string{"containerd-k8s"}})
+	step := PipelineStepState{Name: "k8s", Pipeline: "containerd-k8s"}
+	var mu sync.Mutex
+	result := r.runSubPipeline(ctx, t.TempDir(), runData, &mu, &step, &step)
+	if result.Err == nil {
+		t.Fatalf("expected cycle error")
+	}
+	if step.ChildTaskID != "" {
+		t.Fatalf("child task should not be created for a cyclic reference")
+	}
+}
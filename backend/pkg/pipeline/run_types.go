@@ -1,6 +1,9 @@
 package pipeline
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // RunNode 表示执行流水线时的一台节点（与 design/节点管理.md 一致）。
 type RunNode struct {
@@ -26,25 +29,159 @@ type TemplateStep struct {
 	Args       []string `json:"args,omitempty"`
 	Env        []string `json:"env,omitempty"`
 	Nodes      []string `json:"nodes,omitempty"` // 后继节点名，用于 DAG 边
+	// ForEach 节点标签选择器（* 或 key=value[,key=value]），设置后步骤按匹配节点展开为多个实例，实例中可用 .node / .index
+	ForEach string `json:"forEach,omitempty"`
+	// Pipeline 子流水线名称：设置后步骤不启动容器，而是以嵌套任务的方式执行 PipelinesDir 中的另一条流水线（与 image 等字段互斥）
+	Pipeline string `json:"pipeline,omitempty"`
+	// PipelineArgs 传给子流水线的参数映射：子流水线参数名 -> 父任务参数名
+	PipelineArgs map[string]string `json:"pipelineArgs,omitempty"`
+	// Approval 审批配置：设置后步骤不启动容器，任务进入 waiting 状态直到通过 approve/reject 处理（与 image 等字段互斥）
+	Approval *ApprovalSpec `json:"approval,omitempty"`
+	StepOptions
+	ContainerOptions
+
+	// forEach 展开后实例所属的父步骤与节点 IP，由 expandForEach 填充
+	forEachParent string
+	forEachNode   string
+}
+
+// StepOptions 步骤的执行策略（并发分组、重试等），模板与 pipeline.json 共用，渲染时原样复制。
+type StepOptions struct {
+	// ParallelGroup 并发分组名，未设置时使用步骤名（forEach 实例使用父步骤名）；同组步骤共享 MaxParallel 并发上限
+	ParallelGroup string `json:"parallelGroup,omitempty"`
+	// MaxParallel 同组步骤同时运行的最大数量，0 表示不限制（仍受流水线级与全局上限约束）
+	MaxParallel int `json:"maxParallel,omitempty"`
+	// Retries 失败后的重试次数，0 表示不重试
+	Retries int `json:"retries,omitempty"`
+	// RetryDelay 两次尝试之间的等待时间（Go duration 格式，如 "10s"），为空表示立即重试
+	RetryDelay string `json:"retryDelay,omitempty"`
+	// RetryBackoff 退避策略：fixed（默认，每次等待 RetryDelay）| exponential（每次等待时间翻倍）
+	RetryBackoff string `json:"retryBackoff,omitempty"`
+	// MaxRetryDelay exponential 退避时单次等待的上限（Go duration 格式），为空表示不限制
+	MaxRetryDelay string `json:"maxRetryDelay,omitempty"`
+	// SuccessExitCodes 视为成功的退出码列表，为空表示仅 0 为成功（如 [0, 2] 可将“已安装”等约定退出码视为成功）
+	SuccessExitCodes []int `json:"successExitCodes,omitempty"`
+	// When 执行条件：不含 {{ }} 定界符的 Go template 表达式，在步骤依赖满足后、启动前求值，
+	// 可访问 .args、.nodes（含标签）与 .steps（已有步骤的 status/exitCode）；结果为 false 时步骤标记为 skipped
+	When string `json:"when,omitempty"`
+	// AllowFailure 为 true 时步骤失败（含重试耗尽）不影响任务结果：步骤仍记录为 failed，但后继步骤视其为已满足
+	AllowFailure bool `json:"allowFailure,omitempty"`
+	// Timeout 单次尝试的超时时间（Go duration 格式），超时后容器先收到 SIGTERM，宽限期后 SIGKILL；为空表示不限制
+	Timeout string `json:"timeout,omitempty"`
+	// Resources 容器的 cgroup 资源限制（cpu / memory / pids），未设置的字段使用全局默认值（--step-cpu 等）
+	Resources *StepResources `json:"resources,omitempty"`
+}
+
+// PipelineTemplate 对应对象形式的 pipeline_name.template.json：{ "maxParallel": 2, "steps": [ ... ] }。
+// 旧的数组形式 [ ... ] 仍然支持，此时仅填充 Steps。
+type PipelineTemplate struct {
+	// MaxParallel 流水线内同时运行的最大步骤数，0 表示不限制
+	MaxParallel int `json:"maxParallel,omitempty"`
+	// Timeout 整条流水线的执行时限（Go duration 格式），到期后正在运行的步骤标记为 timeout；为空表示不限制
+	Timeout string `json:"timeout,omitempty"`
+	// FailurePolicy 步骤失败后的调度策略：failFast（默认，不再启动任何新步骤）| finishIndependentBranches（仅停止失败步骤的下游，其余分支继续执行）
+	FailurePolicy string         `json:"failurePolicy,omitempty"`
+	Steps         []TemplateStep `json:"steps"`
+	// OnSuccess / OnFailure / Always 流水线钩子：主流程结束后按结果执行的步骤列表，各自按 nodes 构成独立的 DAG
+	OnSuccess []TemplateStep `json:"onSuccess,omitempty"`
+	OnFailure []TemplateStep `json:"onFailure,omitempty"`
+	Always    []TemplateStep `json:"always,omitempty"`
 }
 
 // PipelineRunData 写入 /var/lib/ar/pipeline_name/taskID/pipeline.json 的运行时状态（执行计划 DAG + 各节点状态）。
 type PipelineRunData struct {
-	TaskID       string              `json:"taskId"`
-	PipelineName string              `json:"pipelineName"`
-	Steps        []PipelineStepState `json:"steps"`
+	TaskID        string              `json:"taskId"`
+	PipelineName  string              `json:"pipelineName"`
+	MaxParallel   int                 `json:"maxParallel,omitempty"`   // 流水线级并发上限（来自模板），0 表示不限制
+	Timeout       string              `json:"timeout,omitempty"`       // 流水线级执行时限（来自模板），为空表示不限制
+	FailurePolicy string              `json:"failurePolicy,omitempty"` // 步骤失败后的调度策略（来自模板），为空表示 failFast
+	Status        string              `json:"status,omitempty"`        // 任务状态：pending | running | waiting | paused | success | failed | timeout | cancelled | interrupted
+	CreatedAt     time.Time           `json:"createdAt"`
+	FinishedAt    *time.Time          `json:"finishedAt,omitempty"` // 任务结束（成功、失败或被停止）的时间，运行中为空
+	Steps         []PipelineStepState `json:"steps"`
+
+	// Args 与 RunNodes 为执行时传入的参数与节点列表，恢复执行时用于求值步骤的 when 条件
+	Args     map[string]interface{} `json:"args,omitempty"`
+	RunNodes []RunNode              `json:"runNodes,omitempty"`
+	// ParentTaskID 作为子流水线执行时，父任务的 taskId
+	ParentTaskID string `json:"parentTaskId,omitempty"`
+	// RunnerPID 正在执行（或恢复执行）该任务的进程 PID，任务结束时清除；启动时对账、resume 与 skip 据此判断任务是否仍有进程托管
+	RunnerPID int `json:"runnerPid,omitempty"`
+	// RunnerStart 执行进程的启动标识（boot_id 与进程启动时间），与 RunnerPID 一起识别进程，避免主机重启后 PID 被复用时误判
+	RunnerStart string `json:"runnerStart,omitempty"`
+	// Pause 任务暂停期间的暂停记录（操作人与时间），恢复后清空
+	Pause *TaskPause `json:"pause,omitempty"`
+
+	// OnSuccess / OnFailure / Always 流水线钩子步骤的执行状态，与主流程 steps 分开记录
+	OnSuccess []PipelineStepState `json:"onSuccess,omitempty"`
+	OnFailure []PipelineStepState `json:"onFailure,omitempty"`
+	Always    []PipelineStepState `json:"always,omitempty"`
+
+	// ForEach 主流程中 forEach 步骤按父步骤汇总的实例视图（只读，写入 pipeline.json 时根据 steps 刷新）
+	ForEach []ForEachGroup `json:"forEach,omitempty"`
+
+	// secrets 任务的敏感值（名称 -> 值），仅保存在内存中；maskOnWrite 为 true 时写入 pipeline.json 前掩码敏感值，
+	// 由新建任务与恢复执行（还原敏感值后）置位，其余读改写 pipeline.json 的操作原样写回已掩码的内容
+	secrets     map[string]string
+	maskOnWrite bool
+	// pauser 执行期间任务的暂停状态（见 run_pause.go），仅保存在内存中
+	pauser *taskPauser
+	// stopper 执行期间对停止标记的响应（见 run_stop.go），仅保存在内存中
+	stopper *taskStopWatcher
 }
 
 // PipelineStepState 单个步骤的执行状态。
 type PipelineStepState struct {
 	Name   string `json:"name"`
 	Image  string `json:"image"`
-	Status string `json:"status"` // pending | running | success | failed | cancelled
+	Status string `json:"status"` // pending | queued | running | waiting | success | failed | timeout | cancelled | skipped | interrupted
 	// 以下为渲染后的运行时参数（便于恢复/日志）
 	Entrypoint string   `json:"entrypoint,omitempty"`
 	Args       []string `json:"args,omitempty"`
 	Env        []string `json:"env,omitempty"`
 	Nodes      []string `json:"nodes,omitempty"`
+	StepOptions
+	ContainerOptions
+	// ForEach / ForEachParent / ForEachNode forEach 展开后的实例记录选择器、模板中的父步骤名与所在节点 IP
+	ForEach       string `json:"forEach,omitempty"`
+	ForEachParent string `json:"forEachParent,omitempty"`
+	ForEachNode   string `json:"forEachNode,omitempty"`
+	// Pipeline / PipelineArgs 子流水线步骤的流水线名与参数映射；ChildTaskID 为其嵌套任务的 taskId（首次执行前生成）
+	Pipeline     string            `json:"pipeline,omitempty"`
+	PipelineArgs map[string]string `json:"pipelineArgs,omitempty"`
+	ChildTaskID  string            `json:"childTaskId,omitempty"`
+	// Approval 审批步骤配置；ApprovalResult 为审批记录（结果、审批人与时间），审批前为空
+	Approval       *ApprovalSpec `json:"approval,omitempty"`
+	ApprovalResult *StepApproval `json:"approvalResult,omitempty"`
+	// Unrendered 引用了其他步骤输出（{{output ...}}）的原始 entrypoint/args/env，步骤启动前据此重新渲染上面三个字段
+	Unrendered *StepCommand `json:"unrendered,omitempty"`
+	// Outputs 步骤成功后从 /current-task/outputs.json 读取的键值输出
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// 以下为最近一次执行的元数据：StartedAt 为首次尝试开始时间，FinishedAt/DurationMs 在步骤结束（含全部重试）后写入；
+	// ExitCode、Error、ContainerID、Attempt 取自最近一次尝试
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	DurationMs  int64      `json:"durationMs,omitempty"`
+	ExitCode    *int       `json:"exitCode,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	ContainerID string     `json:"containerId,omitempty"`
+	Attempt     int        `json:"attempt,omitempty"`
+	// OOMKilled 最近一次尝试的进程因超出内存限制被 OOM killer 终止
+	OOMKilled bool `json:"oomKilled,omitempty"`
+	// Attempts 每次执行尝试的记录（含重试），按尝试顺序排列
+	Attempts []StepAttempt `json:"attempts,omitempty"`
+}
+
+// StepAttempt 步骤的单次执行尝试。
+type StepAttempt struct {
+	Attempt     int       `json:"attempt"` // 从 1 开始
+	ContainerID string    `json:"containerId"`
+	ExitCode    int       `json:"exitCode"`
+	Error       string    `json:"error,omitempty"`
+	TimedOut    bool      `json:"timedOut,omitempty"`
+	OOMKilled   bool      `json:"oomKilled,omitempty"` // 进程因超出内存限制被 OOM killer 终止
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
 }
 
 // NodesFile 从 -n nodes.json 读取的节点列表（与 GraphQL RunPipelineInput 对应）。
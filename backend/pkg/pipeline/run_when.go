@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// parseWhen 将步骤的 when 表达式解析为模板。when 为不含 {{ }} 定界符的 Go template 管道，
+// 如 `eq .args.env "prod"`、`labelHas (index .nodes 0).LabelsStr "role" "master"`、`eq (index .steps "check").exitCode 2`。
+func parseWhen(expr string) (*template.Template, error) {
+	return template.New("when").Funcs(templateFuncs).Option("missingkey=zero").Parse("{{" + expr + "}}")
+}
+
+// evalWhen 以 runData 构建上下文求值 when 表达式，结果需为 true/false（空结果视为 false）。
+// 表达式中可用 output 函数读取已记录的步骤输出。调用方需持有保护 runData 的锁。
+func evalWhen(expr string, runData *PipelineRunData) (bool, error) {
+	tpl, err := parseWhen(expr)
+	if err != nil {
+		return false, err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Funcs(stepOutputFuncs(runData)).Execute(&buf, buildWhenContext(runData)); err != nil {
+		return false, err
+	}
+	out := strings.TrimSpace(buf.String())
+	if out == "" || out == "<no value>" {
+		return false, nil
+	}
+	ok, err := strconv.ParseBool(out)
+	if err != nil {
+		return false, fmt.Errorf("when 表达式结果不是布尔值: %s", out)
+	}
+	return ok, nil
+}
+
+// buildWhenContext 构建 when 表达式的求值上下文：在模板渲染上下文（.nodes、.args）基础上增加 .steps，
+// 以步骤名为键记录主流程步骤的 status、exitCode（尚未执行的步骤为 -1）与 outputs；.failedSteps 为主流程中失败的步骤名（供钩子使用）。
+// 调用方需持有保护 runData 的锁。
+func buildWhenContext(runData *PipelineRunData) map[string]interface{} {
+	data := buildRenderContext(runData.RunNodes, runData.Args)
+	steps := make(map[string]interface{}, len(runData.Steps))
+	for _, s := range runData.Steps {
+		exitCode := -1
+		if s.ExitCode != nil {
+			exitCode = *s.ExitCode
+		}
+		steps[s.Name] = map[string]interface{}{"status": s.Status, "exitCode": exitCode, "outputs": s.Outputs}
+	}
+	data["steps"] = steps
+	data["failedSteps"] = failedStepNames(runData)
+	return data
+}
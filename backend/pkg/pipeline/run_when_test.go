@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvalWhen(t *testing.T) {
+	exitCode := 2
+	runData := &PipelineRunData{
+		Args:     map[string]interface{}{"env": "prod"},
+		RunNodes: []RunNode{{IP: "10.0.0.1", Labels: []Label{{Key: "role", Value: "master"}}}},
+		Steps: []PipelineStepState{
+			{Name: "check", Status: StatusSuccess, ExitCode: &exitCode, Outputs: map[string]string{"version": "1.35.0"}},
+			{Name: "install", Status: StatusPending},
+		},
+	}
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`eq .args.env "prod"`, true},
+		{`eq .args.env "dev"`, false},
+		{`labelHas (index .nodes 0).LabelsStr "role" "master"`, true},
+		{`eq (index .steps "check").exitCode 2`, true},
+		{`eq (index .steps "install").status "success"`, false},
+		{`.args.missing`, false},
+		{`eq (output "check" "version") "1.35.0"`, true},
+	}
+	for _, c := range cases {
+		got, err := evalWhen(c.expr, runData)
+		if err != nil {
+			t.Fatalf("evalWhen(%q) returned error: %v", c.expr, err)
+		}
+		if got != c.want {
+			t.Errorf("evalWhen(%q) = %v, want %v", c.expr, got, c.want)
+		}
+	}
+	if _, err := evalWhen(`.args.env`, runData); err == nil {
+		t.Errorf("expected error for non-boolean result")
+	}
+}
+
+func TestDAGScheduler_SkippedStepSatisfiesSuccessors(t *testing.T) {
+	steps := []PipelineStepState{
+		{Name: "a", Nodes: []string{"b"}},
+		{Name: "b", Nodes: []string{"c"}},
+		{Name: "c"},
+	}
+	scheduler, err := newDAGScheduler(steps, 0)
+	if err != nil {
+		t.Fatalf("newDAGScheduler returned error: %v", err)
+	}
+	scheduler.skip = func(step PipelineStepState) (bool, error) {
+		return step.Name == "b", nil
+	}
+	var executed []string
+	err = scheduler.run(context.Background(), nil, func(ctx context.Context, step PipelineStepState) error {
+		executed = append(executed, step.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if len(executed) != 2 || executed[0] != "a" || executed[1] != "c" {
+		t.Fatalf("expected [a c], got %v", executed)
+	}
+}
@@ -0,0 +1,334 @@
+package pipeline
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tangxusc/ar/backend/pkg/config"
+)
+
+// 密钥存储：ar secret 管理的命名密钥，每个密钥一个文件 arRoot/secrets/<name>.json，值以 AES-256-GCM 加密，
+// 密钥文件（--secret-key-file）为本机生成的 32 字节随机数。模板、节点文件与登录配置中以 ((secret:<name>)) 引用密钥，
+// 使用时（步骤启动前、拉取镜像时）才解析为真实值。
+
+// secretKeySize 加密密钥长度（AES-256）。
+const secretKeySize = 32
+
+var (
+	secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	secretRefPattern  = regexp.MustCompile(`\(\(secret:([A-Za-z0-9][A-Za-z0-9_.-]*)\)\)`)
+)
+
+// ErrSecretNotFound 密钥不存在。
+var ErrSecretNotFound = errors.New("密钥不存在")
+
+// SecretStore 加密的密钥存储。
+type SecretStore struct {
+	dir     string
+	keyFile string
+}
+
+// SecretInfo 密钥的元数据（不含值）。
+type SecretInfo struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// secretRecord 密钥在磁盘上的结构，Nonce 与 Data 为 GCM 的随机数与密文（JSON 中为 base64）。
+type secretRecord struct {
+	Name      string    `json:"name"`
+	Nonce     []byte    `json:"nonce"`
+	Data      []byte    `json:"data"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NewSecretStore 构造密钥存储。dir 为密钥文件目录，keyFile 为加密密钥文件路径。
+func NewSecretStore(dir, keyFile string) *SecretStore {
+	return &SecretStore{dir: dir, keyFile: keyFile}
+}
+
+// ConfiguredSecretStore 返回全局配置中的密钥存储：目录为 arRoot/secrets，加密密钥为 --secret-key-file。
+func ConfiguredSecretStore() *SecretStore {
+	return NewSecretStore(filepath.Join(filepath.Dir(config.PipelinesDir), "secrets"), config.SecretKeyFile)
+}
+
+// ValidateSecretName 校验密钥名称：字母或数字开头，仅允许字母、数字、_ . -。
+func ValidateSecretName(name string) error {
+	if !secretNamePattern.MatchString(name) {
+		return fmt.Errorf("密钥名称无效: %q（仅允许字母、数字、_ . -，且以字母或数字开头）", name)
+	}
+	return nil
+}
+
+// SecretRef 返回密钥的引用文本 ((secret:<name>))。模板函数 secret 生成该引用。
+func SecretRef(name string) (string, error) {
+	if err := ValidateSecretName(name); err != nil {
+		return "", err
+	}
+	return "((secret:" + name + "))", nil
+}
+
+// isSecretRef 判断 s 是否恰好为一个密钥引用。
+func isSecretRef(s string) bool {
+	loc := secretRefPattern.FindStringIndex(s)
+	return loc != nil && loc[0] == 0 && loc[1] == len(s)
+}
+
+// NodePasswordSecretName 返回节点登录密码在密钥存储中的名称。
+func NodePasswordSecretName(ip string) string {
+	return secretFileName("node-" + strings.TrimSpace(ip))
+}
+
+// RegistrySecretName 返回 registry 登录密码在密钥存储中的名称。
+func RegistrySecretName(server string) string {
+	return secretFileName("registry-" + strings.TrimSpace(server))
+}
+
+func (s *SecretStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// key 读取加密密钥；create 为 true 且密钥文件不存在时生成新密钥（先写临时文件再以硬链接原子发布，避免并发生成不同的密钥）。
+func (s *SecretStore) key(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.keyFile)
+	if os.IsNotExist(err) && create {
+		key, err = s.generateKey()
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("密钥文件不存在 %s（尚未保存过任何密钥）", s.keyFile)
+		}
+		return nil, fmt.Errorf("读取密钥文件失败 %s: %w", s.keyFile, err)
+	}
+	if len(key) != secretKeySize {
+		return nil, fmt.Errorf("密钥文件无效 %s: 长度应为 %d 字节", s.keyFile, secretKeySize)
+	}
+	return key, nil
+}
+
+func (s *SecretStore) generateKey() ([]byte, error) {
+	key := make([]byte, secretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成加密密钥失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyFile), 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥文件目录失败: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.tmp-%d", s.keyFile, os.Getpid())
+	if err := os.WriteFile(tmp, key, 0600); err != nil {
+		return nil, fmt.Errorf("写入密钥文件失败 %s: %w", tmp, err)
+	}
+	defer os.Remove(tmp)
+	if err := os.Link(tmp, s.keyFile); err != nil {
+		if os.IsExist(err) {
+			// 其他进程已先生成密钥，使用已发布的密钥
+			return os.ReadFile(s.keyFile)
+		}
+		return nil, fmt.Errorf("写入密钥文件失败 %s: %w", s.keyFile, err)
+	}
+	return key, nil
+}
+
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("初始化加密算法失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 以 aad 为附加数据加密 plain，返回填好 Nonce 与 Data 的记录；加密密钥不存在时生成。
+func (s *SecretStore) seal(aad string, plain []byte) (secretRecord, error) {
+	key, err := s.key(true)
+	if err != nil {
+		return secretRecord{}, err
+	}
+	aead, err := newSecretCipher(key)
+	if err != nil {
+		return secretRecord{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return secretRecord{}, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return secretRecord{Nonce: nonce, Data: aead.Seal(nil, nonce, plain, []byte(aad))}, nil
+}
+
+// open 解密 seal 生成的记录，aad 须与加密时一致。
+func (s *SecretStore) open(aad string, record secretRecord) ([]byte, error) {
+	key, err := s.key(false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(record.Nonce) != aead.NonceSize() {
+		return nil, errors.New("随机数长度无效")
+	}
+	plain, err := aead.Open(nil, record.Nonce, record.Data, []byte(aad))
+	if err != nil {
+		return nil, fmt.Errorf("密钥文件不匹配或密文被篡改: %w", err)
+	}
+	return plain, nil
+}
+
+// Set 加密保存密钥，已存在时覆盖。
+func (s *SecretStore) Set(name, value string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+	// 以密钥名称作为附加数据，密文文件被改名后无法解密
+	record, err := s.seal(name, []byte(value))
+	if err != nil {
+		return err
+	}
+	record.Name, record.UpdatedAt = name, time.Now()
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化密钥失败: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("创建密钥目录失败 %s: %w", s.dir, err)
+	}
+	path := s.path(name)
+	tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入密钥失败 %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入密钥失败 %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get 读取并解密密钥，不存在时返回 ErrSecretNotFound。
+func (s *SecretStore) Get(name string) (string, error) {
+	if err := ValidateSecretName(name); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return "", fmt.Errorf("读取密钥失败 %s: %w", name, err)
+	}
+	var record secretRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return "", fmt.Errorf("解析密钥失败 %s: %w", name, err)
+	}
+	plain, err := s.open(name, record)
+	if err != nil {
+		return "", fmt.Errorf("解密密钥失败 %s: %w", name, err)
+	}
+	return string(plain), nil
+}
+
+// List 列出全部密钥（按名称排序），目录不存在时返回空列表。
+func (s *SecretStore) List() ([]SecretInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取密钥目录失败 %s: %w", s.dir, err)
+	}
+	var infos []SecretInfo
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok || ValidateSecretName(name) != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥失败 %s: %w", name, err)
+		}
+		var record secretRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("解析密钥失败 %s: %w", name, err)
+		}
+		infos = append(infos, SecretInfo{Name: name, UpdatedAt: record.UpdatedAt})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Remove 删除密钥，不存在时返回 ErrSecretNotFound。
+func (s *SecretStore) Remove(name string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return fmt.Errorf("删除密钥失败 %s: %w", name, err)
+	}
+	return nil
+}
+
+// ResolveSecretRefs 将 s 中的全部密钥引用替换为真实值，并把用到的密钥（名称 -> 值）记入 used（可为 nil）。
+// 引用的密钥不存在或无法解密时返回错误；store 为 nil 时含引用即返回错误。
+func ResolveSecretRefs(s string, store *SecretStore, used map[string]string) (string, error) {
+	var resolveErr error
+	out := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := secretRefPattern.FindStringSubmatch(ref)[1]
+		if resolveErr != nil {
+			return ref
+		}
+		if store == nil {
+			resolveErr = fmt.Errorf("未配置密钥存储，无法解析密钥引用: %s", name)
+			return ref
+		}
+		value, err := store.Get(name)
+		if err != nil {
+			resolveErr = err
+			return ref
+		}
+		if used != nil {
+			used[name] = value
+		}
+		return value
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return out, nil
+}
+
+// StoreSecretValue 将明文值保存到密钥存储并返回其引用；value 为空或本身已是引用时原样返回，不写入存储。
+// 用于节点文件与登录配置中的密码，使其不以明文落盘。
+func StoreSecretValue(store *SecretStore, name, value string) (string, error) {
+	if value == "" || isSecretRef(value) {
+		return value, nil
+	}
+	if err := store.Set(name, value); err != nil {
+		return "", err
+	}
+	return SecretRef(name)
+}
+
+// RemoveSecretRef value 为引用名为 name 的密钥时将其从存储中删除（删除节点、覆盖登录配置时调用），不存在时忽略。
+func RemoveSecretRef(store *SecretStore, name, value string) error {
+	ref, err := SecretRef(name)
+	if err != nil || value != ref {
+		return err
+	}
+	if err := store.Remove(name); err != nil && !errors.Is(err, ErrSecretNotFound) {
+		return err
+	}
+	return nil
+}
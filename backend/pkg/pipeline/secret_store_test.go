@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretStore_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSecretStore(filepath.Join(dir, "secrets"), filepath.Join(dir, "secret.key"))
+	if err := store.Set("registry-password", "Harbor12345"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "secrets", "registry-password.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Harbor12345") {
+		t.Fatalf("secret stored in plaintext: %s", data)
+	}
+	if got, err := store.Get("registry-password"); err != nil || got != "Harbor12345" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	infos, err := store.List()
+	if err != nil || len(infos) != 1 || infos[0].Name != "registry-password" {
+		t.Fatalf("List = %+v, %v", infos, err)
+	}
+
+	// 换用另一把密钥后无法解密
+	other := NewSecretStore(filepath.Join(dir, "secrets"), filepath.Join(dir, "other.key"))
+	if err := other.Set("unused", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Get("registry-password"); err == nil {
+		t.Fatalf("expected decryption error with a different key")
+	}
+
+	if err := store.Remove("registry-password"); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+	if _, err := store.Get("registry-password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected ErrSecretNotFound, got %v", err)
+	}
+	if err := store.Set("../escape", "x"); err == nil {
+		t.Fatalf("expected error for invalid secret name")
+	}
+}
+
+func TestResolveStepSecrets(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSecretStore(filepath.Join(dir, "secrets"), filepath.Join(dir, "secret.key"))
+	if err := store.Set("registry-password", "Harbor12345"); err != nil {
+		t.Fatal(err)
+	}
+	ref := renderString(`{{secret "registry-password"}}`, nil)
+	if ref != "((secret:registry-password))" {
+		t.Fatalf("secret template func rendered %q", ref)
+	}
+	cmd := StepCommand{Args: []string{"login -p " + ref}, Env: []string{"REGISTRY_PASSWORD=" + ref, "A=b"}}
+	taskSecrets := map[string]string{"node-10.0.0.1-password": "n0de-pass"}
+	out, secrets, err := resolveStepSecrets(cmd, store, taskSecrets)
+	if err != nil {
+		t.Fatalf("resolveStepSecrets returned error: %v", err)
+	}
+	if out.Args[0] != "login -p Harbor12345" || out.Env[0] != "REGISTRY_PASSWORD=Harbor12345" || out.Env[1] != "A=b" {
+		t.Fatalf("unexpected resolved command %+v", out)
+	}
+	if secrets["secret-registry-password"] != "Harbor12345" || secrets["node-10.0.0.1-password"] != "n0de-pass" || len(taskSecrets) != 1 {
+		t.Fatalf("unexpected step secrets %v (task secrets %v)", secrets, taskSecrets)
+	}
+	if cmd.Args[0] != "login -p "+ref {
+		t.Fatalf("input command must keep the reference: %+v", cmd)
+	}
+
+	if _, _, err := resolveStepSecrets(StepCommand{Env: []string{"X=((secret:missing))"}}, store, nil); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected ErrSecretNotFound, got %v", err)
+	}
+	if _, _, err := resolveStepSecrets(cmd, nil, nil); err == nil {
+		t.Fatalf("expected error without a secret store")
+	}
+	// 未配置密钥存储的 Runner 使用 arRoot 下的默认位置
+	r := NewRunner(dir, "", "", "", 0, StepContainerConfig{})
+	if out, _, err := resolveStepSecrets(cmd, r.secretStore(), nil); err != nil || out.Args[0] != "login -p Harbor12345" {
+		t.Fatalf("runner without a configured store should resolve references, got %+v %v", out, err)
+	}
+}
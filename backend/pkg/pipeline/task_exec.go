@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/console"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// TaskExecOptions ar pipeline task exec 的参数。
+type TaskExecOptions struct {
+	TaskID string
+	// ContainerID 目标步骤容器 ID；为空且任务中只有一个正在运行的步骤容器时自动选择该容器
+	ContainerID string
+	// Command 在容器中执行的命令及参数
+	Command []string
+	// Env 追加的环境变量（KEY=VALUE），同名时覆盖步骤的环境变量
+	Env []string
+	// Cwd 工作目录，为空时使用步骤的工作目录
+	Cwd string
+	// TTY 为 true 时为命令分配伪终端，并将当前终端置为 raw 模式
+	TTY bool
+}
+
+// runningStepContainer 任务中正在运行的步骤容器。
+type runningStepContainer struct {
+	stepName    string
+	containerID string
+}
+
+// runningStepContainers 列出任务中正在运行的步骤容器：优先取 pipeline.json 记录的当前尝试的容器 ID，
+// 未记录时按与 listRunningTasks 一致的方式计算（取最近一次尝试）。子流水线步骤与审批步骤没有容器，不包含在内。
+func runningStepContainers(pipelineDirName string, runData *PipelineRunData) []runningStepContainer {
+	var out []runningStepContainer
+	for _, group := range runData.stepGroups() {
+		for i, step := range group.steps {
+			if step.Status != StatusRunning || step.Pipeline != "" || step.Approval != nil {
+				continue
+			}
+			containerID := step.ContainerID
+			if containerID == "" {
+				containerID = latestContainerID(pipelineDirName, group.indexBase+i, step)
+			}
+			out = append(out, runningStepContainer{stepName: step.Name, containerID: containerID})
+		}
+	}
+	return out
+}
+
+// resolveExecTarget 根据 taskId 与容器 ID 找到正在运行的步骤容器，返回容器 ID 与步骤 bundle 目录。
+func resolveExecTarget(arRoot, taskID, containerID string) (string, string, error) {
+	runDir, err := FindRunDirByTaskID(arRoot, taskID)
+	if err != nil {
+		return "", "", err
+	}
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		return "", "", fmt.Errorf("读取 pipeline.json 失败: %w", err)
+	}
+	running := runningStepContainers(filepath.Base(filepath.Dir(runDir)), runData)
+	if len(running) == 0 {
+		return "", "", fmt.Errorf("任务 %s 当前没有正在运行的步骤容器", taskID)
+	}
+	var ids []string
+	for _, r := range running {
+		ids = append(ids, r.containerID)
+	}
+	containerID = strings.TrimSpace(containerID)
+	if containerID == "" {
+		if len(running) > 1 {
+			return "", "", fmt.Errorf("任务 %s 有多个正在运行的步骤容器，请通过 -c 指定: %s", taskID, strings.Join(ids, ", "))
+		}
+		r := running[0]
+		return r.containerID, filepath.Join(runDir, "bundles", r.stepName), nil
+	}
+	for _, r := range running {
+		if r.containerID == containerID {
+			return r.containerID, filepath.Join(runDir, "bundles", r.stepName), nil
+		}
+	}
+	return "", "", fmt.Errorf("容器 %s 不是任务 %s 中正在运行的步骤容器（正在运行: %s）", containerID, taskID, strings.Join(ids, ", "))
+}
+
+// execProcessSpec 以步骤容器的进程配置（用户、工作目录、环境变量、capabilities 等）为基础构造 exec 进程。
+// bundle 中 config.json 的敏感值已掩码（见 writeRuntimeSpecForRun），敏感值可从 /run/secrets 读取。
+func execProcessSpec(bundleDir string, opts TaskExecOptions) *specs.Process {
+	process := &specs.Process{Cwd: "/", Env: []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"}}
+	if spec, err := readRuntimeSpec(bundleDir); err != nil {
+		logrus.Warnf("读取步骤容器进程配置失败，使用默认配置: %v", err)
+	} else {
+		base := *spec.Process
+		process = &base
+	}
+	process.Args = append([]string{}, opts.Command...)
+	process.Terminal = opts.TTY
+	if opts.Cwd != "" {
+		process.Cwd = opts.Cwd
+	}
+	env := append([]string{}, process.Env...)
+	if opts.TTY && !containsEnvKey(env, "TERM") {
+		env = append(env, "TERM=xterm")
+	}
+	for _, kv := range opts.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		if i := envIndex(env, key); i >= 0 {
+			env[i] = kv
+			continue
+		}
+		env = append(env, kv)
+	}
+	process.Env = env
+	return process
+}
+
+// envIndex 返回 env 中名为 key 的环境变量的下标，不存在时返回 -1。
+func envIndex(env []string, key string) int {
+	for i, kv := range env {
+		if k, _, _ := strings.Cut(kv, "="); k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// isTerminal 判断 f 是否为终端。
+func isTerminal(f *os.File) bool {
+	_, err := console.ConsoleFromFile(f)
+	return err == nil
+}
+
+// ExecInTask 在流水线任务正在运行的步骤容器中执行命令（进入步骤容器的各命名空间），
+// 标准输入输出连接到当前进程，返回命令的退出码。用于排查卡住的步骤，不影响步骤本身的执行与状态。
+func ExecInTask(arRoot, runtimeRoot string, opts TaskExecOptions) (int, error) {
+	if strings.TrimSpace(opts.TaskID) == "" {
+		return -1, fmt.Errorf("taskId 不能为空")
+	}
+	if len(opts.Command) == 0 {
+		return -1, fmt.Errorf("请指定要执行的命令，例如: -- sh")
+	}
+	containerID, bundleDir, err := resolveExecTarget(arRoot, opts.TaskID, opts.ContainerID)
+	if err != nil {
+		return -1, err
+	}
+	logrus.Infof("在容器 %s 中执行: %s", containerID, strings.Join(opts.Command, " "))
+	return execInContainer(runtimeRoot, containerID, execProcessSpec(bundleDir, opts))
+}
@@ -0,0 +1,129 @@
+//go:build linux
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/containerd/console"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/utils"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// execInContainer 通过 libcontainer.Load 加载 runtimeRoot 下正在运行的容器，在其命名空间中启动附加进程并等待退出，返回退出码。
+// process.Terminal 为 true 时参照 runc exec -t：经 console socket 取得容器内伪终端的 master 端，当前终端置为 raw 模式并同步窗口大小；
+// 否则直接连接当前进程的 stdin/stdout/stderr，并将 SIGINT/SIGTERM 转发给该进程。
+func execInContainer(runtimeRoot, containerID string, process *specs.Process) (int, error) {
+	c, err := libcontainer.Load(runtimeRoot, containerID)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrNotExist) {
+			return -1, fmt.Errorf("容器不存在或已退出: %s", containerID)
+		}
+		return -1, fmt.Errorf("加载 OCI 容器失败 %s: %w", containerID, err)
+	}
+	status, err := c.Status()
+	if err != nil {
+		return -1, fmt.Errorf("获取容器 %s 状态失败: %w", containerID, err)
+	}
+	if status != libcontainer.Running {
+		return -1, fmt.Errorf("容器 %s 当前状态为 %s，仅支持在 running 状态的容器中执行命令", containerID, status)
+	}
+
+	tty := process.Terminal
+	spec := *process
+	spec.Terminal = false
+	p, err := toLibcontainerProcess(&spec, nil, nil)
+	if err != nil {
+		return -1, err
+	}
+	p.Init = false
+
+	var parent *os.File
+	if tty {
+		var child *os.File
+		parent, child, err = utils.NewSockPair("console")
+		if err != nil {
+			return -1, fmt.Errorf("创建 console socket 失败: %w", err)
+		}
+		defer parent.Close()
+		defer child.Close()
+		p.ConsoleSocket = child
+		if current, err := console.ConsoleFromFile(os.Stdin); err == nil {
+			if size, err := current.Size(); err == nil {
+				p.ConsoleWidth, p.ConsoleHeight = size.Width, size.Height
+			}
+		}
+	} else {
+		p.Stdin = os.Stdin
+		p.Stdout = os.Stdout
+		p.Stderr = os.Stderr
+	}
+
+	if err := c.Run(p); err != nil {
+		return -1, fmt.Errorf("在容器 %s 中启动进程失败: %w", containerID, err)
+	}
+
+	var copied sync.WaitGroup
+	if tty {
+		p.ConsoleSocket.Close()
+		master, err := utils.RecvFile(parent)
+		if err != nil {
+			_ = p.Signal(syscall.SIGKILL)
+			_, _ = p.Wait()
+			return -1, fmt.Errorf("接收容器伪终端失败: %w", err)
+		}
+		pty, err := console.ConsoleFromFile(master)
+		if err != nil {
+			master.Close()
+			_ = p.Signal(syscall.SIGKILL)
+			_, _ = p.Wait()
+			return -1, fmt.Errorf("打开容器伪终端失败: %w", err)
+		}
+		defer pty.Close()
+		if current, err := console.ConsoleFromFile(os.Stdin); err == nil {
+			if err := current.SetRaw(); err != nil {
+				logrus.Warnf("设置终端 raw 模式失败: %v", err)
+			}
+			defer func() { _ = current.Reset() }()
+			resizeCh := make(chan os.Signal, 1)
+			signal.Notify(resizeCh, syscall.SIGWINCH)
+			defer signal.Stop(resizeCh)
+			go func() {
+				for range resizeCh {
+					_ = pty.ResizeFrom(current)
+				}
+			}()
+		}
+		go func() { _, _ = io.Copy(pty, os.Stdin) }()
+		copied.Add(1)
+		go func() {
+			defer copied.Done()
+			// 进程退出后 master 端读取返回 EIO，视为输出结束
+			_, _ = io.Copy(os.Stdout, pty)
+		}()
+	} else {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigCh)
+		go func() {
+			for sig := range sigCh {
+				_ = p.Signal(sig)
+			}
+		}()
+	}
+
+	state, err := p.Wait()
+	copied.Wait()
+	if err != nil && state == nil {
+		return -1, fmt.Errorf("等待容器 %s 中的进程退出失败: %w", containerID, err)
+	}
+	return exitCodeOf(state), nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package pipeline
+
+import (
+	"errors"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// execInContainer 在非 Linux 上为未实现，保证编译通过。
+func execInContainer(runtimeRoot, containerID string, process *specs.Process) (int, error) {
+	_ = runtimeRoot
+	_ = containerID
+	_ = process
+	return -1, errors.New("pipeline task exec 仅在 Linux 上支持")
+}
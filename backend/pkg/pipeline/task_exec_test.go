@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestResolveExecTarget_UsesTaskListContainerIDs(t *testing.T) {
+	arRoot := t.TempDir()
+	runDir := RunDir(arRoot, "k8s", "task")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runData := &PipelineRunData{
+		TaskID:       "task",
+		PipelineName: "k8s",
+		Status:       StatusRunning,
+		Steps: []PipelineStepState{
+			{Name: "start", Status: StatusSuccess},
+			{Name: "install", Status: StatusRunning, Attempts: []StepAttempt{{Attempt: 1, ContainerID: "ar_k8s_install_2"}, {Attempt: 2, ContainerID: "ar_k8s_install_2_retry2"}}},
+			{Name: "confirm", Status: StatusWaiting, Approval: &ApprovalSpec{Message: "ok?"}},
+		},
+	}
+	if err := WritePipelineJSON(runDir, runData); err != nil {
+		t.Fatal(err)
+	}
+
+	cid, bundleDir, err := resolveExecTarget(arRoot, "task", "")
+	if err != nil {
+		t.Fatalf("resolveExecTarget returned error: %v", err)
+	}
+	if cid != "ar_k8s_install_2_retry2" || bundleDir != filepath.Join(runDir, "bundles", "install") {
+		t.Fatalf("unexpected target %s %s", cid, bundleDir)
+	}
+	if _, _, err := resolveExecTarget(arRoot, "task", "ar_k8s_install_2"); err == nil {
+		t.Fatalf("expected error for a container of a previous attempt")
+	}
+
+	// 以步骤的进程配置为基础，追加的环境变量覆盖同名变量
+	spec := &specs.Spec{Process: &specs.Process{Args: []string{"install.sh"}, Cwd: "/work", Env: []string{"PATH=/bin", "MODE=prod"}, User: specs.User{UID: 1000}}}
+	data, _ := json.Marshal(spec)
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := execProcessSpec(bundleDir, TaskExecOptions{Command: []string{"sh"}, Env: []string{"MODE=debug"}, TTY: true})
+	if !reflect.DeepEqual(p.Args, []string{"sh"}) || p.Cwd != "/work" || p.User.UID != 1000 || !p.Terminal {
+		t.Fatalf("unexpected exec process %+v", p)
+	}
+	if !reflect.DeepEqual(p.Env, []string{"PATH=/bin", "MODE=debug", "TERM=xterm"}) {
+		t.Fatalf("unexpected exec env %v", p.Env)
+	}
+}
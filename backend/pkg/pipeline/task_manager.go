@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TaskManager 在 ar server 进程内托管流水线任务：任务在独立的 goroutine 中执行，生命周期跟随 server 而不是发起请求的连接，
+// 提交后立即返回 taskId。
+type TaskManager struct {
+	ctx    context.Context // server 生命周期，server 退出时取消全部任务
+	runner *Runner
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // 正在执行的 taskId -> 取消函数
+	wg      sync.WaitGroup
+}
+
+// NewTaskManager 构造 TaskManager，ctx 结束时取消所有托管的任务。
+func NewTaskManager(ctx context.Context, runner *Runner) *TaskManager {
+	return &TaskManager{
+		ctx:     ctx,
+		runner:  runner,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Submit 生成 pipeline.json 后在后台执行流水线并立即返回 taskId；模板或节点有误时同步返回错误。
+// timeout 大于 0 时作为整条流水线的执行时限（与 pipeline run --timeout 相同）。
+// 目标节点被其他任务占用时，waitNodes 为 true 则任务排队等待租约，否则同步返回错误。
+func (m *TaskManager) Submit(pipelineName string, nodes []RunNode, args map[string]interface{}, timeout time.Duration, waitNodes bool) (string, error) {
+	if !waitNodes {
+		if err := checkNodeLeases(m.runner.arRoot, runNodeIPs(nodes), nil); err != nil {
+			return "", err
+		}
+	}
+	run, err := m.runner.prepareRun(m.ctx, pipelineName, nodes, args, "")
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := m.taskContext(timeout, waitNodes)
+	m.mu.Lock()
+	m.cancels[run.runData.TaskID] = cancel
+	m.mu.Unlock()
+	m.start(ctx, cancel, run)
+	return run.runData.TaskID, nil
+}
+
+// Resume 按 opts 在后台恢复任务并立即返回；任务仍在执行或参数有误时返回错误，全部步骤已完成时不做任何事。
+// waitNodes 含义同 Submit。
+func (m *TaskManager) Resume(taskID string, opts ResumeOptions, waitNodes bool) error {
+	// 先占用 taskId 再准备恢复，避免并发的 Resume 都通过检查而重复执行同一任务
+	ctx, cancel := m.taskContext(0, waitNodes)
+	m.mu.Lock()
+	if _, ok := m.cancels[taskID]; ok {
+		m.mu.Unlock()
+		cancel()
+		return fmt.Errorf("任务 %s 正在执行，请先停止后再恢复", taskID)
+	}
+	m.cancels[taskID] = cancel
+	m.mu.Unlock()
+	release := func() {
+		m.mu.Lock()
+		delete(m.cancels, taskID)
+		m.mu.Unlock()
+		cancel()
+	}
+
+	run, err := m.runner.prepareResumeRun(taskID, opts)
+	if err != nil || run == nil {
+		release()
+		return err
+	}
+	if !waitNodes {
+		if err := checkNodeLeases(m.runner.arRoot, runNodeIPs(run.runData.RunNodes), leaseOwners(m.runner.arRoot, run.runData)); err != nil {
+			release()
+			return err
+		}
+	}
+	m.start(ctx, cancel, run)
+	return nil
+}
+
+// Cancel 取消正在执行的任务，返回该任务是否由本 TaskManager 托管。容器的停止与状态写回由 Runner.Stop 负责。
+func (m *TaskManager) Cancel(taskID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cancel, ok := m.cancels[taskID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// IsRunning 判断任务是否正在由本 TaskManager 执行。
+func (m *TaskManager) IsRunning(taskID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.cancels[taskID]
+	return ok
+}
+
+// Wait 等待所有托管的任务结束，用于 server 退出前收尾。
+func (m *TaskManager) Wait() {
+	m.wg.Wait()
+}
+
+// taskContext 构造托管任务的 ctx：随 server 退出取消，timeout 大于 0 时设置执行时限（暂停期间不计时），waitNodes 时排队等待节点租约。
+func (m *TaskManager) taskContext(timeout time.Duration, waitNodes bool) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	if timeout > 0 {
+		ctx = WithPipelineTimeout(ctx, timeout)
+	}
+	if waitNodes {
+		ctx = WithNodeLeaseWait(ctx)
+	}
+	return ctx, cancel
+}
+
+// start 在后台 goroutine 中执行已准备好的任务，执行结束后注销取消函数。调用方需已将 cancel 登记到 cancels。
+func (m *TaskManager) start(ctx context.Context, cancel context.CancelFunc, run *taskRun) {
+	taskID := run.runData.TaskID
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.cancels, taskID)
+			m.mu.Unlock()
+			cancel()
+		}()
+		logrus.Infof("后台执行流水线任务: pipeline=%s taskId=%s", run.runData.PipelineName, taskID)
+		if err := m.runner.execute(ctx, run.runDir, run.runData, run.completed); err != nil {
+			logrus.Errorf("后台流水线任务执行失败: pipeline=%s taskId=%s: %v", run.runData.PipelineName, taskID, err)
+			return
+		}
+		logrus.Infof("后台流水线任务执行完成: pipeline=%s taskId=%s", run.runData.PipelineName, taskID)
+	}()
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTaskManager_SubmitReturnsBeforeTaskFinishes(t *testing.T) {
+	arRoot := t.TempDir()
+	pipelinesDir := filepath.Join(arRoot, "pipelines")
+	if err := os.MkdirAll(pipelinesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pipelinesDir, "gate.template.json"), []byte(`[{"name": "gate", "approval": {}}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewTaskManager(context.Background(), NewRunner(arRoot, pipelinesDir, "", "", 0, StepContainerConfig{}))
+
+	if _, err := m.Submit("missing", []RunNode{{IP: "10.0.0.1"}}, nil, 0, false); err == nil {
+		t.Fatalf("expected template error to be returned synchronously")
+	}
+	taskID, err := m.Submit("gate", []RunNode{{IP: "10.0.0.1"}}, nil, 0, false)
+	if err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+	if !m.IsRunning(taskID) {
+		t.Fatalf("expected task %s to be running in background", taskID)
+	}
+	if err := m.Resume(taskID, ResumeOptions{}, false); err == nil {
+		t.Fatalf("expected resume of a running task to be refused")
+	}
+
+	if !m.Cancel(taskID) {
+		t.Fatalf("expected Cancel to find the task")
+	}
+	m.Wait()
+	runData, err := ReadPipelineJSON(RunDir(arRoot, "gate", taskID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runData.Status != StatusCancelled || m.IsRunning(taskID) {
+		t.Fatalf("expected cancelled task, got status %s", runData.Status)
+	}
+
+	// 并发恢复同一任务时只有一个能够执行，准备失败的恢复不占用 taskId
+	if err := m.Resume("missing", ResumeOptions{}, false); err == nil || m.IsRunning("missing") {
+		t.Fatalf("failed resume should return an error and release the taskId, got %v", err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.Resume(taskID, ResumeOptions{}, false); err == nil {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if started != 1 || !m.IsRunning(taskID) {
+		t.Fatalf("expected exactly one concurrent resume to start, got %d", started)
+	}
+	m.Cancel(taskID)
+	m.Wait()
+}
+
+func TestTaskManager_CLIStopCancelsManagedTask(t *testing.T) {
+	arRoot := t.TempDir()
+	pipelinesDir := filepath.Join(arRoot, "pipelines")
+	if err := os.MkdirAll(pipelinesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pipelinesDir, "gate.template.json"), []byte(`[{"name": "gate", "approval": {}}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewTaskManager(context.Background(), NewRunner(arRoot, pipelinesDir, "", "", 0, StepContainerConfig{}))
+	taskID, err := m.Submit("gate", []RunNode{{IP: "10.0.0.1"}}, nil, 0, false)
+	if err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+	runDir := RunDir(arRoot, "gate", taskID)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if runData, err := ReadPipelineJSON(runDir); err == nil && runData.Status == StatusWaiting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("approval step never started waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// CLI 的 task stop 不经过 TaskManager，托管执行的任务通过停止标记取消
+	if err := stopPipelineTask(arRoot, "", taskID); err != nil {
+		t.Fatalf("stopPipelineTask returned error: %v", err)
+	}
+	waited := make(chan struct{})
+	go func() {
+		m.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the managed task did not observe the CLI stop")
+	}
+	runData, err := ReadPipelineJSON(runDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runData.Status != StatusCancelled || m.IsRunning(taskID) {
+		t.Fatalf("expected cancelled task, got status %s", runData.Status)
+	}
+}
+
+func TestSubmitToServer(t *testing.T) {
+	var got struct {
+		Variables struct {
+			Input map[string]interface{} `json:"input"`
+		} `json:"variables"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"data": {"runPipeline": {"taskId": "123_4", "status": "pending"}}}`))
+	}))
+	defer srv.Close()
+
+	nodes := []RunNode{{IP: "10.0.0.1", IntranetIP: "192.168.0.1", Labels: []Label{{Key: "role", Value: "master"}}}}
+	taskID, err := submitToServer(srv.URL, "k8s", nodes, map[string]interface{}{"vip": "10.0.0.100"}, 30*time.Minute, true)
+	if err != nil {
+		t.Fatalf("submitToServer returned error: %v", err)
+	}
+	if taskID != "123_4" {
+		t.Fatalf("unexpected taskId %q", taskID)
+	}
+	if got.Variables.Input["args"] != `{"vip":"10.0.0.100"}` || got.Variables.Input["timeout"] != "30m0s" || got.Variables.Input["waitForNodes"] != true {
+		t.Fatalf("unexpected input %v", got.Variables.Input)
+	}
+}
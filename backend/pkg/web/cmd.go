@@ -14,6 +14,7 @@ import (
 	"github.com/tangxusc/ar/backend/pkg/command"
 	"github.com/tangxusc/ar/backend/pkg/config"
 	"github.com/tangxusc/ar/backend/pkg/container"
+	"github.com/tangxusc/ar/backend/pkg/pipeline"
 )
 
 var webServerPort string = "8080"
@@ -26,6 +27,10 @@ var ociContainerRemove bool = false
 // 要清理的容器 ID 前缀，默认与原设计保持一致：ar_。
 var containerNamePrefix string = "ar_"
 
+// 启动对账后是否自动恢复被中断的任务，以及是否强制清理仍在运行的孤儿容器。
+var autoResume bool = false
+var killOrphans bool = false
+
 func initLog() (io.Writer, error) {
 	if dir := filepath.Dir(logFilePath); dir != "" {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -72,20 +77,30 @@ func AddCommand(ctx context.Context, cancelFunc func(), rootCommand *cobra.Comma
 				logrus.Errorf("server start: 写入 PID 文件失败: %v", err)
 				return err
 			}
+			// 流水线任务由 server 进程托管，生命周期跟随 server 而不是 GraphQL 请求
+			arRoot := filepath.Dir(config.PipelinesDir)
+			runner := pipeline.NewRunner(arRoot, config.PipelinesDir, config.ImagesStoreDir, config.OciRuntimeRoot, config.MaxParallel, pipeline.ConfiguredStepContainerConfig())
+			tasks := pipeline.NewTaskManager(ctx, runner)
+			reconcileTasks(runner, tasks)
 			logrus.Infof("server start: 启动 web server 端口 %s", webServerPort)
-			if err := Start(ctx, logWriter); err != nil {
+			if err := Start(ctx, logWriter, tasks); err != nil {
 				logrus.Errorf("server start 失败: %v", err)
 				return err
 			}
 			logrus.Info("server start: 服务已启动，等待退出信号")
 			<-ctx.Done()
-			logrus.Info("server start: 收到退出信号，服务结束")
+			logrus.Info("server start: 收到退出信号，等待后台流水线任务取消完成")
+			tasks.Wait()
+			logrus.Info("server start: 服务结束")
 			return nil
 		},
 	}
 	startCmd.PersistentFlags().StringVar(&webServerPort, "web-server-port", "8080", "graphql web server port")
 	startCmd.PersistentFlags().StringVar(&logFilePath, "log-file-path", "/var/lib/ar/server.log", "log file path")
 	startCmd.PersistentFlags().StringVar(&pidFilePath, "pid-file-path", "/var/lib/ar/server.pid", "pid file path for stop command")
+	startCmd.PersistentFlags().IntVar(&config.MaxParallel, "max-parallel", 0, "max number of steps running concurrently in one pipeline task (0 means unlimited)")
+	startCmd.PersistentFlags().BoolVar(&autoResume, "auto-resume", false, "resume interrupted pipeline tasks after startup reconciliation")
+	startCmd.PersistentFlags().BoolVar(&killOrphans, "kill-orphans", false, "kill and remove containers still running for interrupted tasks during startup reconciliation")
 	serverCmd.AddCommand(startCmd)
 
 	stopCmd := &cobra.Command{
@@ -118,6 +133,34 @@ func AddCommand(ctx context.Context, cancelFunc func(), rootCommand *cobra.Comma
 	})
 }
 
+// reconcileTasks 对上次异常退出遗留的任务进行对账，开启 --auto-resume 时通过 TaskManager 恢复可安全恢复的顶层任务
+// （子流水线任务随父任务恢复）。对账失败仅记录日志，不影响 server 启动。
+func reconcileTasks(runner *pipeline.Runner, tasks *pipeline.TaskManager) {
+	reconciled, err := runner.Reconcile(killOrphans)
+	if err != nil {
+		logrus.Warnf("server start: 任务对账失败: %v", err)
+		return
+	}
+	logrus.Infof("server start: 任务对账完成，标记为 interrupted 的任务数: %d", len(reconciled))
+	if !autoResume {
+		return
+	}
+	for _, task := range reconciled {
+		if task.ParentTaskID != "" {
+			continue
+		}
+		if !task.Resumable {
+			logrus.Warnf("server start: 任务存在仍在运行的孤儿容器，跳过自动恢复: taskId=%s", task.TaskID)
+			continue
+		}
+		if err := tasks.Resume(task.TaskID, pipeline.ResumeOptions{}, true); err != nil {
+			logrus.Warnf("server start: 自动恢复任务失败: taskId=%s: %v", task.TaskID, err)
+			continue
+		}
+		logrus.Infof("server start: 已自动恢复任务: pipeline=%s taskId=%s", task.PipelineName, task.TaskID)
+	}
+}
+
 func writePIDFile(path string) error {
 	if dir := filepath.Dir(path); dir != "" {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/tangxusc/ar/backend/pkg/config"
 	"github.com/tangxusc/ar/backend/pkg/graph"
 	"github.com/tangxusc/ar/backend/pkg/graph/resolver"
+	"github.com/tangxusc/ar/backend/pkg/pipeline"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
@@ -16,9 +17,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Start(ctx context.Context, logWriter io.Writer) error {
+// Start 启动 GraphQL web server（非阻塞）。tasks 托管通过 GraphQL 提交的流水线任务。
+func Start(ctx context.Context, logWriter io.Writer, tasks *pipeline.TaskManager) error {
 	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{
-		Resolvers: &resolver.Resolver{},
+		Resolvers: &resolver.Resolver{Tasks: tasks},
 	}))
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})